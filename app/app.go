@@ -1,18 +1,28 @@
 package app
 
 import (
+	cmd2 "github.com/smtg-ai/claude-squad/cmd"
 	"github.com/smtg-ai/claude-squad/config"
+	"github.com/smtg-ai/claude-squad/humanize"
 	"github.com/smtg-ai/claude-squad/keys"
 	"github.com/smtg-ai/claude-squad/log"
 	"github.com/smtg-ai/claude-squad/session"
+	"github.com/smtg-ai/claude-squad/session/git"
+	"github.com/smtg-ai/claude-squad/session/tmux"
 	"github.com/smtg-ai/claude-squad/ui"
 	"github.com/smtg-ai/claude-squad/ui/overlay"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -20,13 +30,30 @@ import (
 
 const GlobalInstanceLimit = 10
 
+// listWidthStep is how many percent of the width the list grows or shrinks by per key press
+const listWidthStep = 5
+
 // Run is the main entrypoint into the application.
 func Run(ctx context.Context, program string, autoYes bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	h := newHome(ctx, program, autoYes)
+	if h.statusServer != nil {
+		defer h.statusServer.Close()
+	}
+	if path := h.appConfig.EventLogPath; path != "" {
+		defer session.StartEventLog(path)()
+	}
+
 	p := tea.NewProgram(
-		newHome(ctx, program, autoYes),
+		h,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(), // Mouse scroll
+		tea.WithoutSignalHandler(),
 	)
+	notifyPauseResumeSignals(ctx, p)
+	notifyShutdownSignals(ctx, p)
 	_, err := p.Run()
 	return err
 }
@@ -43,6 +70,18 @@ const (
 	stateHelp
 	// stateConfirm is the state when a confirmation modal is displayed.
 	stateConfirm
+	// stateChoice is the state when a multi-choice modal is displayed.
+	stateChoice
+)
+
+// continuousModeChange is how a duration entered for an instance that's already in continuous mode is applied
+type continuousModeChange int
+
+const (
+	// continuousModeExtend adds the duration to the time remaining
+	continuousModeExtend continuousModeChange = iota
+	// continuousModeReset starts the clock over with the duration
+	continuousModeReset
 )
 
 type home struct {
@@ -74,6 +113,55 @@ type home struct {
 	// Continuous mode state
 	continuousModeTarget  *session.Instance // Instance we're setting continuous mode for
 	isContinuousModeInput bool              // True when inputting duration
+	// continuousModeChange is how the entered duration changes continuous mode that's already running
+	continuousModeChange continuousModeChange
+
+	// isBranchInput is true when inputting the name of an existing branch to import
+	isBranchInput bool
+	// isTemplateInput is true when inputting the name of a template
+	isTemplateInput bool
+	// isQueueInput is true when inputting a prompt to queue for the selected instance
+	isQueueInput bool
+	// isSearchInput is true when inputting the text to search for in the preview
+	isSearchInput bool
+	// isKeysInput is true when inputting raw tmux keys to send to the selected instance
+	isKeysInput bool
+	// isRemoteInput is true when inputting the git remote the selected instance is pushed to
+	isRemoteInput bool
+	// pendingTemplate is the template used for the instance being created, if any
+	pendingTemplate *config.TemplateSpec
+
+	// focusMode is true if the list is collapsed to the selected session and the preview takes the full width
+	focusMode bool
+	// listWidthPercent is the share of the width taken by the list
+	listWidthPercent int
+
+	// capturing is true while the pane of the selected instance is captured for the preview, in the background.
+	// captureSeq numbers the captures.
+	capturing  bool
+	captureSeq int
+	// checkingMetadata is true while the panes of the instances are captured for the metadata update, in the
+	// background. metadataSeq numbers the checks.
+	checkingMetadata bool
+	metadataSeq      int
+	// attentionView is true if the list shows only the sessions waiting for the user, longest waiting first
+	attentionView bool
+
+	// tmuxServerDead is true once we've noticed the tmux server is gone, so that we only offer to recreate the
+	// sessions once
+	tmuxServerDead bool
+	// tmuxServerDeclined is true if the user declined to recreate the sessions. The instances are updated as usual
+	// then; each can be recovered by attaching to it.
+	tmuxServerDeclined bool
+
+	// statusServer serves the state of the instances over HTTP. Nil unless enabled in the config.
+	statusServer *statusServer
+
+	// busy is the long operation running in the background, if any. Most keys are ignored until it completes.
+	busy *busyOperation
+	// quitAfterBusy is set when the user quits while busy runs. The instances are saved and the app quits once it
+	// completes.
+	quitAfterBusy bool
 
 	// keySent is used to manage underlining menu items
 	keySent bool
@@ -96,6 +184,28 @@ type home struct {
 	textOverlay *overlay.TextOverlay
 	// confirmationOverlay displays confirmation modals
 	confirmationOverlay *overlay.ConfirmationOverlay
+	// confirmedMsg is the msg returned by the confirmed action, handled once the confirmation modal closes
+	confirmedMsg tea.Msg
+	// multiChoiceOverlay displays modals with more than two options
+	multiChoiceOverlay *overlay.MultiChoiceOverlay
+	// onChoice is called with the key of the selected choice when the multi-choice modal closes
+	onChoice func(key string) (tea.Model, tea.Cmd)
+	// onHelpDismiss is called when the help screen is dismissed. May be nil.
+	onHelpDismiss func() tea.Cmd
+
+	// errorHistory holds the most recent errors, oldest first, so that they can be read after they're hidden
+	errorHistory []errorEntry
+	// errorSeq counts the errors shown, so that hiding an error doesn't hide a newer one
+	errorSeq int
+}
+
+// maxErrorHistory is the number of errors kept in the error history
+const maxErrorHistory = 20
+
+// errorEntry is an error in the error history
+type errorEntry struct {
+	err  error
+	time time.Time
 }
 
 func newHome(ctx context.Context, program string, autoYes bool) *home {
@@ -111,6 +221,7 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 		fmt.Printf("Failed to initialize storage: %v\n", err)
 		os.Exit(1)
 	}
+	storage.SetCompact(appConfig.CompactState)
 
 	h := &home{
 		ctx:          ctx,
@@ -124,8 +235,17 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 		autoYes:      autoYes,
 		state:        stateDefault,
 		appState:     appState,
+		focusMode:    appState.GetFocusMode(),
 	}
+	h.menu.SetPromptAfterCreate(appConfig.PromptAfterCreate)
+	session.SetConfig(appConfig)
+	session.SetSafeMode(appConfig.SafeMode)
 	h.list = ui.NewList(&h.spinner, autoYes)
+	h.list.SetFocused(h.focusMode)
+	h.listWidthPercent = appConfig.GetListWidthPercent()
+	if percent := appState.GetListWidthPercent(); percent > 0 {
+		h.listWidthPercent = config.ClampListWidthPercent(percent)
+	}
 
 	// Load saved instances
 	instances, err := storage.LoadInstances()
@@ -141,6 +261,39 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 		if autoYes {
 			instance.AutoYes = true
 		}
+		// The diff content isn't saved in compact mode, so recompute it right away.
+		if appConfig.CompactState && !instance.Paused() {
+			if err := instance.UpdateDiffStats(); err != nil {
+				log.WarningLog.Printf("could not update diff stats for %s: %v", instance.Title, err)
+			}
+		}
+	}
+
+	if wrapper := appConfig.ProgramWrapper; wrapper != "" {
+		if err := session.CheckProgramWrapper(wrapper); err != nil {
+			log.ErrorLog.Print(err)
+			h.errBox.SetError(err)
+		}
+	}
+	if strings.TrimSpace(appConfig.DefaultProgram) == "" {
+		h.errBox.SetError(fmt.Errorf("default_program is empty in the config file, new sessions run %s", program))
+	}
+
+	if appConfig.CleanupZombieSessionsOnStart {
+		if _, err := h.cleanupZombieSessions(); err != nil {
+			log.ErrorLog.Print(err)
+		}
+	}
+
+	if addr := appConfig.GetStatusHTTPAddr(); addr != "" {
+		server, err := startStatusServer(addr)
+		if err != nil {
+			log.ErrorLog.Print(err)
+			h.errBox.SetError(err)
+		} else {
+			server.update(h.list.GetInstances())
+			h.statusServer = server
+		}
 	}
 
 	return h
@@ -149,8 +302,8 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 // updateHandleWindowSizeEvent sets the sizes of the components.
 // The components will try to render inside their bounds.
 func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
-	// List takes 30% of width, preview takes 70%
-	listWidth := int(float32(msg.Width) * 0.3)
+	// List takes listWidthPercent of the width, preview takes the rest
+	listWidth := msg.Width * m.listWidthPercent / 100
 	tabsWidth := msg.Width - listWidth
 
 	// Menu takes 10% of height, list and window take 90%
@@ -158,8 +311,14 @@ func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
 	menuHeight := msg.Height - contentHeight - 1     // minus 1 for error box
 	m.errBox.SetSize(int(float32(msg.Width)*0.9), 1) // error box takes 1 row
 
-	m.tabbedWindow.SetSize(tabsWidth, contentHeight)
-	m.list.SetSize(listWidth, contentHeight)
+	if m.focusMode {
+		// The selected session goes above the preview, which takes the full width
+		m.tabbedWindow.SetSize(msg.Width, contentHeight-ui.FocusedListHeight)
+		m.list.SetSize(msg.Width, ui.FocusedListHeight)
+	} else {
+		m.tabbedWindow.SetSize(tabsWidth, contentHeight)
+		m.list.SetSize(listWidth, contentHeight)
+	}
 
 	if m.textInputOverlay != nil {
 		m.textInputOverlay.SetSize(int(float32(msg.Width)*0.6), int(float32(msg.Height)*0.4))
@@ -180,36 +339,91 @@ func (m *home) Init() tea.Cmd {
 	// update the spinner, which sends a new spinner.TickMsg. I think this lasts forever lol.
 	return tea.Batch(
 		m.spinner.Tick,
-		func() tea.Msg {
-			time.Sleep(100 * time.Millisecond)
-			return previewTickMsg{}
-		},
-		tickUpdateMetadataCmd,
+		m.tickPreviewCmd(),
+		m.tickUpdateMetadataCmd(),
 	)
 }
 
 func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case busyDoneMsg:
+		return m, m.finishBusy(msg)
+	case pauseAllMsg:
+		return m, m.pauseAll()
+	case resumeAllMsg:
+		return m, m.resumeAll()
+	case shutdownMsg:
+		return m, m.shutdown()
 	case hideErrMsg:
-		m.errBox.Clear()
+		if msg.seq == m.errorSeq {
+			m.errBox.Clear()
+		}
 	case previewTickMsg:
 		cmd := m.instanceChanged()
 		return m, tea.Batch(
 			cmd,
-			func() tea.Msg {
-				time.Sleep(100 * time.Millisecond)
-				return previewTickMsg{}
-			},
+			m.tickPreviewCmd(),
 		)
+	case previewCapturedMsg:
+		m.capturing = false
+		selected := m.list.GetSelectedInstance()
+		if msg.instance != selected {
+			// The selection changed while capturing, so the content is stale. Capture the new selection right away.
+			return m, m.instanceChanged()
+		}
+		if msg.err != nil {
+			return m, m.handleError(msg.err)
+		}
+		m.tabbedWindow.SetPreviewContent(msg.instance, msg.content)
+		return m, nil
+	case previewCaptureTimeoutMsg:
+		if m.capturing && msg.seq == m.captureSeq {
+			// The capture was killed. Let the next tick try again.
+			m.capturing = false
+			m.captureSeq++
+			m.tabbedWindow.SetPreviewCapturing(msg.instance)
+		}
+		return m, nil
 	case keyupMsg:
 		m.menu.ClearKeydown()
 		return m, nil
 	case tickUpdateMetadataMessage:
+		return m, m.checkMetadata()
+	case metadataCheckTimeoutMsg:
+		if m.checkingMetadata && msg.seq == m.metadataSeq {
+			// The check was killed. Keep ticking, tmux may recover.
+			m.checkingMetadata = false
+			m.metadataSeq++
+			return m, tea.Batch(
+				m.handleError(fmt.Errorf("tmux is not responding, session statuses are not being updated")),
+				m.tickUpdateMetadataCmd(),
+			)
+		}
+		return m, nil
+	case metadataCheckedMsg:
+		m.checkingMetadata = false
+		if m.checkTmuxServer(msg.serverRunning) {
+			// Every session is gone. Don't let crash detection restart them one by one.
+			return m, m.tickUpdateMetadataCmd()
+		}
 		for _, instance := range m.list.GetInstances() {
+			if m.busy != nil && m.busy.instance == instance {
+				// The background operation owns the instance until it completes
+				continue
+			}
+			// Checked out instances are usually paused, so check this before skipping them
+			instance.UpdateCheckedOut()
 			if !instance.Started() || instance.Paused() {
 				continue
 			}
-			updated, prompt := instance.HasUpdated()
+			check, ok := msg.checks[instance]
+			if !ok {
+				// Started or resumed while the panes were captured, it's checked on the next tick
+				continue
+			}
+			wasReady := instance.Status == session.Ready
+			updated, prompt := instance.ApplyPaneCheck(check)
+			instance.SetPromptWaiting(!updated && prompt && (!instance.AutoYes || session.SafeMode()))
 			if updated {
 				instance.SetStatus(session.Running)
 			} else {
@@ -222,9 +436,17 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if err := instance.UpdateDiffStats(); err != nil {
 				log.WarningLog.Printf("could not update diff stats: %v", err)
 			}
+			if _, err := instance.AutoCommit(m.appConfig.GetAutoCommitInterval()); err != nil {
+				log.WarningLog.Printf("could not autosave '%s': %v", instance.Title, err)
+			}
+			// Send the next queued prompt once the instance finishes its current task
+			if !wasReady && instance.Status == session.Ready {
+				m.sendQueuedPrompt(instance)
+			}
 			
-			// Crash detection and auto-restart
-			if instance.DetectCrashAndRestart() {
+			// Crash detection and auto-restart. Without a tmux server, every session looks crashed; the user was
+			// offered to recreate them all instead.
+			if msg.serverRunning && instance.DetectCrashAndRestart(m.appConfig.GetMaxRestartAttempts(), m.appConfig.GetRestartCooldown()) {
 				// Session was restarted, skip other checks this cycle
 				continue
 			}
@@ -240,11 +462,18 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if m.list.GetSelectedInstance() == instance {
 						m.errBox.SetError(fmt.Errorf("⏰ Continuous mode expired for '%s'", instance.Title))
 					}
+				} else if maxRuntime := m.appConfig.GetContinuousModeMaxRuntime(); instance.GetContinuousModeRuntime() >= maxRuntime {
+					// Safety cap so a forgotten session doesn't run up a huge bill, even in indefinite mode
+					instance.DisableContinuousMode()
+					log.WarningLog.Printf("continuous mode for '%s' hit the %v cap and was disabled", instance.Title, maxRuntime)
+					m.errBox.SetError(fmt.Errorf("⛔ Continuous mode for '%s' hit the %v safety cap and was disabled",
+						instance.Title, maxRuntime))
 				}
 			}
 			
 			// Watchdog functionality
-			if instance.DetectStall(m.appConfig.StallTimeoutSeconds, m.appConfig.ContinuousModeTimeoutSeconds) {
+			if check.Err == nil && instance.DetectStall(check.Content, m.appConfig.StallTimeoutSeconds,
+				m.appConfig.ContinuousModeTimeoutSeconds) {
 				enabled, _, stallCount := instance.GetWatchdogStatus()
 				if enabled && stallCount < m.appConfig.MaxContinueAttempts {
 					if err := instance.InjectContinue(m.appConfig.ContinueCommands); err != nil {
@@ -258,7 +487,13 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		}
-		return m, tickUpdateMetadataCmd
+		if m.statusServer != nil {
+			m.statusServer.update(m.list.GetInstances())
+		}
+		if m.attentionView && m.state == stateDefault {
+			m.list.SetFilter(m.attentionInstances())
+		}
+		return m, m.tickUpdateMetadataCmd()
 	case tea.MouseMsg:
 		// Handle mouse wheel scrolling in the diff view
 		if m.tabbedWindow.IsInDiffTab() {
@@ -288,12 +523,51 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
+		if m.busy != nil {
+			m.errBox.SetStatus(fmt.Sprintf("%s %s", m.spinner.View(), m.busy.status))
+		}
 		return m, cmd
 	}
 	return m, nil
 }
 
 func (m *home) handleQuit() (tea.Model, tea.Cmd) {
+	running := 0
+	for _, instance := range m.list.GetInstances() {
+		if instance.Started() && !instance.Paused() {
+			running++
+		}
+	}
+	if !m.appConfig.ConfirmQuit || running == 0 {
+		return m.saveAndQuit()
+	}
+
+	message := fmt.Sprintf("[!] %d session(s) running — quit?", running)
+	return m, m.chooseAction(message, []overlay.Choice{
+		{Key: "s", Label: "Quit and save"},
+		{Key: "d", Label: "Quit without saving"},
+		{Key: "c", Label: "Cancel"},
+	}, func(key string) (tea.Model, tea.Cmd) {
+		switch key {
+		case "s":
+			return m.saveAndQuit()
+		case "d":
+			log.WarningLog.Printf("quitting without saving instances")
+			return m, tea.Quit
+		}
+		return m, nil
+	})
+}
+
+// saveAndQuit persists all instances and quits the application. If a background operation is running, it waits for
+// it to complete first, so that its result is saved too; quitting again doesn't wait.
+func (m *home) saveAndQuit() (tea.Model, tea.Cmd) {
+	if m.busy != nil && !m.quitAfterBusy {
+		m.quitAfterBusy = true
+		m.busy.status = fmt.Sprintf("%s Quitting once done, press q again to quit now.", m.busy.status)
+		m.errBox.SetStatus(fmt.Sprintf("%s %s", m.spinner.View(), m.busy.status))
+		return m, nil
+	}
 	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
 		return m, m.handleError(err)
 	}
@@ -307,7 +581,7 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 		m.keySent = false
 		return nil, false
 	}
-	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm {
+	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm || m.state == stateChoice {
 		return nil, false
 	}
 	// If it's in the global keymap, we should try to highlight it.
@@ -322,6 +596,10 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 	if name == keys.KeyShiftDown || name == keys.KeyShiftUp {
 		return nil, false
 	}
+	// n and N move between search matches while searching the preview
+	if m.tabbedWindow.IsPreviewSearching() && (name == keys.KeyNew || name == keys.KeyPrompt) {
+		return nil, false
+	}
 
 	// Skip the menu highlighting if the key is not in the map or we are using the shift up and down keys.
 	// TODO: cleanup: when you press enter on stateNew, we use keys.KeySubmitName. We should unify the keymap.
@@ -349,6 +627,7 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		if msg.String() == "ctrl+c" {
 			m.state = stateDefault
 			m.promptAfterName = false
+			m.pendingTemplate = nil
 			m.list.Kill()
 			return m, tea.Sequence(
 				tea.WindowSize(),
@@ -360,6 +639,11 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		}
 
 		instance := m.list.GetInstances()[m.list.NumInstances()-1]
+		if msg.String() == "ctrl+y" {
+			// Opt this instance in or out of AutoYes regardless of the global setting.
+			instance.AutoYes = !instance.AutoYes
+			return m, nil
+		}
 		switch msg.Type {
 		// Start the instance (enable previews etc) and go back to the main menu state.
 		case tea.KeyEnter:
@@ -367,42 +651,31 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				return m, m.handleError(fmt.Errorf("title cannot be empty"))
 			}
 
-			if err := instance.Start(true); err != nil {
-				m.list.Kill()
-				m.state = stateDefault
-				return m, m.handleError(err)
-			}
+			template := m.pendingTemplate
+			m.pendingTemplate = nil
+
 			// Initialize watchdog for new instances
-			instance.InitializeWatchdog(m.appConfig.WatchdogEnabled)
-			
-			// Save after adding new instance
-			if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
-				return m, m.handleError(err)
+			watchdogEnabled := m.appConfig.WatchdogEnabled
+			if template != nil && template.WatchdogEnabled != nil {
+				watchdogEnabled = *template.WatchdogEnabled
 			}
-			// Instance added successfully, call the finalizer.
-			m.newInstanceFinalizer()
-			if m.autoYes {
-				instance.AutoYes = true
+			if instance.CloneURL() != "" {
+				// Cloning takes a while, so it runs in the background
+				m.state = stateDefault
+				m.menu.SetState(ui.StateDefault)
+				return m, tea.Batch(tea.WindowSize(), m.cloneNewInstance(instance, watchdogEnabled, template))
 			}
-
-			m.newInstanceFinalizer()
-			m.state = stateDefault
-			if m.promptAfterName {
-				m.state = statePrompt
-				m.menu.SetState(ui.StatePrompt)
-				// Initialize the text input overlay
-				m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", "")
+			if err := m.finalizeNewInstance(instance, watchdogEnabled, m.newInstanceFinalizer); err != nil {
+				m.state = stateDefault
 				m.promptAfterName = false
-			} else {
 				m.menu.SetState(ui.StateDefault)
-				m.showHelpScreen(helpTypeInstanceStart, nil)
+				return m, tea.Batch(tea.WindowSize(), m.instanceChanged(), m.handleError(err))
 			}
 
+			m.state = stateDefault
+			m.showNewInstance(template)
 			return m, tea.Batch(tea.WindowSize(), m.instanceChanged())
 		case tea.KeyRunes:
-			if len(instance.Title) >= 32 {
-				return m, m.handleError(fmt.Errorf("title cannot be longer than 32 characters"))
-			}
 			if err := instance.SetTitle(instance.Title + string(msg.Runes)); err != nil {
 				return m, m.handleError(err)
 			}
@@ -420,6 +693,7 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		case tea.KeyEsc:
 			m.list.Kill()
 			m.state = stateDefault
+			m.pendingTemplate = nil
 			m.instanceChanged()
 
 			return m, tea.Sequence(
@@ -438,6 +712,55 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 
 		// Check if the form was submitted or canceled
 		if shouldClose {
+			if m.isBranchInput && m.textInputOverlay.IsSubmitted() {
+				branch := strings.TrimSpace(m.textInputOverlay.GetValue())
+				m.isBranchInput = false
+				m.textInputOverlay = nil
+				m.state = stateDefault
+				m.menu.SetState(ui.StateDefault)
+				return m.importBranch(branch)
+			}
+			if m.isTemplateInput && m.textInputOverlay.IsSubmitted() {
+				name := strings.TrimSpace(m.textInputOverlay.GetValue())
+				m.isTemplateInput = false
+				m.textInputOverlay = nil
+				m.state = stateDefault
+				m.menu.SetState(ui.StateDefault)
+				return m.newFromTemplate(name)
+			}
+			if m.isQueueInput && m.textInputOverlay.IsSubmitted() {
+				prompt := m.textInputOverlay.GetValue()
+				m.isQueueInput = false
+				m.textInputOverlay = nil
+				m.state = stateDefault
+				m.menu.SetState(ui.StateDefault)
+				return m, tea.Sequence(tea.WindowSize(), m.queuePrompt(prompt))
+			}
+			if m.isSearchInput && m.textInputOverlay.IsSubmitted() {
+				query := m.textInputOverlay.GetValue()
+				m.isSearchInput = false
+				m.textInputOverlay = nil
+				m.state = stateDefault
+				m.menu.SetState(ui.StateDefault)
+				m.tabbedWindow.SetPreviewSearch(query)
+				return m, tea.WindowSize()
+			}
+			if m.isKeysInput && m.textInputOverlay.IsSubmitted() {
+				sequence := m.textInputOverlay.GetValue()
+				m.isKeysInput = false
+				m.textInputOverlay = nil
+				m.state = stateDefault
+				m.menu.SetState(ui.StateDefault)
+				return m, tea.Sequence(tea.WindowSize(), m.sendRawKeys(sequence))
+			}
+			if m.isRemoteInput && m.textInputOverlay.IsSubmitted() {
+				remote := m.textInputOverlay.GetValue()
+				m.isRemoteInput = false
+				m.textInputOverlay = nil
+				m.state = stateDefault
+				m.menu.SetState(ui.StateDefault)
+				return m, tea.Sequence(tea.WindowSize(), m.setRemote(remote))
+			}
 			if m.textInputOverlay.IsSubmitted() {
 				// Form was submitted, process the input
 				selected := m.list.GetSelectedInstance()
@@ -447,71 +770,40 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				
 				// Check if we're setting continuous mode duration
 				if m.isContinuousModeInput && m.continuousModeTarget != nil {
-					// Parse duration
-					durationStr := strings.TrimSpace(m.textInputOverlay.GetValue())
-					var duration time.Duration
-					var err error
-					
-					if durationStr != "" {
-						// Parse duration string (e.g., "2h", "30m", "1h30m")
-						duration, err = time.ParseDuration(durationStr)
-						if err != nil {
-							// Try adding common suffixes if parsing fails
-							if !strings.ContainsAny(durationStr, "hms") {
-								// Assume hours if no unit specified
-								duration, err = time.ParseDuration(durationStr + "h")
-							}
-							
-							if err != nil {
-								m.isContinuousModeInput = false
-								m.continuousModeTarget = nil
-								m.textInputOverlay = nil
-								m.state = stateDefault
-								return m, tea.Sequence(
-									tea.WindowSize(),
-									func() tea.Msg {
-										m.menu.SetState(ui.StateDefault)
-										return nil
-									},
-									m.handleError(fmt.Errorf("invalid duration format: %s", durationStr)),
-								)
-							}
-						}
-					}
-					
-					// Validate duration limits
-					const MaxContinuousModeDuration = 24 * time.Hour
-					const LongDurationThreshold = 2 * time.Hour
-					
-					if duration > MaxContinuousModeDuration {
-						m.isContinuousModeInput = false
-						m.continuousModeTarget = nil
-						m.textInputOverlay = nil
-						m.state = stateDefault
-						return m, tea.Sequence(
-							tea.WindowSize(),
-							func() tea.Msg {
-								m.menu.SetState(ui.StateDefault)
-								return nil
-							},
-							m.handleError(fmt.Errorf("duration cannot exceed 24 hours")),
-						)
+					duration, err := parseContinuousModeDuration(m.textInputOverlay.GetValue())
+					if err != nil {
+						// Keep the overlay open so the input can be corrected
+						m.textInputOverlay.SetError(err.Error())
+						return m, nil
 					}
-					
+
 					// Warn for long durations
-					if duration > LongDurationThreshold {
+					if duration > longContinuousModeDuration {
 						// For now, just log a warning. In future, could add confirmation
 						log.WarningLog.Printf("setting continuous mode for long duration: %v", duration)
 					}
 					
-					// Set continuous mode with duration
-					m.continuousModeTarget.SetContinuousModeDuration(duration)
-					m.continuousModeTarget.ToggleContinuousMode()
-					
 					modeText := "enabled (indefinite duration)"
 					if duration > 0 {
 						modeText = fmt.Sprintf("enabled for %v", duration)
 					}
+
+					switch {
+					case !m.continuousModeTarget.IsContinuousMode():
+						// Set continuous mode with duration
+						m.continuousModeTarget.SetContinuousModeDuration(duration)
+						m.continuousModeTarget.ToggleContinuousMode()
+					case duration == 0:
+						// Without a duration, both changes make it run indefinitely
+						m.continuousModeTarget.SetContinuousModeDuration(0)
+						modeText = "now indefinite"
+					case m.continuousModeChange == continuousModeReset:
+						m.continuousModeTarget.ResetContinuousMode(duration)
+						modeText = fmt.Sprintf("set to %v from now", duration)
+					default:
+						m.continuousModeTarget.ExtendContinuousMode(duration)
+						modeText = fmt.Sprintf("extended by %v", duration)
+					}
 					
 					// Capture the title before setting continuousModeTarget to nil
 					targetTitle := m.continuousModeTarget.Title
@@ -530,9 +822,17 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 						m.handleError(fmt.Errorf("✓ Continuous mode %s for '%s'", modeText, targetTitle)),
 					)
 				} else {
-					// Regular prompt handling
-					if err := selected.SendPrompt(m.textInputOverlay.GetValue()); err != nil {
+					// Regular prompt handling. The startup prompt is still waiting for the program to be ready, so
+					// this one waits its turn behind it.
+					if selected.QueueLength() > 0 {
+						selected.EnqueuePrompt(m.textInputOverlay.GetValue())
+						if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+							return m, m.handleError(err)
+						}
+					} else if err := selected.SendPrompt(m.textInputOverlay.GetValue()); err != nil {
 						return m, m.handleError(err)
+					} else {
+						selected.ResetStallCount()
 					}
 				}
 			}
@@ -543,6 +843,12 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			m.promptAfterName = false
 			m.isContinuousModeInput = false
 			m.continuousModeTarget = nil
+			m.isBranchInput = false
+			m.isTemplateInput = false
+			m.isQueueInput = false
+			m.isSearchInput = false
+			m.isKeysInput = false
+			m.isRemoteInput = false
 			return m, tea.Sequence(
 				tea.WindowSize(),
 				func() tea.Msg {
@@ -562,13 +868,61 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 	if m.state == stateConfirm {
 		shouldClose := m.confirmationOverlay.HandleKeyPress(msg)
 		if shouldClose {
-			m.state = stateDefault
+			// The confirmed action may have opened another overlay, e.g. to show an error in detail
+			if m.state == stateConfirm {
+				m.state = stateDefault
+			}
 			m.confirmationOverlay = nil
+			// Hand the result of the action to Update, e.g. so that an error is shown and hidden again
+			if confirmed := m.confirmedMsg; confirmed != nil {
+				m.confirmedMsg = nil
+				return m, func() tea.Msg { return confirmed }
+			}
 			return m, nil
 		}
 		return m, nil
 	}
 
+	// Handle multi-choice state
+	if m.state == stateChoice {
+		shouldClose := m.multiChoiceOverlay.HandleKeyPress(msg)
+		if shouldClose {
+			selected := m.multiChoiceOverlay.Selected()
+			onChoice := m.onChoice
+			m.state = stateDefault
+			m.multiChoiceOverlay = nil
+			m.onChoice = nil
+			if selected != "" && onChoice != nil {
+				return onChoice(selected)
+			}
+		}
+		return m, nil
+	}
+
+	if m.busy != nil && msg.String() != "ctrl+c" && msg.String() != "q" {
+		// Only allow looking around and quitting until the background operation completes
+		switch keys.GlobalKeyStringsMap[msg.String()] {
+		case keys.KeyUp, keys.KeyDown, keys.KeyTab, keys.KeyHelp, keys.KeyErrors, keys.KeyClearError:
+		default:
+			return m, m.handleError(fmt.Errorf("please wait: %s", m.busy.status))
+		}
+	}
+
+	// While searching the preview, n/N move between the matches and esc ends the search
+	if m.tabbedWindow.IsPreviewSearching() {
+		switch msg.String() {
+		case "n":
+			m.tabbedWindow.NextPreviewMatch()
+			return m, nil
+		case "N":
+			m.tabbedWindow.PrevPreviewMatch()
+			return m, nil
+		case "esc":
+			m.tabbedWindow.ClearPreviewSearch()
+			return m, nil
+		}
+	}
+
 	// Handle quit commands first
 	if msg.String() == "ctrl+c" || msg.String() == "q" {
 		return m.handleQuit()
@@ -588,73 +942,185 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			return m, nil
 		}
 		
-		// If continuous mode is currently enabled, just disable it
+		// If continuous mode is currently enabled, offer to change its duration or disable it
 		if selected.IsContinuousMode() {
-			selected.ToggleContinuousMode()
-			log.InfoLog.Printf("continuous mode disabled for '%s'", selected.Title)
-			return m, m.handleError(fmt.Errorf("✓ Continuous mode disabled for '%s'", selected.Title))
+			message := fmt.Sprintf("Continuous mode is on for '%s' (indefinite)", selected.Title)
+			var choices []overlay.Choice
+			// Indefinite continuous mode has no time left to extend
+			if remaining := selected.GetContinuousModeTimeRemainingFormatted(); remaining != "" {
+				message = fmt.Sprintf("Continuous mode is on for '%s' (%s left)", selected.Title, remaining)
+				choices = append(choices, overlay.Choice{Key: "e", Label: "Extend the time left"})
+			}
+			choices = append(choices,
+				overlay.Choice{Key: "s", Label: "Set a new duration, starting now"},
+				overlay.Choice{Key: "x", Label: "Turn off"},
+				overlay.Choice{Key: "c", Label: "Cancel"},
+			)
+			return m, m.chooseAction(message, choices, func(key string) (tea.Model, tea.Cmd) {
+				switch key {
+				case "e":
+					m.promptContinuousModeDuration(selected, continuousModeExtend,
+						"Extend by minutes or e.g. '30m', '2h' (max 24h), or press Enter to run indefinitely:")
+				case "s":
+					m.promptContinuousModeDuration(selected, continuousModeReset,
+						"Enter the new duration from now in minutes or as e.g. '30m', '2h' (max 24h), or press Enter for indefinite:")
+				case "x":
+					selected.ToggleContinuousMode()
+					log.InfoLog.Printf("continuous mode disabled for '%s'", selected.Title)
+					return m, m.handleError(fmt.Errorf("✓ Continuous mode disabled for '%s'", selected.Title))
+				}
+				return m, nil
+			})
 		}
 		
 		// Otherwise, show duration input
-		m.state = statePrompt
-		m.menu.SetState(ui.StatePrompt)
-		m.textInputOverlay = overlay.NewTextInputOverlay(
-			"Enter duration (e.g., '30m', '2h', '1h30m', max 24h) or press Enter for indefinite:", 
-			"",
-		)
-		
-		// Store which instance we're setting continuous mode for
-		m.continuousModeTarget = selected
-		m.isContinuousModeInput = true
-		
+		m.promptContinuousModeDuration(selected, continuousModeExtend,
+			"Enter duration in minutes or as e.g. '30m', '2h', '1h30m' (max 24h), or press Enter for indefinite:")
 		return m, nil
-	case keys.KeyPrompt:
+	case keys.KeyPrompt, keys.KeyNew:
 		if m.list.NumInstances() >= GlobalInstanceLimit {
 			return m, m.handleError(
 				fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
 		}
 		instance, err := session.NewInstance(session.InstanceOptions{
-			Title:   "",
-			Path:    ".",
-			Program: m.program,
+			Title:          "",
+			Path:           ".",
+			Program:        m.program,
+			AutoYes:        m.autoYes,
+			MaxTitleLength: m.appConfig.GetMaxTitleLength(),
 		})
 		if err != nil {
 			return m, m.handleError(err)
 		}
+		if err := session.CheckNotInWorktree(instance.Path, m.list.GetInstances()); err != nil {
+			return m, m.handleError(err)
+		}
 
 		m.newInstanceFinalizer = m.list.AddInstance(instance)
 		m.list.SetSelectedInstance(m.list.NumInstances() - 1)
 		m.state = stateNew
 		m.menu.SetState(ui.StateNewInstance)
-		m.promptAfterName = true
+		// prompt_after_create swaps the keys, so that the one used most does what's wanted most
+		m.promptAfterName = (name == keys.KeyPrompt) != m.appConfig.PromptAfterCreate
 
 		return m, nil
-	case keys.KeyNew:
+	case keys.KeyImportBranch:
 		if m.list.NumInstances() >= GlobalInstanceLimit {
 			return m, m.handleError(
 				fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
 		}
-		instance, err := session.NewInstance(session.InstanceOptions{
-			Title:   "",
-			Path:    ".",
-			Program: m.program,
+		m.state = statePrompt
+		m.menu.SetState(ui.StatePrompt)
+		m.textInputOverlay = overlay.NewTextInputOverlay("Enter the name of an existing branch:", "")
+		m.textInputOverlay.SetPlaceholder("e.g., feature/login")
+		m.isBranchInput = true
+		return m, tea.WindowSize()
+	case keys.KeyTemplate:
+		if len(m.appConfig.Templates) == 0 {
+			return m, m.handleError(fmt.Errorf("no templates configured: add them under \"templates\" in the config file"))
+		}
+		if m.list.NumInstances() >= GlobalInstanceLimit {
+			return m, m.handleError(
+				fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
+		}
+		names := make([]string, 0, len(m.appConfig.Templates))
+		for name := range m.appConfig.Templates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		m.state = statePrompt
+		m.menu.SetState(ui.StatePrompt)
+		m.textInputOverlay = overlay.NewTextInputOverlay(
+			fmt.Sprintf("Enter template name (%s):", strings.Join(names, ", ")), "")
+		m.textInputOverlay.SetPlaceholder(names[0])
+		m.isTemplateInput = true
+		return m, tea.WindowSize()
+	case keys.KeyQueuePrompt:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || selected.Paused() {
+			return m, nil
+		}
+		m.state = statePrompt
+		m.menu.SetState(ui.StatePrompt)
+		m.textInputOverlay = overlay.NewTextInputOverlay(
+			fmt.Sprintf("Queue a prompt for '%s' (%d queued, sent when ready):", selected.Title, selected.QueueLength()), "")
+		m.textInputOverlay.SetPlaceholder("")
+		m.isQueueInput = true
+		return m, tea.WindowSize()
+	case keys.KeySafeMode:
+		session.SetSafeMode(!session.SafeMode())
+		if session.SafeMode() {
+			log.InfoLog.Printf("safe mode on")
+			return m, m.handleError(fmt.Errorf("🛑 Safe mode on: no auto-yes, watchdog, crash restarts or queued prompts"))
+		}
+		log.InfoLog.Printf("safe mode off")
+		return m, m.handleError(fmt.Errorf("✓ Safe mode off"))
+	case keys.KeyPing:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() || selected.Paused() {
+			return m, nil
+		}
+		var responsive bool
+		return m, m.runBusy(selected, fmt.Sprintf("Pinging '%s'...", selected.Title), func() error {
+			var err error
+			responsive, err = selected.Ping()
+			return err
+		}, func() tea.Cmd {
+			if !responsive {
+				return m.handleError(fmt.Errorf("'%s' didn't react to the ping, its program looks hung", selected.Title))
+			}
+			return m.handleError(fmt.Errorf("✓ '%s' is responsive", selected.Title))
 		})
-		if err != nil {
-			return m, m.handleError(err)
+	case keys.KeyRemote:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() {
+			return m, nil
 		}
-
-		m.newInstanceFinalizer = m.list.AddInstance(instance)
-		m.list.SetSelectedInstance(m.list.NumInstances() - 1)
-		m.state = stateNew
-		m.menu.SetState(ui.StateNewInstance)
-
-		return m, nil
+		m.state = statePrompt
+		m.menu.SetState(ui.StatePrompt)
+		m.textInputOverlay = overlay.NewTextInputOverlay(
+			fmt.Sprintf("Git remote to push '%s' to (empty for origin):", selected.Title), selected.Remote)
+		m.textInputOverlay.SetPlaceholder("origin")
+		m.isRemoteInput = true
+		return m, tea.WindowSize()
+	case keys.KeySendKeys:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || selected.Paused() {
+			return m, nil
+		}
+		m.state = statePrompt
+		m.menu.SetState(ui.StatePrompt)
+		m.textInputOverlay = overlay.NewTextInputOverlay(
+			fmt.Sprintf("Send keys to '%s' in tmux notation, e.g. C-c or Escape Up Enter:", selected.Title), "")
+		m.textInputOverlay.SetPlaceholder("")
+		m.isKeysInput = true
+		return m, tea.WindowSize()
+	case keys.KeySearch:
+		if m.tabbedWindow.IsInDiffTab() || m.list.GetSelectedInstance() == nil {
+			return m, nil
+		}
+		m.state = statePrompt
+		m.menu.SetState(ui.StatePrompt)
+		m.textInputOverlay = overlay.NewTextInputOverlay("Search the preview (n/N for next/previous, esc to stop):", "")
+		m.textInputOverlay.SetPlaceholder("")
+		m.isSearchInput = true
+		return m, tea.WindowSize()
 	case keys.KeyUp:
 		m.list.Up()
 		return m, m.instanceChanged()
 	case keys.KeyDown:
 		m.list.Down()
 		return m, m.instanceChanged()
+	case keys.KeyNextReady:
+		if !m.list.NextWithStatus(session.Ready) {
+			return m, m.handleError(fmt.Errorf("no session is waiting for input"))
+		}
+		return m, m.instanceChanged()
+	case keys.KeyNextRunning:
+		if !m.list.NextWithStatus(session.Running) {
+			return m, m.handleError(fmt.Errorf("no session is running"))
+		}
+		return m, m.instanceChanged()
 	case keys.KeyShiftUp:
 		if m.tabbedWindow.IsInDiffTab() {
 			m.tabbedWindow.ScrollUp()
@@ -665,6 +1131,48 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			m.tabbedWindow.ScrollDown()
 		}
 		return m, m.instanceChanged()
+	case keys.KeyRefreshDiff:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		if selected.SetupIncomplete() {
+			return m, m.recoverSetup(selected)
+		}
+		// Paused instances keep the stats from when they were paused.
+		if err := selected.UpdateDiffStats(); err != nil {
+			return m, tea.Batch(m.instanceChanged(), m.handleError(err))
+		}
+		return m, m.instanceChanged()
+	case keys.KeyTag:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		selected.CycleTag()
+		if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, m.instanceChanged()
+	case keys.KeyNudge:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || selected.Paused() || !selected.TmuxAlive() {
+			return m, nil
+		}
+		if err := selected.SendPrompt(m.appConfig.GetNudgePrompt()); err != nil {
+			return m, m.handleError(err)
+		}
+		selected.ResetStallCount()
+		return m, m.handleError(fmt.Errorf("✓ Nudged '%s'", selected.Title))
+	case keys.KeyInterrupt:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		if err := selected.Interrupt(m.appConfig.InterruptKey); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, m.instanceChanged()
 	case keys.KeyTab:
 		m.tabbedWindow.Toggle()
 		m.menu.SetInDiffTab(m.tabbedWindow.IsInDiffTab())
@@ -675,36 +1183,52 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			return m, nil
 		}
 
-		// Create the kill action as a tea.Cmd
-		killAction := func() tea.Msg {
-			// Get worktree and check if branch is checked out
-			worktree, err := selected.GetGitWorktree()
-			if err != nil {
-				return err
-			}
-
-			checkedOut, err := worktree.IsBranchCheckedOut()
-			if err != nil {
-				return err
-			}
-
-			if checkedOut {
-				return fmt.Errorf("instance %s is currently checked out", selected.Title)
-			}
-
-			// Delete from storage first
-			if err := m.storage.DeleteInstance(selected.Title); err != nil {
-				return err
-			}
-
-			// Then kill the instance
-			m.list.Kill()
-			return instanceChangedMsg{}
+		if m.hasUncommittedChanges(selected) {
+			message := fmt.Sprintf("[!] Session '%s' has uncommitted changes that will be lost — kill anyway?", selected.Title)
+			return m, m.chooseAction(message, []overlay.Choice{
+				{Key: "k", Label: "Kill anyway"},
+				{Key: "p", Label: "Commit and push, then kill"},
+				{Key: "c", Label: "Cancel"},
+			}, func(key string) (tea.Model, tea.Cmd) {
+				switch key {
+				case "k":
+					return m.Update(m.killAction(selected, false)())
+				case "p":
+					return m, m.runBusy(selected, fmt.Sprintf("Pushing '%s' before killing it...", selected.Title), func() error {
+						worktree, err := selected.GetGitWorktree()
+						if err != nil {
+							return err
+						}
+						commitMsg := fmt.Sprintf("[claudesquad] update from '%s' on %s", selected.Title, time.Now().Format(time.RFC822))
+						return worktree.PushChanges(commitMsg, false)
+					}, func() tea.Cmd {
+						_, cmd := m.Update(m.killAction(selected, false)())
+						return cmd
+					})
+				}
+				return m, nil
+			})
 		}
 
 		// Show confirmation modal
 		message := fmt.Sprintf("[!] Kill session '%s'?", selected.Title)
-		return m, m.confirmAction(message, killAction)
+		return m, m.confirmAction(message, m.killAction(selected, false))
+	case keys.KeyKillBranch:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		if m.appConfig.IsProtectedBranch(selected.Branch) {
+			return m, m.handleError(fmt.Errorf("branch %s is protected, not deleting it", selected.Branch))
+		}
+
+		message := fmt.Sprintf("[!!] Kill session '%s' and permanently DELETE branch '%s', including unpushed commits?",
+			selected.Title, selected.Branch)
+		if m.hasUncommittedChanges(selected) {
+			message = fmt.Sprintf("[!!] Kill session '%s' and permanently DELETE branch '%s', including unpushed commits "+
+				"and uncommitted changes?", selected.Title, selected.Branch)
+		}
+		return m, m.confirmAction(message, m.killAction(selected, true))
 	case keys.KeySubmit:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
@@ -720,78 +1244,669 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				return err
 			}
 			if err = worktree.PushChanges(commitMsg, true); err != nil {
+				var hookErr *git.HookError
+				if errors.As(err, &hookErr) {
+					m.showErrorDetails(fmt.Sprintf("Push rejected by the %s hook", hookErr.Hook), hookErr.Output)
+					return nil
+				}
 				return err
 			}
 			return nil
 		}
 
-		// Show confirmation modal
+		// Show confirmation modal with what is going to be committed and pushed
 		message := fmt.Sprintf("[!] Push changes from session '%s'?", selected.Title)
-		return m, m.confirmAction(message, pushAction)
+		if worktree, err := selected.GetGitWorktree(); err == nil {
+			if preview, err := worktree.PreviewChanges(); err != nil {
+				log.WarningLog.Printf("could not preview the push of '%s': %v", selected.Title, err)
+			} else {
+				message = pushConfirmMessage(selected.Title, preview)
+			}
+		}
+		cmd := m.confirmAction(message, pushAction)
+		m.confirmationOverlay.SetWidth(pushConfirmWidth)
+		return m, cmd
 	case keys.KeyCheckout:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
 			return m, nil
 		}
 
-		// Show help screen before pausing
-		m.showHelpScreen(helpTypeInstanceCheckout, func() {
-			if err := selected.Pause(); err != nil {
-				m.handleError(err)
-			}
-			m.instanceChanged()
+		// Show help screen before pausing. Pausing commits the changes, which can take a while with git hooks.
+		return m.showHelpScreen(helpTypeInstanceCheckout, func() tea.Cmd {
+			return m.runBusy(selected, fmt.Sprintf("Pausing '%s'...", selected.Title), selected.Pause, func() tea.Cmd {
+				return m.copyToClipboard("branch name", selected.Branch)
+			})
 		})
-		return m, nil
+	case keys.KeyRestart:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() || selected.Paused() {
+			return m, nil
+		}
+		restart := func() error {
+			return selected.ManualRestart(m.appConfig.GetMaxRestartAttempts(), m.appConfig.GetRestartCooldown())
+		}
+		return m, m.runBusy(selected, fmt.Sprintf("Restarting '%s'...", selected.Title), restart, nil)
 	case keys.KeyResume:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
 			return m, nil
 		}
-		if err := selected.Resume(); err != nil {
-			return m, m.handleError(err)
+		return m, m.runBusy(selected, fmt.Sprintf("Resuming '%s'...", selected.Title), selected.Resume, func() tea.Cmd {
+			// Initialize watchdog for resumed instances
+			selected.InitializeWatchdog(m.appConfig.WatchdogEnabled)
+			return tea.WindowSize()
+		})
+	case keys.KeyAttachSplit:
+		if tmux.InsideTmux() {
+			selected := m.list.GetSelectedInstance()
+			if selected == nil || selected.Paused() || !selected.TmuxAlive() {
+				return m, nil
+			}
+			if err := m.list.AttachSplit(); err != nil {
+				return m, m.handleError(err)
+			}
+			return m, nil
 		}
-		// Initialize watchdog for resumed instances
-		selected.InitializeWatchdog(m.appConfig.WatchdogEnabled)
-		return m, tea.WindowSize()
+		// Not inside tmux, so there's no pane to split. Fall back to the full attach.
+		fallthrough
 	case keys.KeyEnter:
 		if m.list.NumInstances() == 0 {
 			return m, nil
 		}
+		return m.attachSelected()
+	case keys.KeyResumeAll:
+		return m, m.resumeAll()
+	case keys.KeyRestartStalled:
+		return m, m.restartStalled()
+	case keys.KeyAttention:
+		m.attentionView = !m.attentionView
+		if m.attentionView {
+			m.list.SetFilter(m.attentionInstances())
+		} else {
+			m.list.SetFilter(nil)
+		}
+		return m, m.instanceChanged()
+	case keys.KeyCleanupSessions:
+		cleanupAction := func() tea.Msg {
+			killed, err := m.cleanupZombieSessions()
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("🧹 Killed %d leftover tmux sessions", killed)
+		}
+		message := "[!] Kill the claude-squad tmux sessions that don't belong to any saved session? This includes sessions another claude-squad is still starting."
+		return m, m.confirmAction(message, cleanupAction)
+	case keys.KeyErrors:
+		return m, m.showErrorHistory()
+	case keys.KeyDestructiveLog:
+		return m, m.showDestructiveLog()
+	case keys.KeyDebugInstance:
 		selected := m.list.GetSelectedInstance()
-		if selected == nil || selected.Paused() || !selected.TmuxAlive() {
+		if selected == nil {
 			return m, nil
 		}
-		// Show help screen before attaching
-		m.showHelpScreen(helpTypeInstanceAttach, func() {
-			ch, err := m.list.Attach()
-			if err != nil {
-				m.handleError(err)
-				return
+		return m, m.showInstanceData(selected)
+	case keys.KeyTailOutput:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() {
+			return m, nil
+		}
+		path, err := selected.SetTailOutput(!selected.TailOutput)
+		if err != nil {
+			return m, m.handleError(err)
+		}
+		if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+			return m, m.handleError(err)
+		}
+		if !selected.TailOutput {
+			return m, m.handleError(fmt.Errorf("✓ Stopped logging the output of '%s'", selected.Title))
+		}
+		return m, m.handleError(fmt.Errorf("✓ Logging the output of '%s' to %s", selected.Title, path))
+	case keys.KeyTranscripts:
+		return m, m.chooseTranscript()
+	case keys.KeyClearError:
+		m.errBox.Clear()
+		return m, nil
+	case keys.KeyFocus:
+		m.focusMode = !m.focusMode
+		m.list.SetFocused(m.focusMode)
+		if err := m.appState.SetFocusMode(m.focusMode); err != nil {
+			log.WarningLog.Printf("failed to save focus mode: %v", err)
+		}
+		return m, tea.WindowSize()
+	case keys.KeyMerge:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() {
+			return m, nil
+		}
+		target := "the branch checked out in the main repository"
+		if base := m.appConfig.MergeBaseBranch; base != "" {
+			target = base
+		}
+		message := fmt.Sprintf("[!] Merge %s into %s?", selected.Branch, target)
+		return m, m.chooseAction(message, []overlay.Choice{
+			{Key: "m", Label: "Merge"},
+			{Key: "c", Label: "Cancel"},
+		}, func(key string) (tea.Model, tea.Cmd) {
+			if key != "m" {
+				return m, nil
 			}
-			<-ch
-			m.state = stateDefault
+			return m, m.mergeIntoBase(selected)
 		})
-		return m, nil
+	case keys.KeyCopyPreview:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+		if selected.Paused() {
+			return m, m.handleError(fmt.Errorf("'%s' is paused, resume it to copy its preview", selected.Title))
+		}
+		if !selected.Started() {
+			return m, nil
+		}
+		content, err := selected.PreviewText()
+		if err != nil {
+			return m, m.handleError(err)
+		}
+		if content == "" {
+			return m, m.handleError(fmt.Errorf("the preview of '%s' is empty", selected.Title))
+		}
+		return m, m.copyToClipboard(fmt.Sprintf("preview of '%s'", selected.Title), content)
+	case keys.KeyGrowList, keys.KeyShrinkList:
+		if m.focusMode {
+			return m, nil
+		}
+		step := listWidthStep
+		if name == keys.KeyShrinkList {
+			step = -step
+		}
+		percent := config.ClampListWidthPercent(m.listWidthPercent + step)
+		if percent == m.listWidthPercent {
+			return m, nil
+		}
+		m.listWidthPercent = percent
+		if err := m.appState.SetListWidthPercent(percent); err != nil {
+			log.WarningLog.Printf("failed to save the list width: %v", err)
+		}
+		return m, tea.WindowSize()
+	case keys.KeyResumeAttach:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Paused() {
+			return m, nil
+		}
+		if err := selected.Resume(); err != nil {
+			return m, m.handleError(err)
+		}
+		// Initialize watchdog for resumed instances
+		selected.InitializeWatchdog(m.appConfig.WatchdogEnabled)
+		// Resume failures return above, so there's never an attach to a session that didn't come back
+		model, cmd := m.attachSelected()
+		return model, tea.Batch(tea.WindowSize(), cmd)
 	default:
 		return m, nil
 	}
 }
 
+const (
+	// maxContinuousModeDuration is the longest duration that can be entered for continuous mode
+	maxContinuousModeDuration = 24 * time.Hour
+	// longContinuousModeDuration is the duration above which enabling continuous mode is logged as a warning
+	longContinuousModeDuration = 2 * time.Hour
+)
+
+// promptContinuousModeDuration asks for the continuous mode duration of the instance. If continuous mode is already
+// on, change says how the duration is applied.
+func (m *home) promptContinuousModeDuration(instance *session.Instance, change continuousModeChange, message string) {
+	m.state = statePrompt
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewTextInputOverlay(message, "")
+
+	// Store which instance we're setting continuous mode for
+	m.continuousModeTarget = instance
+	m.continuousModeChange = change
+	m.isContinuousModeInput = true
+}
+
+// parseContinuousModeDuration parses the duration entered in the continuous mode overlay. Empty input or
+// "indefinite" means no duration (0). A bare number is a number of minutes; anything else must be a Go duration
+// like "1h30m".
+func parseContinuousModeDuration(input string) (time.Duration, error) {
+	input = strings.TrimSpace(input)
+	if input == "" || strings.EqualFold(input, "indefinite") {
+		return 0, nil
+	}
+
+	var duration time.Duration
+	if minutes, err := strconv.Atoi(input); err == nil {
+		duration = time.Duration(minutes) * time.Minute
+	} else {
+		duration, err = time.ParseDuration(input)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: enter minutes (30) or a duration like 30m, 2h or 1h30m", input)
+		}
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	if duration > maxContinuousModeDuration {
+		return 0, fmt.Errorf("duration cannot exceed 24 hours")
+	}
+	return duration, nil
+}
+
+// queuePrompt queues a prompt for the selected instance. If the instance is idle and nothing is queued yet, the
+// prompt is sent right away.
+func (m *home) queuePrompt(prompt string) tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return nil
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return m.handleError(fmt.Errorf("prompt cannot be empty"))
+	}
+
+	if selected.Status == session.Ready && selected.QueueLength() == 0 {
+		if err := selected.SendPrompt(prompt); err != nil {
+			return m.handleError(err)
+		}
+		selected.ResetStallCount()
+		selected.SetStatus(session.Running)
+		return m.handleError(fmt.Errorf("✓ Sent prompt to '%s'", selected.Title))
+	}
+
+	selected.EnqueuePrompt(prompt)
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m.handleError(err)
+	}
+	return m.handleError(fmt.Errorf("✓ Queued prompt for '%s' (%d waiting)", selected.Title, selected.QueueLength()))
+}
+
+// recoverSetup offers to complete the worktree setup of an instance whose setup never completed, keeping its
+// worktree, or to re-create its worktree and tmux session from scratch
+func (m *home) recoverSetup(instance *session.Instance) tea.Cmd {
+	message := fmt.Sprintf("[!] Worktree setup of '%s' never completed, so it has no diff", instance.Title)
+	return m.chooseAction(message, []overlay.Choice{
+		{Key: "s", Label: "Set up again, keeping the worktree"},
+		{Key: "r", Label: "Re-create from scratch (changes are lost)"},
+		{Key: "c", Label: "Cancel"},
+	}, func(key string) (tea.Model, tea.Cmd) {
+		var op func() error
+		switch key {
+		case "s":
+			op = instance.CompleteSetup
+		case "r":
+			op = instance.Recreate
+		default:
+			return m, nil
+		}
+		return m, m.runBusy(instance, fmt.Sprintf("Setting up '%s' again...", instance.Title), op, func() tea.Cmd {
+			if err := instance.UpdateDiffStats(); err != nil {
+				return m.handleError(err)
+			}
+			if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+				return m.handleError(err)
+			}
+			return tea.Batch(m.instanceChanged(), m.handleError(fmt.Errorf("✓ Set up '%s' again", instance.Title)))
+		})
+	})
+}
+
+// setRemote sets the git remote the selected instance is pushed to
+func (m *home) setRemote(remote string) tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return nil
+	}
+	if err := selected.SetRemote(remote); err != nil {
+		return m.handleError(err)
+	}
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m.handleError(err)
+	}
+	target := selected.Remote
+	if target == "" {
+		target = "origin"
+	}
+	return m.handleError(fmt.Errorf("✓ '%s' is pushed to %s", selected.Title, target))
+}
+
+// sendRawKeys sends keys in tmux notation to the selected instance
+func (m *home) sendRawKeys(sequence string) tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return nil
+	}
+	if err := selected.SendRawKeys(sequence); err != nil {
+		return m.handleError(err)
+	}
+	return m.handleError(fmt.Errorf("✓ Sent %s to '%s'", strings.Join(strings.Fields(sequence), " "), selected.Title))
+}
+
+// sendQueuedPrompt sends the next queued prompt to an instance that just became ready
+func (m *home) sendQueuedPrompt(instance *session.Instance) {
+	sent, err := instance.SendQueuedPrompt()
+	if err != nil {
+		// It's retried the next time the instance becomes ready
+		log.ErrorLog.Printf("failed to send queued prompt to '%s': %v", instance.Title, err)
+		return
+	}
+	if !sent {
+		return
+	}
+	log.InfoLog.Printf("sent queued prompt to '%s', %d left", instance.Title, instance.QueueLength())
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		log.ErrorLog.Printf("failed to save instances: %v", err)
+	}
+}
+
+// pauseAll pauses every running instance in the background, one at a time, since each one commits its changes and
+// removes its worktree. Instances that fail to pause are left running and reported.
+func (m *home) pauseAll() tea.Cmd {
+	if m.busy != nil {
+		return m.handleError(fmt.Errorf("please wait: %s", m.busy.status))
+	}
+
+	var running []*session.Instance
+	for _, instance := range m.list.GetInstances() {
+		if instance.Started() && !instance.Paused() {
+			running = append(running, instance)
+		}
+	}
+	return m.pauseNext(running, 0, 0, nil)
+}
+
+// pauseNext pauses running[idx] and then moves on to the next one. Once all are done, it reports the result.
+func (m *home) pauseNext(running []*session.Instance, idx int, paused int, errs []error) tea.Cmd {
+	if idx == len(running) {
+		log.InfoLog.Printf("paused %d instances", paused)
+		if len(errs) > 0 {
+			return m.handleError(errors.Join(errs...))
+		}
+		return m.handleError(fmt.Errorf("⏸ Paused %d sessions", paused))
+	}
+
+	instance := running[idx]
+	status := fmt.Sprintf("Pausing '%s' (%d/%d)...", instance.Title, idx+1, len(running))
+	return m.runBusyThen(instance, status, instance.Pause, func(err error) tea.Cmd {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to pause '%s': %w", instance.Title, err))
+		} else {
+			paused++
+		}
+		return m.pauseNext(running, idx+1, paused, errs)
+	})
+}
+
+// resumeAll resumes every paused instance in the background, one at a time, since each one sets up a worktree and
+// starts a program. Instances whose branch is checked out are skipped. Those and instances that fail to resume stay
+// paused and are reported.
+func (m *home) resumeAll() tea.Cmd {
+	if m.busy != nil {
+		return m.handleError(fmt.Errorf("please wait: %s", m.busy.status))
+	}
+
+	var paused []*session.Instance
+	var errs []error
+	for _, instance := range m.list.GetInstances() {
+		if !instance.Paused() {
+			continue
+		}
+		worktree, err := instance.GetGitWorktree()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resume '%s': %w", instance.Title, err))
+			continue
+		}
+		if checkedOut, err := worktree.IsBranchCheckedOut(); err == nil && checkedOut {
+			errs = append(errs, fmt.Errorf("skipped '%s': branch %s is checked out", instance.Title, instance.Branch))
+			continue
+		}
+		paused = append(paused, instance)
+	}
+	return m.resumeNext(paused, 0, 0, errs)
+}
+
+// resumeNext resumes paused[idx] and then moves on to the next one. Once all are done, it reports the result.
+func (m *home) resumeNext(paused []*session.Instance, idx int, resumed int, errs []error) tea.Cmd {
+	if idx == len(paused) {
+		log.InfoLog.Printf("resumed %d instances", resumed)
+		if len(errs) > 0 {
+			return tea.Sequence(tea.WindowSize(), m.handleError(errors.Join(errs...)))
+		}
+		return tea.Sequence(tea.WindowSize(), m.handleError(fmt.Errorf("▶ Resumed %d sessions", resumed)))
+	}
+
+	instance := paused[idx]
+	status := fmt.Sprintf("Resuming '%s' (%d/%d)...", instance.Title, idx+1, len(paused))
+	return m.runBusyThen(instance, status, instance.Resume, func(err error) tea.Cmd {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resume '%s': %w", instance.Title, err))
+		} else {
+			instance.InitializeWatchdog(m.appConfig.WatchdogEnabled)
+			resumed++
+		}
+		return m.resumeNext(paused, idx+1, resumed, errs)
+	})
+}
+
+// restartStalled restarts every Claude Code instance that needs it, e.g. after an outage stalled them all, in the
+// background and one at a time, since each restart waits for Claude Code to come back up. Restarts count towards the
+// limit like ctrl+r does, so instances out of restarts fail and are reported.
+func (m *home) restartStalled() tea.Cmd {
+	if m.busy != nil {
+		return m.handleError(fmt.Errorf("please wait: %s", m.busy.status))
+	}
+
+	stallTimeout := time.Duration(m.appConfig.StallTimeoutSeconds) * time.Second
+	var stalled []*session.Instance
+	for _, instance := range m.list.GetInstances() {
+		if instance.NeedsRestart(m.appConfig.MaxContinueAttempts, stallTimeout) {
+			stalled = append(stalled, instance)
+		}
+	}
+	if len(stalled) == 0 {
+		return m.handleError(fmt.Errorf("no stalled or crashed sessions to restart"))
+	}
+	return m.restartNext(stalled, 0, 0, nil)
+}
+
+// restartNext restarts stalled[idx] and then moves on to the next one. Once all are done, it reports the result.
+func (m *home) restartNext(stalled []*session.Instance, idx int, restarted int, errs []error) tea.Cmd {
+	if idx == len(stalled) {
+		log.InfoLog.Printf("restarted %d stalled instances", restarted)
+		if len(errs) > 0 {
+			return m.handleError(fmt.Errorf("restarted %d of %d sessions: %w", restarted, len(stalled), errors.Join(errs...)))
+		}
+		return m.handleError(fmt.Errorf("✓ Restarted %d sessions", restarted))
+	}
+
+	instance := stalled[idx]
+	status := fmt.Sprintf("Restarting '%s' (%d/%d)...", instance.Title, idx+1, len(stalled))
+	restart := func() error {
+		return instance.ManualRestart(m.appConfig.GetMaxRestartAttempts(), m.appConfig.GetRestartCooldown())
+	}
+	return m.runBusyThen(instance, status, restart, func(err error) tea.Cmd {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restart '%s': %w", instance.Title, err))
+		} else {
+			restarted++
+		}
+		return m.restartNext(stalled, idx+1, restarted, errs)
+	})
+}
+
+// showErrorDetails shows an error whose details don't fit in the error box, such as the output of a failed git hook
+func (m *home) showErrorDetails(title string, details string) {
+	log.ErrorLog.Printf("%s: %s", title, details)
+	m.showDetails(title, details)
+}
+
+// showDetails shows a title and details that don't fit in the error box in an overlay
+func (m *home) showDetails(title string, details string) {
+	// Keep the end of long output, that's where tools usually summarize what failed
+	const maxLines = 30
+	lines := strings.Split(details, "\n")
+	if len(lines) > maxLines {
+		lines = append([]string{"..."}, lines[len(lines)-maxLines:]...)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF0000"))
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#808080"))
+	content := titleStyle.Render(title) + "\n\n" + strings.Join(lines, "\n") + "\n\n" +
+		hintStyle.Render("Press any key to close")
+
+	m.textOverlay = overlay.NewTextOverlay(content)
+	m.onHelpDismiss = nil
+	m.state = stateHelp
+}
+
+// showInstanceData shows the data instance is stored as, to debug what was persisted. The content of the diff is left
+// out: it's long, and it's not stored in compact state anyway.
+func (m *home) showInstanceData(instance *session.Instance) tea.Cmd {
+	data := instance.ToInstanceData()
+	diffSize := len(data.DiffStats.Content)
+	data.DiffStats.Content = ""
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return m.handleError(fmt.Errorf("failed to marshal '%s': %w", instance.Title, err))
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true)
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#808080"))
+	content := titleStyle.Render(fmt.Sprintf("Stored data of '%s'", instance.Title)) + "\n\n" + string(raw) + "\n\n" +
+		hintStyle.Render(fmt.Sprintf("diff_stats.content (%d bytes) left out. Press any key to close", diffSize))
+	m.textOverlay = overlay.NewTextOverlay(content)
+	m.onHelpDismiss = nil
+	m.state = stateHelp
+	return nil
+}
+
+// importBranch creates a new instance that checks out an existing branch. The title is pre-filled from the
+// branch name and the user confirms it in the naming step like any other new instance.
+func (m *home) importBranch(branch string) (tea.Model, tea.Cmd) {
+	if branch == "" {
+		return m, m.handleError(fmt.Errorf("branch name cannot be empty"))
+	}
+
+	title := branch
+	if idx := strings.LastIndex(title, "/"); idx >= 0 {
+		title = title[idx+1:]
+	}
+	maxTitleLength := m.appConfig.GetMaxTitleLength()
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = string(runes[:maxTitleLength])
+	}
+
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title:          title,
+		Path:           ".",
+		Program:        m.program,
+		Branch:         branch,
+		AutoYes:        m.autoYes,
+		MaxTitleLength: maxTitleLength,
+	})
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	if err := session.CheckNotInWorktree(instance.Path, m.list.GetInstances()); err != nil {
+		return m, m.handleError(err)
+	}
+
+	m.newInstanceFinalizer = m.list.AddInstance(instance)
+	m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+	m.state = stateNew
+	m.menu.SetState(ui.StateNewInstance)
+
+	return m, tea.WindowSize()
+}
+
+// newFromTemplate creates a new instance pre-filled from the named template. The user names the instance as usual,
+// after which the template's prompt is offered for sending.
+func (m *home) newFromTemplate(name string) (tea.Model, tea.Cmd) {
+	template, ok := m.appConfig.Templates[name]
+	if !ok {
+		return m, m.handleError(fmt.Errorf("template not found: %s", name))
+	}
+
+	program := template.Program
+	if strings.TrimSpace(program) == "" {
+		program = m.program
+	}
+	path := template.Path
+	if path == "" {
+		path = "."
+	}
+
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title:          "",
+		Path:           path,
+		Program:        program,
+		AutoYes:        template.AutoYes || m.autoYes,
+		MaxTitleLength: m.appConfig.GetMaxTitleLength(),
+		StartupPrompt:  template.StartupPrompt,
+		Remote:         template.Remote,
+	})
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	if err := session.CheckNotInWorktree(instance.Path, m.list.GetInstances()); err != nil {
+		return m, m.handleError(err)
+	}
+
+	m.pendingTemplate = &template
+	m.newInstanceFinalizer = m.list.AddInstance(instance)
+	m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+	m.state = stateNew
+	m.menu.SetState(ui.StateNewInstance)
+
+	return m, tea.WindowSize()
+}
+
 // instanceChanged updates the preview pane, menu, and diff pane based on the selected instance. It returns an error
 // Cmd if there was any error.
 func (m *home) instanceChanged() tea.Cmd {
 	// selected may be nil
 	selected := m.list.GetSelectedInstance()
+	if m.busy != nil && m.busy.instance == selected {
+		// The tmux session may be going away under the background operation. Keep showing the last preview.
+		m.menu.SetInstance(selected)
+		return nil
+	}
 
+	if selected != nil {
+		selected.MarkDiffViewed()
+	}
 	m.tabbedWindow.UpdateDiff(selected)
+	m.tabbedWindow.UpdateRuntime(selected, m.appConfig.GetMaxRestartAttempts(), m.appConfig.GetRestartCooldown())
 	// Update menu with current instance
 	m.menu.SetInstance(selected)
 
-	// If there's no selected instance, we don't need to update the preview.
-	if err := m.tabbedWindow.UpdatePreview(selected); err != nil {
-		return m.handleError(err)
+	return m.capturePreview(selected)
+}
+
+// capturePreview shows selected in the preview and captures its pane in the background, so that a hanging tmux server
+// can't freeze the UI. One capture runs at a time; if it takes longer than capture_timeout_ms, the preview shows that
+// it's capturing and keeps the last content.
+func (m *home) capturePreview(selected *session.Instance) tea.Cmd {
+	if !m.tabbedWindow.UpdatePreview(selected) || m.capturing {
+		return nil
+	}
+	m.capturing = true
+	m.captureSeq++
+	seq := m.captureSeq
+	timeout := m.appConfig.GetCaptureTimeout()
+	// Look at the instance now, the capture runs while the UI keeps changing it
+	capture := selected.PreviewCapturer()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, timeout)
+		defer cancel()
+		content, err := capture(ctx)
+		if ctx.Err() != nil {
+			return previewCaptureTimeoutMsg{seq: seq, instance: selected}
+		}
+		return previewCapturedMsg{instance: selected, content: content, err: err}
 	}
-	return nil
 }
 
 type keyupMsg struct{}
@@ -809,39 +1924,543 @@ func (m *home) keydownCallback(name keys.KeyName) tea.Cmd {
 	}
 }
 
-// hideErrMsg implements tea.Msg and clears the error text from the screen.
-type hideErrMsg struct{}
+// hideErrMsg implements tea.Msg and clears the error text from the screen, unless a newer error was shown since.
+type hideErrMsg struct {
+	seq int
+}
 
 // previewTickMsg implements tea.Msg and triggers a preview update
 type previewTickMsg struct{}
 
+// previewCapturedMsg implements tea.Msg and delivers the pane content captured for the preview
+type previewCapturedMsg struct {
+	instance *session.Instance
+	content  string
+	err      error
+}
+
+// previewCaptureTimeoutMsg implements tea.Msg and is sent instead of previewCapturedMsg when capture seq took longer
+// than capture_timeout_ms and was killed
+type previewCaptureTimeoutMsg struct {
+	seq      int
+	instance *session.Instance
+}
+
 type tickUpdateMetadataMessage struct{}
 
 type instanceChangedMsg struct{}
 
-// tickUpdateMetadataCmd is the callback to update the metadata of the instances every 500ms. Note that we iterate
-// overall the instances and capture their output. It's a pretty expensive operation. Let's do it 2x a second only.
-var tickUpdateMetadataCmd = func() tea.Msg {
-	time.Sleep(500 * time.Millisecond)
-	return tickUpdateMetadataMessage{}
+// busyOperation is a long operation on an instance that runs in the background while a spinner is shown.
+type busyOperation struct {
+	instance *session.Instance
+	status   string
+	// onDone is called with the result once the operation completes
+	onDone func(err error) tea.Cmd
+}
+
+// busyDoneMsg implements tea.Msg and reports the result of the background operation
+type busyDoneMsg struct {
+	err error
+}
+
+// pauseAllMsg implements tea.Msg and pauses all running instances
+type pauseAllMsg struct{}
+
+// resumeAllMsg implements tea.Msg and resumes all paused instances
+type resumeAllMsg struct{}
+
+// metadataCheckedMsg implements tea.Msg and delivers the panes captured for the metadata update
+type metadataCheckedMsg struct {
+	// serverRunning is false if the tmux server is gone. It's only checked if there are running instances.
+	serverRunning bool
+	checks        map[*session.Instance]session.PaneCheck
+}
+
+// metadataCheckTimeoutMsg implements tea.Msg and is sent instead of metadataCheckedMsg when metadata check seq took
+// longer than capture_timeout_ms and was killed
+type metadataCheckTimeoutMsg struct {
+	seq int
+}
+
+// checkMetadata captures the panes of the running instances in the background, so that a hanging tmux server can't
+// freeze the UI. The instances are updated from them once metadataCheckedMsg arrives, which schedules the next tick.
+func (m *home) checkMetadata() tea.Cmd {
+	checkers := make(map[*session.Instance]func(context.Context) session.PaneCheck)
+	for _, instance := range m.list.GetInstances() {
+		if m.busy != nil && m.busy.instance == instance {
+			continue
+		}
+		if checker := instance.PaneChecker(); checker != nil {
+			checkers[instance] = checker
+		}
+	}
+	m.checkingMetadata = true
+	m.metadataSeq++
+	seq := m.metadataSeq
+	timeout := m.appConfig.GetCaptureTimeout()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, timeout)
+		defer cancel()
+		msg := metadataCheckedMsg{serverRunning: true, checks: make(map[*session.Instance]session.PaneCheck)}
+		if len(checkers) > 0 {
+			msg.serverRunning = tmux.IsServerRunning(ctx, cmd2.MakeExecutor())
+		}
+		for instance, checker := range checkers {
+			msg.checks[instance] = checker(ctx)
+		}
+		if ctx.Err() != nil {
+			// Some captures were killed, so the checks are incomplete
+			return metadataCheckTimeoutMsg{seq: seq}
+		}
+		return msg
+	}
+}
+
+// tickUpdateMetadataCmd returns the callback to update the metadata of the instances every metadata_interval_ms (500ms
+// by default). Note that we iterate overall the instances and capture their output. It's a pretty expensive operation.
+func (m *home) tickUpdateMetadataCmd() tea.Cmd {
+	interval := m.appConfig.GetMetadataInterval()
+	return func() tea.Msg {
+		time.Sleep(interval)
+		return tickUpdateMetadataMessage{}
+	}
+}
+
+// tickPreviewCmd returns the callback to refresh the preview every preview_interval_ms (100ms by default)
+func (m *home) tickPreviewCmd() tea.Cmd {
+	interval := m.appConfig.GetPreviewInterval()
+	return func() tea.Msg {
+		time.Sleep(interval)
+		return previewTickMsg{}
+	}
 }
 
 // handleError handles all errors which get bubbled up to the app. sets the error message. We return a callback tea.Cmd that returns a hideErrMsg message
-// which clears the error message after 3 seconds.
+// which clears the error message after error_hide_ms (3 seconds by default).
 func (m *home) handleError(err error) tea.Cmd {
 	log.ErrorLog.Printf("%v", err)
+	m.errorHistory = append(m.errorHistory, errorEntry{err: err, time: time.Now()})
+	if len(m.errorHistory) > maxErrorHistory {
+		m.errorHistory = m.errorHistory[len(m.errorHistory)-maxErrorHistory:]
+	}
+	return m.showError(err)
+}
+
+// showError shows err in the error box until it's hidden after error_hide_ms
+func (m *home) showError(err error) tea.Cmd {
 	m.errBox.SetError(err)
+	m.errorSeq++
+	seq := m.errorSeq
+	delay := m.appConfig.GetErrorHideDuration()
 	return func() tea.Msg {
 		select {
 		case <-m.ctx.Done():
-		case <-time.After(3 * time.Second):
+		case <-time.After(delay):
 		}
 
-		return hideErrMsg{}
+		return hideErrMsg{seq: seq}
+	}
+}
+
+// showErrorHistory shows the last error in the error box again and all recent errors, newest first, in full.
+func (m *home) showErrorHistory() tea.Cmd {
+	if len(m.errorHistory) == 0 {
+		return m.showError(fmt.Errorf("no errors"))
+	}
+
+	lines := make([]string, 0, len(m.errorHistory))
+	for idx := len(m.errorHistory) - 1; idx >= 0; idx-- {
+		entry := m.errorHistory[idx]
+		lines = append(lines, fmt.Sprintf("%s: %v", humanize.RelativeTime(entry.time), entry.err))
 	}
+	cmd := m.showError(m.errorHistory[len(m.errorHistory)-1].err)
+	m.showDetails("Recent errors", strings.Join(lines, "\n"))
+	return cmd
+}
+
+// maxDestructiveLogLines is how many of the recent destructive git operations the overlay lists
+const maxDestructiveLogLines = 20
+
+// showDestructiveLog shows the recent destructive git operations, newest first, so that deleted branches can be
+// recovered from their SHA
+func (m *home) showDestructiveLog() tea.Cmd {
+	path, err := git.DestructiveLogPath()
+	if err != nil {
+		return m.handleError(err)
+	}
+	records, err := git.ReadDestructiveLog(path)
+	if err != nil {
+		return m.handleError(err)
+	}
+	if len(records) == 0 {
+		return m.handleError(fmt.Errorf("no branches or worktrees were deleted yet"))
+	}
+
+	var lines []string
+	for idx := len(records) - 1; idx >= 0 && len(lines) < maxDestructiveLogLines; idx-- {
+		lines = append(lines, fmt.Sprintf("%s: %s", humanize.RelativeTime(records[idx].Time), records[idx]))
+	}
+	m.showDetails("Recent destructive actions", strings.Join(lines, "\n"))
+	return nil
+}
+
+// attachSelected attaches to the selected instance, showing the attach help screen first if it hasn't been seen.
+// A paused instance or one whose tmux session died can't be attached to, so it offers to bring the instance back
+// instead.
+func (m *home) attachSelected() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() {
+		return m, nil
+	}
+	if selected.Paused() {
+		return m, m.offerResume(selected)
+	}
+	if !selected.TmuxAlive() {
+		return m, m.recoverDeadSession(selected)
+	}
+	// Show help screen before attaching
+	return m.showHelpScreen(helpTypeInstanceAttach, func() tea.Cmd {
+		ch, err := m.list.Attach()
+		if err != nil {
+			return m.handleError(err)
+		}
+		<-ch
+		// Whatever the user did while attached, the watchdog's continues start over
+		selected.ResetStallCount()
+		m.state = stateDefault
+		return nil
+	})
+}
+
+// offerResume asks whether to resume the paused instance and attach to it
+func (m *home) offerResume(instance *session.Instance) tea.Cmd {
+	message := fmt.Sprintf("'%s' is paused, resume it to attach", instance.Title)
+	return m.chooseAction(message, []overlay.Choice{
+		{Key: "r", Label: "Resume and attach"},
+		{Key: "c", Label: "Cancel"},
+	}, func(key string) (tea.Model, tea.Cmd) {
+		if key != "r" {
+			return m, nil
+		}
+		return m, m.runBusy(instance, fmt.Sprintf("Resuming '%s'...", instance.Title), instance.Resume, func() tea.Cmd {
+			// Initialize watchdog for resumed instances
+			instance.InitializeWatchdog(m.appConfig.WatchdogEnabled)
+			if m.list.GetSelectedInstance() != instance {
+				// The user moved on while it was resuming
+				return tea.WindowSize()
+			}
+			_, cmd := m.attachSelected()
+			return tea.Batch(tea.WindowSize(), cmd)
+		})
+	})
 }
 
-// confirmAction shows a confirmation modal and stores the action to execute on confirm
+// recoverDeadSession handles attaching to an instance that is running as far as claude-squad knows, but whose tmux
+// session died, e.g. with the tmux server. It restarts or pauses the instance as dead_session_action says, or asks.
+func (m *home) recoverDeadSession(instance *session.Instance) tea.Cmd {
+	log.WarningLog.Printf("tmux session of '%s' died while it was %s", instance.Title, instance.Status)
+	restart := func() tea.Cmd {
+		op := func() error {
+			return instance.RestartDeadSession(m.appConfig.GetMaxRestartAttempts(), m.appConfig.GetRestartCooldown())
+		}
+		return m.runBusy(instance, fmt.Sprintf("Restarting '%s'...", instance.Title), op, func() tea.Cmd {
+			return m.handleError(fmt.Errorf("✓ Restarted '%s', press enter again to attach", instance.Title))
+		})
+	}
+	pause := func() tea.Cmd {
+		return m.runBusy(instance, fmt.Sprintf("Pausing '%s'...", instance.Title), instance.Pause, nil)
+	}
+
+	switch m.appConfig.DeadSessionAction {
+	case "restart":
+		return restart()
+	case "pause":
+		return pause()
+	}
+	message := fmt.Sprintf("[!] The tmux session of '%s' is gone, its program isn't running anymore", instance.Title)
+	return m.chooseAction(message, []overlay.Choice{
+		{Key: "r", Label: "Restart the program in the worktree"},
+		{Key: "p", Label: "Pause, committing its changes"},
+		{Key: "c", Label: "Cancel"},
+	}, func(key string) (tea.Model, tea.Cmd) {
+		switch key {
+		case "r":
+			return m, restart()
+		case "p":
+			return m, pause()
+		}
+		return m, nil
+	})
+}
+
+// attentionInstances returns the instances waiting for the user, the one waiting the longest first
+func (m *home) attentionInstances() []*session.Instance {
+	type waiting struct {
+		instance *session.Instance
+		since    time.Time
+	}
+	var found []waiting
+	for _, instance := range m.list.GetInstances() {
+		if since, ok := instance.NeedsAttention(m.appConfig.MaxContinueAttempts); ok {
+			found = append(found, waiting{instance: instance, since: since})
+		}
+	}
+	sort.SliceStable(found, func(a, b int) bool {
+		return found[a].since.Before(found[b].since)
+	})
+
+	instances := make([]*session.Instance, 0, len(found))
+	for _, w := range found {
+		instances = append(instances, w.instance)
+	}
+	return instances
+}
+
+// maxTranscriptChoices is the number of transcripts offered by the transcript viewer, one for each digit key
+const maxTranscriptChoices = 9
+
+// chooseTranscript lets the user pick one of the newest saved transcripts and opens it in $PAGER (less by default).
+func (m *home) chooseTranscript() tea.Cmd {
+	transcripts, err := session.ListTranscripts()
+	if err != nil {
+		return m.handleError(err)
+	}
+	if len(transcripts) == 0 {
+		return m.handleError(fmt.Errorf("no saved transcripts. Set save_transcript_on_close in the config to save them"))
+	}
+	if len(transcripts) > maxTranscriptChoices {
+		transcripts = transcripts[:maxTranscriptChoices]
+	}
+
+	choices := make([]overlay.Choice, 0, len(transcripts)+1)
+	for idx, transcript := range transcripts {
+		choices = append(choices, overlay.Choice{
+			Key:   strconv.Itoa(idx + 1),
+			Label: fmt.Sprintf("%s (%s)", transcript.Name, humanize.RelativeTime(transcript.SavedAt)),
+		})
+	}
+	choices = append(choices, overlay.Choice{Key: "c", Label: "Cancel"})
+
+	return m.chooseAction("Open a saved transcript", choices, func(key string) (tea.Model, tea.Cmd) {
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 1 || idx > len(transcripts) {
+			return m, nil
+		}
+		return m, openInPager(transcripts[idx-1].Path, func(err error) tea.Msg {
+			if err != nil {
+				return fmt.Errorf("failed to open transcript: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// openInPager suspends the UI and shows the file in $PAGER, or less if it's not set
+func openInPager(path string, onExit tea.ExecCallback) tea.Cmd {
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less"
+	}
+	command, err := session.ParseProgram(pager)
+	if err != nil {
+		return func() tea.Msg { return err }
+	}
+	return tea.ExecProcess(exec.Command(command.Command, append(command.Args, path)...), onExit)
+}
+
+// hasUncommittedChanges returns true if the worktree of the instance has changes that killing it would discard.
+func (m *home) hasUncommittedChanges(instance *session.Instance) bool {
+	if !instance.Started() || instance.Paused() {
+		return false
+	}
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		return false
+	}
+	dirty, err := worktree.IsDirty()
+	if err != nil {
+		log.WarningLog.Printf("could not check '%s' for uncommitted changes: %v", instance.Title, err)
+		return false
+	}
+	return dirty
+}
+
+// killAction returns the command that kills the instance once the user confirms. If deleteBranch is true, the branch
+// is deleted as well, even if it was imported rather than created for the instance.
+func (m *home) killAction(selected *session.Instance, deleteBranch bool) tea.Cmd {
+	return func() tea.Msg {
+		// Get worktree and check if branch is checked out
+		worktree, err := selected.GetGitWorktree()
+		if err != nil {
+			return err
+		}
+
+		checkedOut, err := worktree.IsBranchCheckedOut()
+		if err != nil {
+			return err
+		}
+
+		if checkedOut {
+			return fmt.Errorf("instance %s is currently checked out", selected.Title)
+		}
+
+		// Delete from storage first
+		if err := m.storage.DeleteInstance(selected.Title); err != nil {
+			return err
+		}
+
+		// Then kill the instance. The selection may have moved while a push ran first, so kill it by reference.
+		m.list.KillInstance(selected)
+
+		if deleteBranch {
+			if err := worktree.DeleteBranch(); err != nil {
+				return err
+			}
+		}
+		return instanceChangedMsg{}
+	}
+}
+
+// runBusy runs op in the background while showing status next to a spinner. Only one operation runs at a time.
+// onSuccess, which may be nil, is called on the UI goroutine once op completes without error.
+func (m *home) runBusy(instance *session.Instance, status string, op func() error, onSuccess func() tea.Cmd) tea.Cmd {
+	return m.runBusyThen(instance, status, op, func(err error) tea.Cmd {
+		if err != nil {
+			return m.handleError(err)
+		}
+		if onSuccess != nil {
+			return onSuccess()
+		}
+		return nil
+	})
+}
+
+// runBusyThen is like runBusy, but onDone is called with the result whether op succeeded or not. It may start the
+// next operation, e.g. to work through several instances one at a time.
+func (m *home) runBusyThen(instance *session.Instance, status string, op func() error, onDone func(err error) tea.Cmd) tea.Cmd {
+	if m.busy != nil {
+		return m.handleError(fmt.Errorf("please wait: %s", m.busy.status))
+	}
+	m.busy = &busyOperation{instance: instance, status: status, onDone: onDone}
+	m.errBox.Clear()
+	m.errBox.SetStatus(fmt.Sprintf("%s %s", m.spinner.View(), status))
+	return func() tea.Msg {
+		return busyDoneMsg{err: op()}
+	}
+}
+
+// finishBusy clears the background operation once it completes and reports its result.
+func (m *home) finishBusy(msg busyDoneMsg) tea.Cmd {
+	busy := m.busy
+	m.busy = nil
+	m.errBox.ClearStatus()
+	if busy == nil {
+		return nil
+	}
+
+	if m.quitAfterBusy {
+		m.quitAfterBusy = false
+		busy.onDone(msg.err)
+		// Don't start whatever onDone would do next
+		m.busy = nil
+		m.errBox.ClearStatus()
+		_, cmd := m.saveAndQuit()
+		return cmd
+	}
+
+	cmds := []tea.Cmd{busy.onDone(msg.err)}
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		cmds = append(cmds, m.handleError(err))
+	}
+	return tea.Batch(append(cmds, m.instanceChanged())...)
+}
+
+// maxConflictFiles is how many of the files with conflicts the merge conflict message lists
+const maxConflictFiles = 8
+
+// mergeIntoBase merges the branch of instance into the base branch in the background. If the merge has conflicts, it's
+// left in progress for the user to resolve, or aborted if they choose to.
+func (m *home) mergeIntoBase(instance *session.Instance) tea.Cmd {
+	var base string
+	merge := func() (err error) {
+		base, err = instance.MergeIntoBase(m.appConfig.MergeBaseBranch, git.MergeMode(m.appConfig.MergeMode))
+		return err
+	}
+	return m.runBusyThen(instance, fmt.Sprintf("Merging '%s'...", instance.Title), merge, func(err error) tea.Cmd {
+		var conflictErr *git.MergeConflictError
+		if !errors.As(err, &conflictErr) {
+			if err != nil {
+				return m.handleError(err)
+			}
+			return m.handleError(fmt.Errorf("✅ Merged %s into %s", instance.Branch, base))
+		}
+
+		log.WarningLog.Print(conflictErr)
+		files := conflictErr.Files
+		if len(files) > maxConflictFiles {
+			files = append(files[:maxConflictFiles:maxConflictFiles], fmt.Sprintf("...and %d more", len(conflictErr.Files)-maxConflictFiles))
+		}
+		message := fmt.Sprintf("[!] Merging %s into %s has conflicts in:\n%s", conflictErr.Branch, conflictErr.Base,
+			strings.Join(files, "\n"))
+		return m.chooseAction(message, []overlay.Choice{
+			{Key: "l", Label: "Leave the merge to resolve by hand"},
+			{Key: "a", Label: "Abort the merge"},
+		}, func(key string) (tea.Model, tea.Cmd) {
+			if key != "a" {
+				return m, m.handleError(fmt.Errorf("merge of %s left in progress, resolve the conflicts in the main "+
+					"repository and commit", conflictErr.Branch))
+			}
+			worktree, err := instance.GetGitWorktree()
+			if err != nil {
+				return m, m.handleError(err)
+			}
+			if err := worktree.AbortMerge(); err != nil {
+				return m, m.handleError(err)
+			}
+			return m, m.handleError(fmt.Errorf("merge of %s into %s aborted", conflictErr.Branch, conflictErr.Base))
+		})
+	})
+}
+
+// pushConfirmWidth is the width of the push confirmation, which is wider than others to fit file names
+const pushConfirmWidth = 70
+
+// maxPushPreviewFiles is how many of the files to be committed the push confirmation lists
+const maxPushPreviewFiles = 10
+
+// pushConfirmMessage describes what pushing the session commits and where it goes
+func pushConfirmMessage(title string, preview git.PushPreview) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[!] Push changes from session '%s'?\n\n", title)
+	if len(preview.Files) == 0 {
+		b.WriteString("No uncommitted changes to commit.\n")
+	} else {
+		fmt.Fprintf(&b, "Files to commit (%d):\n", len(preview.Files))
+		for idx, file := range preview.Files {
+			if idx == maxPushPreviewFiles {
+				fmt.Fprintf(&b, "  ...and %d more\n", len(preview.Files)-maxPushPreviewFiles)
+				break
+			}
+			fmt.Fprintf(&b, "  %s\n", file)
+		}
+	}
+
+	commits := preview.Unpushed
+	if len(preview.Files) > 0 {
+		commits++
+	}
+	fmt.Fprintf(&b, "\nCommits to push to %s: %d", preview.Target(), commits)
+	if preview.NewBranch {
+		b.WriteString(" (new branch)")
+	}
+	if preview.RemoteURL != "" {
+		fmt.Fprintf(&b, "\n  %s", preview.RemoteURL)
+	}
+	return b.String()
+}
+
+// confirmAction shows a confirmation modal and stores the action to execute on confirm. The action runs on the UI
+// goroutine and its msg, e.g. an error or instanceChangedMsg, is handled by Update once the modal closes.
 func (m *home) confirmAction(message string, action tea.Cmd) tea.Cmd {
 	m.state = stateConfirm
 
@@ -855,21 +2474,237 @@ func (m *home) confirmAction(message string, action tea.Cmd) tea.Cmd {
 		m.state = stateDefault
 		// Execute the action if it exists
 		if action != nil {
-			_ = action()
+			m.confirmedMsg = action()
+		}
+	}
+
+	m.confirmationOverlay.OnCancel = func() {
+		m.state = stateDefault
+	}
+
+	return nil
+}
+
+// cleanupZombieSessions kills the claude-squad tmux sessions that don't belong to a running instance or to any saved
+// instance. It returns how many were killed.
+func (m *home) cleanupZombieSessions() (int, error) {
+	var titles []string
+	for _, instance := range m.list.GetInstances() {
+		if instance.Started() && !instance.Paused() {
+			titles = append(titles, instance.Title)
+		}
+	}
+	// Other claude-squad processes, e.g. in other repos, save their instances to the same state file. Read it from disk
+	// rather than using our copy so that their sessions are left alone.
+	saved, err := session.NewStorage(config.LoadState())
+	if err != nil {
+		return 0, err
+	}
+	savedTitles, err := saved.Titles()
+	if err != nil {
+		return 0, err
+	}
+	titles = append(titles, savedTitles...)
+	killed, err := tmux.CleanupZombieSessions(cmd2.MakeExecutor(), titles)
+	if len(killed) > 0 {
+		log.InfoLog.Printf("killed %d zombie tmux sessions: %s", len(killed), strings.Join(killed, ", "))
+	}
+	return len(killed), err
+}
+
+// checkTmuxServer returns true if there are running instances but the tmux server is gone, until the user answers the
+// offer to recreate the sessions of all running instances from their worktrees. If they decline, it returns false and
+// the instances are updated as usual, without restarting them one by one.
+func (m *home) checkTmuxServer(serverRunning bool) bool {
+	var running []*session.Instance
+	for _, instance := range m.list.GetInstances() {
+		if instance.Started() && !instance.Paused() {
+			running = append(running, instance)
 		}
 	}
+	if len(running) == 0 || serverRunning {
+		m.tmuxServerDead = false
+		m.tmuxServerDeclined = false
+		return false
+	}
+	if m.tmuxServerDeclined {
+		return false
+	}
+	if m.tmuxServerDead {
+		return true
+	}
+	if m.state != stateDefault {
+		// Don't interrupt whatever the user is doing. Ask once they're done.
+		return true
+	}
+	m.tmuxServerDead = true
+
+	log.ErrorLog.Printf("tmux server is not running, %d sessions are gone", len(running))
 
+	message := fmt.Sprintf("[!] tmux server died. Recreate %d sessions?", len(running))
+	m.confirmAction(message, func() tea.Msg {
+		var errs []error
+		for _, instance := range running {
+			if err := instance.RecreateSession(); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			log.InfoLog.Printf("recreated tmux session for '%s'", instance.Title)
+		}
+		m.tmuxServerDead = false
+		return errors.Join(errs...)
+	})
 	m.confirmationOverlay.OnCancel = func() {
 		m.state = stateDefault
+		m.tmuxServerDeclined = true
+	}
+	return true
+}
+
+// copyToClipboard copies text to the system clipboard. If the clipboard is disabled or unavailable (e.g. on a headless
+// server), the text is shown instead so that the user can copy it by hand: in the error box, or in an overlay if it
+// has several lines.
+func (m *home) copyToClipboard(label, text string) tea.Cmd {
+	if m.appConfig.ClipboardEnabled && !clipboard.Unsupported {
+		err := clipboard.WriteAll(text)
+		if err == nil {
+			return m.handleError(fmt.Errorf("📋 Copied the %s", label))
+		}
+		log.WarningLog.Printf("could not copy %s to clipboard: %v", label, err)
+	}
+	if strings.Contains(text, "\n") {
+		// Too long for the error box
+		m.showDetails(fmt.Sprintf("The %s (not copied to clipboard)", label), text)
+		return nil
+	}
+	return m.handleError(fmt.Errorf("%s (not copied to clipboard): %s", label, text))
+}
+
+// finalizeNewInstance starts the instance being created and saves it to storage. Creation is all-or-nothing: if
+// starting or saving fails, the instance is killed, which cleans up its tmux session and worktree, and removed from
+// the list. finalizer is the one returned when the instance was added to the list.
+func (m *home) finalizeNewInstance(instance *session.Instance, watchdogEnabled bool, finalizer func()) error {
+	if err := m.checkNewInstanceProgram(instance); err != nil {
+		return err
+	}
+	if err := instance.Start(true); err != nil {
+		m.list.KillInstance(instance)
+		return err
+	}
+	return m.saveNewInstance(instance, watchdogEnabled, finalizer)
+}
+
+// checkNewInstanceProgram checks the program of the instance being created before setting up the worktree and tmux
+// session for it. If it fails, the instance is removed from the list.
+func (m *home) checkNewInstanceProgram(instance *session.Instance) error {
+	if wrapper := m.appConfig.ProgramWrapper; wrapper != "" {
+		if err := session.CheckProgramWrapper(wrapper); err != nil {
+			m.list.KillInstance(instance)
+			return err
+		}
+	}
+	if !m.appConfig.SkipProgramCheck {
+		if err := session.CheckProgram(instance.Program); err != nil {
+			m.list.KillInstance(instance)
+			return err
+		}
 	}
+	return nil
+}
+
+// saveNewInstance saves the instance being created once it started, or kills it if saving fails
+func (m *home) saveNewInstance(instance *session.Instance, watchdogEnabled bool, finalizer func()) error {
+	instance.InitializeWatchdog(watchdogEnabled)
+
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		m.list.KillInstance(instance)
+		return fmt.Errorf("failed to save new instance: %w", err)
+	}
+
+	// Instance added successfully, call the finalizer.
+	finalizer()
+	return nil
+}
+
+// cloneNewInstance clones the repository of the instance being created and starts it in the background, like
+// finalizeNewInstance does in the foreground. The user can keep working with the other instances meanwhile.
+func (m *home) cloneNewInstance(instance *session.Instance, watchdogEnabled bool, template *config.TemplateSpec) tea.Cmd {
+	// Another instance may be created while this one clones, so hold on to the finalizer of this one
+	finalizer := m.newInstanceFinalizer
+	if m.busy != nil {
+		m.list.KillInstance(instance)
+		m.promptAfterName = false
+		return tea.Batch(m.instanceChanged(), m.handleError(fmt.Errorf("please wait: %s", m.busy.status)))
+	}
+	if err := m.checkNewInstanceProgram(instance); err != nil {
+		m.promptAfterName = false
+		return tea.Batch(m.instanceChanged(), m.handleError(err))
+	}
+	status := fmt.Sprintf("Cloning %s for '%s'...", instance.CloneURL(), instance.Title)
+	return m.runBusyThen(instance, status, func() error {
+		return instance.Start(true)
+	}, func(err error) tea.Cmd {
+		if err != nil {
+			m.list.KillInstance(instance)
+		} else {
+			err = m.saveNewInstance(instance, watchdogEnabled, finalizer)
+		}
+		if err != nil {
+			m.promptAfterName = false
+			return m.handleError(err)
+		}
+		if m.state != stateDefault {
+			// Don't interrupt whatever the user is doing meanwhile
+			m.promptAfterName = false
+			return m.handleError(fmt.Errorf("✓ '%s' is ready", instance.Title))
+		}
+		m.showNewInstance(template)
+		return tea.WindowSize()
+	})
+}
 
+// showNewInstance shows what follows the creation of an instance: the prompt input if the user asked for it or the
+// template has a prompt, and the help screen otherwise
+func (m *home) showNewInstance(template *config.TemplateSpec) {
+	if template != nil && template.Prompt != "" {
+		// Pre-fill the prompt from the template so the user can review it before sending.
+		m.promptAfterName = true
+	}
+	if m.promptAfterName {
+		initialPrompt := ""
+		if template != nil {
+			initialPrompt = template.Prompt
+		}
+		m.state = statePrompt
+		m.menu.SetState(ui.StatePrompt)
+		// Initialize the text input overlay
+		m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", initialPrompt)
+		m.promptAfterName = false
+	} else {
+		m.menu.SetState(ui.StateDefault)
+		m.showHelpScreen(helpTypeInstanceStart, nil)
+	}
+}
+
+// chooseAction shows a multi-choice modal. onChoice is called with the key of the selected choice. It is not
+// called if the modal is canceled with esc.
+func (m *home) chooseAction(message string, choices []overlay.Choice, onChoice func(key string) (tea.Model, tea.Cmd)) tea.Cmd {
+	m.state = stateChoice
+	m.multiChoiceOverlay = overlay.NewMultiChoiceOverlay(message, choices)
+	m.multiChoiceOverlay.SetWidth(50)
+	m.onChoice = onChoice
 	return nil
 }
 
 func (m *home) View() string {
-	listWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.list.String())
-	previewWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.tabbedWindow.String())
-	listAndPreview := lipgloss.JoinHorizontal(lipgloss.Top, listWithPadding, previewWithPadding)
+	var listAndPreview string
+	if m.focusMode {
+		listAndPreview = lipgloss.JoinVertical(lipgloss.Left, m.list.String(), m.tabbedWindow.String())
+	} else {
+		listWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.list.String())
+		previewWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.tabbedWindow.String())
+		listAndPreview = lipgloss.JoinHorizontal(lipgloss.Top, listWithPadding, previewWithPadding)
+	}
 
 	mainView := lipgloss.JoinVertical(
 		lipgloss.Center,
@@ -893,6 +2728,11 @@ func (m *home) View() string {
 			log.ErrorLog.Printf("confirmation overlay is nil")
 		}
 		return overlay.PlaceOverlay(0, 0, m.confirmationOverlay.Render(), mainView, true, true)
+	} else if m.state == stateChoice {
+		if m.multiChoiceOverlay == nil {
+			log.ErrorLog.Printf("multi-choice overlay is nil")
+		}
+		return overlay.PlaceOverlay(0, 0, m.multiChoiceOverlay.Render(), mainView, true, true)
 	}
 
 	return mainView
@@ -4,12 +4,17 @@ import (
 	"github.com/smtg-ai/claude-squad/config"
 	"github.com/smtg-ai/claude-squad/log"
 	"github.com/smtg-ai/claude-squad/session"
+	"github.com/smtg-ai/claude-squad/session/tmux"
 	"github.com/smtg-ai/claude-squad/ui"
 	"github.com/smtg-ai/claude-squad/ui/overlay"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -30,14 +35,64 @@ func TestMain(m *testing.M) {
 	os.Exit(exitCode)
 }
 
+// testHomeOption overrides part of the home built by newTestHome
+type testHomeOption func(t *testing.T, h *home)
+
+// withState starts the home in the given state
+func withState(state state) testHomeOption {
+	return func(t *testing.T, h *home) {
+		h.state = state
+	}
+}
+
+// withAppConfig replaces the default config
+func withAppConfig(appConfig *config.Config) testHomeOption {
+	return func(t *testing.T, h *home) {
+		h.appConfig = appConfig
+	}
+}
+
+// withBackend stores the instances in the given backend instead of a recordingStorage
+func withBackend(backend config.InstanceStorage) testHomeOption {
+	return func(t *testing.T, h *home) {
+		storage, err := session.NewStorage(backend)
+		require.NoError(t, err)
+		h.storage = storage
+	}
+}
+
+// withKeySent skips menu highlighting so key presses are handled right away
+func withKeySent() testHomeOption {
+	return func(t *testing.T, h *home) {
+		h.keySent = true
+	}
+}
+
+// newTestHome returns a home with an empty list, the default config and in-memory storage, with opts applied
+func newTestHome(t *testing.T, opts ...testHomeOption) *home {
+	t.Helper()
+	spinner := spinner.New(spinner.WithSpinner(spinner.MiniDot))
+	h := &home{
+		ctx:          context.Background(),
+		state:        stateDefault,
+		appConfig:    config.DefaultConfig(),
+		list:         ui.NewList(&spinner, false),
+		menu:         ui.NewMenu(),
+		errBox:       ui.NewErrBox(),
+		spinner:      spinner,
+		tabbedWindow: ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane()),
+	}
+	withBackend(&recordingStorage{})(t, h)
+	for _, opt := range opts {
+		opt(t, h)
+	}
+	return h
+}
+
 // TestConfirmationModalStateTransitions tests state transitions without full instance setup
 func TestConfirmationModalStateTransitions(t *testing.T) {
 	// Create a minimal home struct for testing state transitions
-	h := &home{
-		ctx:       context.Background(),
-		state:     stateDefault,
-		appConfig: config.DefaultConfig(),
-	}
+	h := newTestHome(t)
 
 	t.Run("shows confirmation on D press", func(t *testing.T) {
 		// Simulate pressing 'D'
@@ -107,19 +162,9 @@ func TestConfirmationModalStateTransitions(t *testing.T) {
 
 // TestConfirmationModalKeyHandling tests the actual key handling in confirmation state
 func TestConfirmationModalKeyHandling(t *testing.T) {
-	// Import needed packages
-	spinner := spinner.New(spinner.WithSpinner(spinner.MiniDot))
-	list := ui.NewList(&spinner, false)
-
 	// Create enough of home struct to test handleKeyPress in confirmation state
-	h := &home{
-		ctx:                 context.Background(),
-		state:               stateConfirm,
-		appConfig:           config.DefaultConfig(),
-		list:                list,
-		menu:                ui.NewMenu(),
-		confirmationOverlay: overlay.NewConfirmationOverlay("Kill session?"),
-	}
+	h := newTestHome(t, withState(stateConfirm))
+	h.confirmationOverlay = overlay.NewConfirmationOverlay("Kill session?")
 
 	testCases := []struct {
 		name              string
@@ -229,8 +274,7 @@ func TestConfirmationMessageFormatting(t *testing.T) {
 // TestConfirmationFlowSimulation tests the confirmation flow by simulating the state changes
 func TestConfirmationFlowSimulation(t *testing.T) {
 	// Create a minimal setup
-	spinner := spinner.New(spinner.WithSpinner(spinner.MiniDot))
-	list := ui.NewList(&spinner, false)
+	h := newTestHome(t)
 
 	// Add test instance
 	instance, err := session.NewInstance(session.InstanceOptions{
@@ -240,16 +284,8 @@ func TestConfirmationFlowSimulation(t *testing.T) {
 		AutoYes: false,
 	})
 	require.NoError(t, err)
-	_ = list.AddInstance(instance)
-	list.SetSelectedInstance(0)
-
-	h := &home{
-		ctx:       context.Background(),
-		state:     stateDefault,
-		appConfig: config.DefaultConfig(),
-		list:      list,
-		menu:      ui.NewMenu(),
-	}
+	_ = h.list.AddInstance(instance)
+	h.list.SetSelectedInstance(0)
 
 	// Simulate what happens when D is pressed
 	selected := h.list.GetSelectedInstance()
@@ -271,11 +307,7 @@ func TestConfirmationFlowSimulation(t *testing.T) {
 
 // TestConfirmActionWithDifferentTypes tests that confirmAction works with different action types
 func TestConfirmActionWithDifferentTypes(t *testing.T) {
-	h := &home{
-		ctx:       context.Background(),
-		state:     stateDefault,
-		appConfig: config.DefaultConfig(),
-	}
+	h := newTestHome(t)
 
 	t.Run("works with simple action returning nil", func(t *testing.T) {
 		actionCalled := false
@@ -361,11 +393,7 @@ func TestConfirmActionWithDifferentTypes(t *testing.T) {
 
 // TestMultipleConfirmationsDontInterfere tests that multiple confirmations don't interfere with each other
 func TestMultipleConfirmationsDontInterfere(t *testing.T) {
-	h := &home{
-		ctx:       context.Background(),
-		state:     stateDefault,
-		appConfig: config.DefaultConfig(),
-	}
+	h := newTestHome(t)
 
 	// First confirmation
 	action1Called := false
@@ -433,13 +461,29 @@ func TestMultipleConfirmationsDontInterfere(t *testing.T) {
 	assert.True(t, action1Called, "First action should be callable after being replaced")
 }
 
+// TestConfirmedActionErrorIsShown tests that the msg of a confirmed action is handed to Update, so that its error is
+// shown and hidden again
+func TestConfirmedActionErrorIsShown(t *testing.T) {
+	h := newTestHome(t, withKeySent())
+	h.errBox.SetSize(100, 1)
+	h.confirmAction("[!] Do it?", func() tea.Msg {
+		return fmt.Errorf("it failed")
+	})
+
+	_, cmd := h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	assert.Equal(t, stateDefault, h.state)
+	require.NotNil(t, cmd)
+	msg := cmd()
+	require.EqualError(t, msg.(error), "it failed")
+
+	_, hide := h.Update(msg)
+	assert.Contains(t, h.errBox.String(), "it failed")
+	assert.NotNil(t, hide, "the error is hidden again after a while")
+}
+
 // TestConfirmationModalVisualAppearance tests that confirmation modal has distinct visual appearance
 func TestConfirmationModalVisualAppearance(t *testing.T) {
-	h := &home{
-		ctx:       context.Background(),
-		state:     stateDefault,
-		appConfig: config.DefaultConfig(),
-	}
+	h := newTestHome(t)
 
 	// Create a test confirmation overlay
 	message := "[!] Delete everything?"
@@ -468,8 +512,7 @@ func TestConfirmationModalVisualAppearance(t *testing.T) {
 // TestContinuousModeFixed tests that the continuous mode functionality works without panicking
 func TestContinuousModeFixed(t *testing.T) {
 	// Create a minimal setup
-	spinner := spinner.New(spinner.WithSpinner(spinner.MiniDot))
-	list := ui.NewList(&spinner, false)
+	h := newTestHome(t, withState(statePrompt))
 
 	// Add test instance 
 	instance, err := session.NewInstance(session.InstanceOptions{
@@ -479,20 +522,12 @@ func TestContinuousModeFixed(t *testing.T) {
 		AutoYes: false,
 	})
 	require.NoError(t, err)
-	_ = list.AddInstance(instance)
-	list.SetSelectedInstance(0)
+	_ = h.list.AddInstance(instance)
+	h.list.SetSelectedInstance(0)
 
-	h := &home{
-		ctx:                     context.Background(),
-		state:                   statePrompt,
-		appConfig:               config.DefaultConfig(),
-		list:                    list,
-		menu:                    ui.NewMenu(),
-		errBox:                  ui.NewErrBox(),
-		isContinuousModeInput:   true,
-		continuousModeTarget:    instance,
-		textInputOverlay:        overlay.NewTextInputOverlay("Enter duration for continuous mode (5m, 1h, etc.) or press Enter for indefinite:", ""),
-	}
+	h.isContinuousModeInput = true
+	h.continuousModeTarget = instance
+	h.textInputOverlay = overlay.NewTextInputOverlay("Enter duration for continuous mode (5m, 1h, etc.) or press Enter for indefinite:", "")
 
 	// This should work without panicking after the fix
 	t.Run("continuous mode duration entry works without panic", func(t *testing.T) {
@@ -544,3 +579,683 @@ func TestTextInputSingleLine(t *testing.T) {
 	assert.True(t, shouldClose, "Enter should submit in single-line mode")
 	assert.True(t, overlay.IsSubmitted(), "Should be marked as submitted after Enter")
 }
+
+// TestParseContinuousModeDuration tests the parsing of the duration entered in the continuous mode overlay
+func TestParseContinuousModeDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{input: "30", expected: 30 * time.Minute},
+		{input: "30m", expected: 30 * time.Minute},
+		{input: "1h30m", expected: 90 * time.Minute},
+		{input: " 2h ", expected: 2 * time.Hour},
+		{input: "", expected: 0},
+		{input: "indefinite", expected: 0},
+		{input: "Indefinite", expected: 0},
+		{input: "abc", wantErr: true},
+		{input: "0", wantErr: true},
+		{input: "-5m", wantErr: true},
+		{input: "25h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.input), func(t *testing.T) {
+			duration, err := parseContinuousModeDuration(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, duration)
+		})
+	}
+}
+
+// TestContinuousModeInvalidDurationKeepsOverlayOpen tests that an invalid duration is reported in the overlay
+// instead of closing it
+func TestContinuousModeInvalidDurationKeepsOverlayOpen(t *testing.T) {
+	h := newTestHome(t, withState(statePrompt))
+
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title:   "test-session",
+		Path:    t.TempDir(),
+		Program: "claude",
+	})
+	require.NoError(t, err)
+	_ = h.list.AddInstance(instance)
+	h.list.SetSelectedInstance(0)
+
+	h.isContinuousModeInput = true
+	h.continuousModeTarget = instance
+	h.textInputOverlay = overlay.NewTextInputOverlay("Enter duration:", "abc")
+
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, statePrompt, h.state)
+	require.NotNil(t, h.textInputOverlay)
+	assert.False(t, h.textInputOverlay.IsSubmitted())
+	assert.Contains(t, h.textInputOverlay.Render(), "invalid duration")
+	assert.False(t, instance.IsContinuousMode())
+
+	// Correcting the input clears the error and enables continuous mode
+	for _, key := range []tea.KeyType{tea.KeyBackspace, tea.KeyBackspace, tea.KeyBackspace} {
+		h.handleKeyPress(tea.KeyMsg{Type: key})
+	}
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("30")})
+	assert.NotContains(t, h.textInputOverlay.Render(), "invalid duration")
+
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, stateDefault, h.state)
+	assert.Nil(t, h.textInputOverlay)
+	assert.True(t, instance.IsContinuousMode())
+	assert.Equal(t, 30*time.Minute, instance.ContinuousModeDuration)
+}
+
+// TestMultiChoiceModalKeyHandling tests that the multi-choice modal dispatches the selected choice
+func TestMultiChoiceModalKeyHandling(t *testing.T) {
+	h := newTestHome(t)
+
+	var chosen string
+	setup := func() {
+		chosen = ""
+		h.chooseAction("[!] 1 session(s) running — quit?", []overlay.Choice{
+			{Key: "s", Label: "Quit and save"},
+			{Key: "d", Label: "Quit without saving"},
+			{Key: "c", Label: "Cancel"},
+		}, func(key string) (tea.Model, tea.Cmd) {
+			chosen = key
+			return h, nil
+		})
+	}
+
+	t.Run("other keys are ignored", func(t *testing.T) {
+		setup()
+		h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+		assert.Equal(t, stateChoice, h.state)
+		assert.NotNil(t, h.multiChoiceOverlay)
+		assert.Empty(t, chosen)
+	})
+
+	t.Run("choice key selects and closes", func(t *testing.T) {
+		setup()
+		h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+		assert.Equal(t, stateDefault, h.state)
+		assert.Nil(t, h.multiChoiceOverlay)
+		assert.Equal(t, "d", chosen)
+	})
+
+	t.Run("esc cancels without choosing", func(t *testing.T) {
+		setup()
+		h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEscape})
+		assert.Equal(t, stateDefault, h.state)
+		assert.Nil(t, h.multiChoiceOverlay)
+		assert.Empty(t, chosen)
+	})
+}
+
+// failingStorage is an InstanceStorage whose saves always fail
+type failingStorage struct{}
+
+func (failingStorage) SaveInstances(json.RawMessage) error { return fmt.Errorf("disk full") }
+func (failingStorage) GetInstances() json.RawMessage       { return json.RawMessage("[]") }
+func (failingStorage) DeleteAllInstances() error           { return nil }
+
+// TestNewInstanceRollsBackOnSaveFailure tests that a new instance is fully cleaned up when it can't be saved
+func TestNewInstanceRollsBackOnSaveFailure(t *testing.T) {
+	for _, bin := range []string{"git", "tmux"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	// Keep the config and worktrees out of the real home directory.
+	t.Setenv("HOME", t.TempDir())
+
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	h := newTestHome(t, withState(stateNew), withBackend(failingStorage{}), withKeySent())
+
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title:   "rollback-test",
+		Path:    repoDir,
+		Program: "sh",
+	})
+	require.NoError(t, err)
+	h.newInstanceFinalizer = h.list.AddInstance(instance)
+	h.list.SetSelectedInstance(0)
+
+	model, _ := h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	homeModel, ok := model.(*home)
+	require.True(t, ok)
+
+	assert.Equal(t, stateDefault, homeModel.state)
+	assert.Equal(t, 0, homeModel.list.NumInstances(), "instance should be removed from the list")
+	assert.Error(t, exec.Command("tmux", "has-session", "-t", "claudesquad_rollback-test").Run(),
+		"tmux session should be killed")
+
+	out, err := exec.Command("git", "-C", repoDir, "worktree", "list", "--porcelain").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.NotContains(t, string(out), "rollback-test", "worktree should be cleaned up")
+	out, err = exec.Command("git", "-C", repoDir, "branch", "--list").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.NotContains(t, string(out), "rollback-test", "branch should be deleted")
+}
+
+// TestCloneNewInstanceRunsInBackground tests that a new instance of a remote repository is cloned in the background,
+// and removed from the list if the clone fails
+func TestCloneNewInstanceRunsInBackground(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// Keep the config and clones out of the real home directory.
+	t.Setenv("HOME", t.TempDir())
+
+	h := newTestHome(t)
+
+	// Nothing listens on port 1, so the clone fails right away.
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title:   "clone-test",
+		Path:    "git://127.0.0.1:1/owner/repo.git",
+		Program: "sh",
+	})
+	require.NoError(t, err)
+	h.newInstanceFinalizer = h.list.AddInstance(instance)
+	h.list.SetSelectedInstance(0)
+
+	cmd := h.cloneNewInstance(instance, false, nil)
+	require.NotNil(t, cmd)
+	require.NotNil(t, h.busy, "clone should run in the background")
+	assert.Equal(t, 1, h.list.NumInstances(), "instance should stay listed while cloning")
+
+	msg := cmd()
+	_, ok := msg.(busyDoneMsg)
+	require.True(t, ok)
+	h.Update(msg)
+
+	assert.Nil(t, h.busy)
+	assert.Equal(t, 0, h.list.NumInstances(), "instance should be removed from the list")
+}
+
+// TestBusyOperationBlocksInput tests that a long operation runs in the background and that input which could
+// interfere with it is ignored until it completes
+func TestBusyOperationBlocksInput(t *testing.T) {
+	h := newTestHome(t, withBackend(failingStorage{}))
+
+	release := make(chan struct{})
+	succeeded := false
+	cmd := h.runBusy(nil, "Restarting 'test'...", func() error {
+		<-release
+		return nil
+	}, func() tea.Cmd {
+		succeeded = true
+		return nil
+	})
+	require.NotNil(t, cmd)
+	assert.Contains(t, h.errBox.String(), "Restarting 'test'...")
+
+	// Only one operation runs at a time
+	h.runBusy(nil, "Pausing 'test'...", func() error { return nil }, nil)
+	require.NotNil(t, h.busy)
+	assert.Equal(t, "Restarting 'test'...", h.busy.status)
+
+	// Keys that act on sessions are ignored
+	h.keySent = true
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	assert.Equal(t, stateDefault, h.state)
+	assert.Equal(t, 0, h.list.NumInstances())
+
+	close(release)
+	h.Update(cmd())
+	assert.Nil(t, h.busy)
+	assert.True(t, succeeded)
+	assert.NotContains(t, h.errBox.String(), "Restarting")
+}
+
+// TestQuitWhileBusy tests that quitting while a background operation runs waits for it, saves and quits, and that
+// quitting again doesn't wait
+func TestQuitWhileBusy(t *testing.T) {
+	for _, forceQuit := range []bool{false, true} {
+		backend := &recordingStorage{}
+		h := newTestHome(t, withBackend(backend), withKeySent())
+
+		next := false
+		cmd := h.runBusyThen(nil, "Restarting 'test'...", func() error { return nil }, func(error) tea.Cmd {
+			// Chains another operation, which doesn't run when quitting
+			return h.runBusy(nil, "Restarting 'other'...", func() error { next = true; return nil }, nil)
+		})
+		require.NotNil(t, cmd)
+
+		_, quitCmd := h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+		assert.Nil(t, quitCmd, "quitting waits for the operation")
+		assert.True(t, h.quitAfterBusy)
+		assert.Contains(t, h.errBox.String(), "Quitting once done")
+
+		if forceQuit {
+			_, quitCmd = h.handleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlC})
+		} else {
+			_, quitCmd = h.Update(cmd())
+		}
+		require.NotNil(t, quitCmd)
+		assert.IsType(t, tea.QuitMsg{}, quitCmd())
+		assert.Equal(t, 1, backend.saves)
+		assert.False(t, next)
+	}
+}
+
+// TestOfferResumeRunsInBackground tests that resuming a paused instance to attach to it doesn't block the UI
+func TestOfferResumeRunsInBackground(t *testing.T) {
+	h := newTestHome(t, withKeySent())
+	h.errBox.SetSize(100, 1)
+	instance, err := session.NewInstance(session.InstanceOptions{Title: "paused", Path: t.TempDir(), Program: "claude"})
+	require.NoError(t, err)
+	h.list.AddInstance(instance)()
+
+	h.offerResume(instance)
+	_, cmd := h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	require.NotNil(t, cmd)
+	require.NotNil(t, h.busy)
+	assert.Contains(t, h.errBox.String(), "Resuming 'paused'...")
+
+	// The instance was never started, so resuming it fails once the operation is done
+	h.Update(cmd())
+	assert.Nil(t, h.busy)
+	assert.Contains(t, h.errBox.String(), "cannot resume")
+}
+
+// TestPauseAllRunsInBackground tests that pausing all instances runs one pause at a time in the background
+func TestPauseAllRunsInBackground(t *testing.T) {
+	for _, bin := range []string{"git", "tmux"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	// Keep the config and worktrees out of the real home directory.
+	t.Setenv("HOME", t.TempDir())
+
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	backend := &recordingStorage{}
+	h := newTestHome(t, withBackend(backend))
+	h.errBox.SetSize(100, 1)
+	instance, err := session.NewInstance(session.InstanceOptions{Title: "pause-all-test", Path: repoDir, Program: "sh"})
+	require.NoError(t, err)
+	require.NoError(t, instance.Start(true))
+	defer instance.Kill()
+	h.list.AddInstance(instance)()
+	// Not started yet, so there's nothing to pause
+	pending, err := session.NewInstance(session.InstanceOptions{Title: "pending", Path: repoDir, Program: "sh"})
+	require.NoError(t, err)
+	h.list.AddInstance(pending)()
+
+	_, cmd := h.Update(pauseAllMsg{})
+	require.NotNil(t, cmd)
+	require.NotNil(t, h.busy)
+	assert.Contains(t, h.errBox.String(), "Pausing 'pause-all-test' (1/1)...")
+	assert.False(t, instance.Paused(), "the pause runs once the command does")
+
+	h.Update(cmd())
+	assert.Nil(t, h.busy)
+	assert.True(t, instance.Paused())
+	assert.Contains(t, h.errBox.String(), "Paused 1 sessions")
+	assert.Equal(t, 1, backend.saves)
+}
+
+// TestPromptAfterCreateOpensPromptInput tests that with prompt_after_create on, n asks for a prompt once the new
+// session is named and started, and N creates one without a prompt
+func TestPromptAfterCreateOpensPromptInput(t *testing.T) {
+	for _, bin := range []string{"git", "tmux"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	// Keep the config and worktrees out of the real home directory.
+	t.Setenv("HOME", t.TempDir())
+
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	// New sessions are created in the current directory
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(repoDir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	appConfig := config.DefaultConfig()
+	appConfig.PromptAfterCreate = true
+	h := newTestHome(t, withAppConfig(appConfig))
+	h.program = "sh"
+
+	// Each key press skips the menu highlighting, as if it had been sent again after it
+	press := func(msg tea.KeyMsg) {
+		h.keySent = true
+		h.handleKeyPress(msg)
+	}
+	runes := func(s string) tea.KeyMsg {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+
+	// N swaps with n, so it creates a session without asking for a prompt
+	press(runes("N"))
+	require.Equal(t, stateNew, h.state)
+	assert.False(t, h.promptAfterName)
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	require.Equal(t, 0, h.list.NumInstances())
+
+	press(runes("n"))
+	require.Equal(t, stateNew, h.state)
+	assert.True(t, h.promptAfterName)
+	press(runes("prompt-after-create-test"))
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, 1, h.list.NumInstances())
+	instance := h.list.GetInstances()[0]
+	defer instance.Kill()
+	require.True(t, instance.Started())
+
+	assert.Equal(t, statePrompt, h.state)
+	require.NotNil(t, h.textInputOverlay)
+	assert.False(t, h.promptAfterName)
+}
+
+// TestKillDirtyInstanceAsksFirst tests that killing an instance with uncommitted changes offers to keep them
+func TestKillDirtyInstanceAsksFirst(t *testing.T) {
+	for _, bin := range []string{"git", "tmux"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	// Keep the config and worktrees out of the real home directory.
+	t.Setenv("HOME", t.TempDir())
+
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	h := newTestHome(t, withBackend(failingStorage{}))
+
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title:   "dirty-kill-test",
+		Path:    repoDir,
+		Program: "sh",
+	})
+	require.NoError(t, err)
+	require.NoError(t, instance.Start(true))
+	defer instance.Kill()
+	h.list.AddInstance(instance)()
+	h.list.SetSelectedInstance(0)
+
+	worktree, err := instance.GetGitWorktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(worktree.GetWorktreePath(), "work.txt"), []byte("unsaved"), 0644))
+
+	h.keySent = true
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	require.Equal(t, stateChoice, h.state)
+	assert.Contains(t, h.multiChoiceOverlay.Render(), "uncommitted")
+
+	// Canceling keeps the instance and its work
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	assert.Equal(t, stateDefault, h.state)
+	assert.Equal(t, 1, h.list.NumInstances())
+	assert.FileExists(t, filepath.Join(worktree.GetWorktreePath(), "work.txt"))
+}
+
+func TestCleanupSessionsAsksFirst(t *testing.T) {
+	h := newTestHome(t, withKeySent())
+
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Z")})
+	require.Equal(t, stateConfirm, h.state)
+	assert.Contains(t, h.confirmationOverlay.Render(), "tmux sessions")
+
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	assert.Equal(t, stateDefault, h.state)
+}
+
+func TestCopyToClipboardFallback(t *testing.T) {
+	appConfig := config.DefaultConfig()
+	appConfig.ClipboardEnabled = false
+	h := newTestHome(t, withAppConfig(appConfig))
+
+	// A single line fits in the error box
+	assert.NotNil(t, h.copyToClipboard("branch name", "feature"))
+	assert.Equal(t, stateDefault, h.state)
+
+	// Several lines, like a preview, are shown in an overlay
+	assert.Nil(t, h.copyToClipboard("preview of 'test'", "line 1\nline 2"))
+	assert.Equal(t, stateHelp, h.state)
+	assert.Contains(t, h.textOverlay.Render(), "line 2")
+}
+
+func TestEnterOnDeadSessionOffersRecovery(t *testing.T) {
+	for _, bin := range []string{"git", "tmux"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	// Keep the config and worktrees out of the real home directory.
+	t.Setenv("HOME", t.TempDir())
+
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	h := newTestHome(t, withBackend(failingStorage{}))
+
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title:   "deadentertest",
+		Path:    repoDir,
+		Program: "sh",
+	})
+	require.NoError(t, err)
+	require.NoError(t, instance.Start(true))
+	defer instance.Kill()
+	h.list.AddInstance(instance)()
+	h.list.SetSelectedInstance(0)
+
+	// The tmux session dies behind claude-squad's back
+	out, err := exec.Command("tmux", "kill-session", "-t", tmux.TmuxPrefix+"deadentertest").CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.False(t, instance.Paused())
+
+	h.keySent = true
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, stateChoice, h.state)
+	assert.Contains(t, h.multiChoiceOverlay.Render(), "Restart the program")
+
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	assert.Equal(t, stateDefault, h.state)
+	assert.False(t, instance.Paused())
+}
+
+func TestDecliningToRecreateSessionsResumesUpdates(t *testing.T) {
+	for _, bin := range []string{"git", "tmux"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	// Keep the config and worktrees out of the real home directory.
+	t.Setenv("HOME", t.TempDir())
+
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	h := newTestHome(t)
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title:   "declinetest",
+		Path:    repoDir,
+		Program: "sh",
+	})
+	require.NoError(t, err)
+	require.NoError(t, instance.Start(true))
+	defer instance.Kill()
+	h.list.AddInstance(instance)()
+
+	// The metadata tick holds off while the offer is open
+	require.True(t, h.checkTmuxServer(false))
+	require.Equal(t, stateConfirm, h.state)
+	require.True(t, h.checkTmuxServer(false))
+
+	h.keySent = true
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	assert.Equal(t, stateDefault, h.state)
+	assert.False(t, h.checkTmuxServer(false), "declined, the instances are updated again")
+
+	// Once the server is back and dies again, the offer comes back too
+	assert.False(t, h.checkTmuxServer(true))
+	assert.True(t, h.checkTmuxServer(false))
+	assert.Equal(t, stateConfirm, h.state)
+}
+
+// recordingStorage is an InstanceStorage that counts its saves
+type recordingStorage struct {
+	saves int
+}
+
+func (s *recordingStorage) SaveInstances(json.RawMessage) error { s.saves++; return nil }
+func (s *recordingStorage) GetInstances() json.RawMessage       { return json.RawMessage("[]") }
+func (s *recordingStorage) DeleteAllInstances() error           { return nil }
+
+func TestShutdownSavesAndQuits(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		backend config.InstanceStorage
+	}{
+		{name: "saves", backend: &recordingStorage{}},
+		// The process is terminated either way, so a failed save doesn't stop the quit
+		{name: "save fails", backend: failingStorage{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHome(t, withBackend(tt.backend))
+
+			_, cmd := h.Update(shutdownMsg{})
+			require.NotNil(t, cmd)
+			assert.IsType(t, tea.QuitMsg{}, cmd())
+			if recording, ok := tt.backend.(*recordingStorage); ok {
+				assert.Equal(t, 1, recording.saves)
+			}
+		})
+	}
+}
+
+func TestShowInstanceData(t *testing.T) {
+	h := newTestHome(t)
+	instance, err := session.NewInstance(session.InstanceOptions{Title: "debug-me", Path: t.TempDir(), Program: "claude"})
+	require.NoError(t, err)
+	instance.ContinuousMode = true
+	h.list.AddInstance(instance)()
+	h.list.SetSelectedInstance(0)
+
+	h.keySent = true
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d"), Alt: true})
+	require.Equal(t, stateHelp, h.state)
+	require.NotNil(t, h.textOverlay)
+	rendered := h.textOverlay.Render()
+	assert.Contains(t, rendered, `"title": "debug-me"`)
+	assert.Contains(t, rendered, `"continuous_mode": true`)
+}
+
+func TestKillInstanceKillsByReference(t *testing.T) {
+	spinner := spinner.New(spinner.WithSpinner(spinner.MiniDot))
+	list := ui.NewList(&spinner, false)
+	var instances []*session.Instance
+	for _, title := range []string{"first", "second", "third"} {
+		instance, err := session.NewInstance(session.InstanceOptions{Title: title, Path: t.TempDir(), Program: "claude"})
+		require.NoError(t, err)
+		list.AddInstance(instance)()
+		instances = append(instances, instance)
+	}
+
+	// The user moved on to another session while the kill of the first one was pending
+	list.SetSelectedInstance(1)
+	list.KillInstance(instances[0])
+	assert.Equal(t, []*session.Instance{instances[1], instances[2]}, list.GetInstances())
+	assert.Equal(t, instances[1], list.GetSelectedInstance())
+
+	list.KillInstance(instances[2])
+	assert.Equal(t, []*session.Instance{instances[1]}, list.GetInstances())
+	assert.Equal(t, instances[1], list.GetSelectedInstance())
+}
+
+func TestMetadataCheckTimeout(t *testing.T) {
+	h := newTestHome(t)
+	h.errBox.SetSize(100, 1)
+
+	// The check runs in the background; the UI only hears about it once it's done or slow
+	h.checkMetadata()
+	require.True(t, h.checkingMetadata)
+	seq := h.metadataSeq
+	_, cmd := h.Update(metadataCheckTimeoutMsg{seq: seq})
+	assert.Contains(t, h.errBox.String(), "tmux is not responding")
+	// The updates keep going
+	assert.False(t, h.checkingMetadata)
+	assert.NotEqual(t, seq, h.metadataSeq)
+	assert.NotNil(t, cmd)
+
+	h.errBox.Clear()
+	h.checkMetadata()
+	h.Update(metadataCheckedMsg{serverRunning: true})
+	assert.False(t, h.checkingMetadata)
+	h.Update(metadataCheckTimeoutMsg{seq: seq})
+	assert.NotContains(t, h.errBox.String(), "tmux is not responding", "a stale timeout is ignored")
+}
+
+func TestErrorHistory(t *testing.T) {
+	h := newTestHome(t)
+	h.errBox.SetSize(100, 1)
+
+	for i := 0; i < maxErrorHistory+5; i++ {
+		h.handleError(fmt.Errorf("error %d", i))
+	}
+	require.Len(t, h.errorHistory, maxErrorHistory)
+	assert.Equal(t, "error 5", h.errorHistory[0].err.Error())
+
+	// Hiding an older error doesn't hide the newest one
+	h.Update(hideErrMsg{seq: h.errorSeq - 1})
+	assert.Contains(t, h.errBox.String(), "error 24")
+
+	h.keySent = true
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlL})
+	assert.NotContains(t, h.errBox.String(), "error")
+
+	// The last error is shown again, and all of them in the overlay
+	h.keySent = true
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	assert.Contains(t, h.errBox.String(), "error 24")
+	assert.Equal(t, stateHelp, h.state)
+	require.NotNil(t, h.textOverlay)
+	assert.Contains(t, h.textOverlay.Render(), "error 5")
+}
@@ -47,18 +47,48 @@ func (h helpType) ToContent(instance *session.Instance) string {
 			headerStyle.Render("Managing:"),
 			keyStyle.Render("n")+descStyle.Render("         - Create a new session"),
 			keyStyle.Render("N")+descStyle.Render("         - Create a new session with a prompt"),
+			keyStyle.Render("b")+descStyle.Render("         - Create a new session from an existing branch"),
+			keyStyle.Render("M")+descStyle.Render("         - Merge the branch of the selected session into the base branch"),
+			keyStyle.Render("t")+descStyle.Render("         - Create a new session from a template"),
 			keyStyle.Render("D")+descStyle.Render("         - Kill (delete) the selected session"),
+			keyStyle.Render("X")+descStyle.Render("         - Kill the selected session and delete its branch"),
+			keyStyle.Render("L")+descStyle.Render("         - Cycle the color tag of the selected session"),
 			keyStyle.Render("↑/j, ↓/k")+descStyle.Render("  - Navigate between sessions"),
+			keyStyle.Render("w/W")+descStyle.Render("       - Jump to the next waiting/running session"),
+			keyStyle.Render("A")+descStyle.Render("         - Show only the sessions that need you, press again for all"),
 			keyStyle.Render("↵/o")+descStyle.Render("       - Attach to the selected session"),
+			keyStyle.Render("v")+descStyle.Render("         - Attach in a split pane when running inside tmux"),
 			keyStyle.Render("ctrl-q")+descStyle.Render("    - Detach from session"),
+			keyStyle.Render("i")+descStyle.Render("         - Interrupt the program in the selected session"),
+			keyStyle.Render("ctrl-r")+descStyle.Render("    - Restart Claude Code in the selected session, resuming its conversation"),
+			keyStyle.Render("alt-ctrl-r")+descStyle.Render(" - Restart all stalled or crashed Claude Code sessions"),
+			keyStyle.Render("u")+descStyle.Render("         - Nudge the selected session to summarize its progress"),
+			keyStyle.Render("a")+descStyle.Render("         - Queue a prompt, sent when the session is ready"),
+			keyStyle.Render("K")+descStyle.Render("         - Send keys in tmux notation, e.g. C-c or Escape, without enter"),
+			keyStyle.Render("P")+descStyle.Render("         - Ping the program to check that it's not hung"),
 			"",
 			headerStyle.Render("Handoff:"),
+			keyStyle.Render("G")+descStyle.Render("         - Set the git remote the session is pushed to, e.g. your fork"),
 			keyStyle.Render("p")+descStyle.Render("         - Commit and push branch to github"),
 			keyStyle.Render("c")+descStyle.Render("         - Checkout: commit changes and pause session"),
 			keyStyle.Render("r")+descStyle.Render("         - Resume a paused session"),
+			keyStyle.Render("R")+descStyle.Render("         - Resume a paused session and attach to it"),
+			keyStyle.Render("alt-r")+descStyle.Render("     - Resume all paused sessions"),
 			"",
 			headerStyle.Render("Other:"),
 			keyStyle.Render("tab")+descStyle.Render("       - Switch between preview and diff tabs"),
+			keyStyle.Render("F")+descStyle.Render("         - Focus mode: show only the selected session, press again for the full list"),
+			keyStyle.Render("</>")+descStyle.Render("       - Make the preview or the list wider"),
+			keyStyle.Render("/")+descStyle.Render("         - Search the preview, n/N for next/previous match, esc to stop"),
+			keyStyle.Render("f")+descStyle.Render("         - Refresh the diff now, or fix a session marked [setup incomplete]"),
+			keyStyle.Render("e")+descStyle.Render("         - Show the recent errors"),
+			keyStyle.Render("U")+descStyle.Render("         - Show recently deleted branches and worktrees, to recover them"),
+			keyStyle.Render("ctrl-l")+descStyle.Render("    - Clear the error"),
+			keyStyle.Render("H")+descStyle.Render("         - Browse saved session transcripts"),
+			keyStyle.Render("T")+descStyle.Render("         - Log the output of the selected session to a file, press again to stop"),
+			keyStyle.Render("y")+descStyle.Render("         - Copy the preview of the selected session to the clipboard"),
+			keyStyle.Render("S")+descStyle.Render("         - Safe mode: stop all automatic actions on sessions, press again to resume them"),
+			keyStyle.Render("Z")+descStyle.Render("         - Kill leftover tmux sessions that don't belong to any session"),
 			keyStyle.Render("shift-↓/↑")+descStyle.Render(" - Scroll in diff view"),
 			keyStyle.Render("q")+descStyle.Render("         - Quit the application"),
 		)
@@ -95,7 +125,7 @@ func (h helpType) ToContent(instance *session.Instance) string {
 		content := lipgloss.JoinVertical(lipgloss.Left,
 			titleStyle.Render("Checkout Instance"),
 			"",
-			"Changes will be committed and pushed to GitHub. The branch name has been copied to your clipboard for you to checkout.",
+			"Changes will be committed and pushed to GitHub. The branch name will be copied to your clipboard for you to checkout (or shown below if no clipboard is available).",
 			"",
 			"Feel free to make changes to the branch and commit them. When resuming, the session will continue from where you left off.",
 			"",
@@ -109,7 +139,7 @@ func (h helpType) ToContent(instance *session.Instance) string {
 }
 
 // showHelpScreen displays the help screen overlay if it hasn't been shown before
-func (m *home) showHelpScreen(helpType helpType, onDismiss func()) (tea.Model, tea.Cmd) {
+func (m *home) showHelpScreen(helpType helpType, onDismiss func() tea.Cmd) (tea.Model, tea.Cmd) {
 	// Get the flag for this help type
 	var helpFlag uint32
 	switch helpType {
@@ -134,14 +164,14 @@ func (m *home) showHelpScreen(helpType helpType, onDismiss func()) (tea.Model, t
 		content := helpType.ToContent(m.list.GetSelectedInstance())
 
 		m.textOverlay = overlay.NewTextOverlay(content)
-		m.textOverlay.OnDismiss = onDismiss
+		m.onHelpDismiss = onDismiss
 		m.state = stateHelp
 		return m, nil
 	}
 
 	// Skip displaying the help screen
 	if onDismiss != nil {
-		onDismiss()
+		return m, onDismiss()
 	}
 	return m, nil
 }
@@ -152,12 +182,18 @@ func (m *home) handleHelpState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	shouldClose := m.textOverlay.HandleKeyPress(msg)
 	if shouldClose {
 		m.state = stateDefault
+		var cmd tea.Cmd
+		if onDismiss := m.onHelpDismiss; onDismiss != nil {
+			m.onHelpDismiss = nil
+			cmd = onDismiss()
+		}
 		return m, tea.Sequence(
 			tea.WindowSize(),
 			func() tea.Msg {
 				m.menu.SetState(ui.StateDefault)
 				return nil
 			},
+			cmd,
 		)
 	}
 
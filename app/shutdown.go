@@ -0,0 +1,54 @@
+package app
+
+import (
+	"github.com/smtg-ai/claude-squad/log"
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// shutdownTimeout is how long a shutdown signal waits for the instances to be saved before claude-squad exits anyway
+const shutdownTimeout = 5 * time.Second
+
+// shutdownMsg implements tea.Msg and saves the instances and quits, like quitting with q does
+type shutdownMsg struct{}
+
+// notifyShutdownSignals saves the instances and quits on SIGINT and SIGTERM, e.g. when the system shuts down, instead
+// of Bubble Tea's own handler, which quits without saving. The save runs in Update like any other, so it can't
+// interleave with what the UI is doing. If it doesn't complete within shutdownTimeout, e.g. because the UI is stuck on
+// a hung tmux server, the program is killed without saving. It stops listening when ctx is done.
+func notifyShutdownSignals(ctx context.Context, p *tea.Program) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(sigChan)
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-sigChan:
+			log.InfoLog.Printf("received signal %s, saving and quitting", sig.String())
+			p.Send(shutdownMsg{})
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(shutdownTimeout):
+			log.ErrorLog.Printf("saving took longer than %s, quitting without saving", shutdownTimeout)
+			p.Kill()
+		}
+	}()
+}
+
+// shutdown saves the instances and quits. Unlike saveAndQuit, it quits even if saving fails, since the process is
+// about to be terminated either way.
+func (m *home) shutdown() tea.Cmd {
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		log.ErrorLog.Printf("failed to save instances on shutdown: %v", err)
+	}
+	return tea.Quit
+}
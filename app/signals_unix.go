@@ -0,0 +1,38 @@
+//go:build !windows
+
+package app
+
+import (
+	"github.com/smtg-ai/claude-squad/log"
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// notifyPauseResumeSignals pauses all sessions on SIGUSR1 and resumes them on SIGUSR2, so that scripts such as
+// pre-sleep hooks can drive claude-squad. It stops listening when ctx is done.
+func notifyPauseResumeSignals(ctx context.Context, p *tea.Program) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		defer signal.Stop(sigChan)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-sigChan:
+				log.InfoLog.Printf("received signal %s", sig.String())
+				switch sig {
+				case syscall.SIGUSR1:
+					p.Send(pauseAllMsg{})
+				case syscall.SIGUSR2:
+					p.Send(resumeAllMsg{})
+				}
+			}
+		}
+	}()
+}
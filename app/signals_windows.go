@@ -0,0 +1,12 @@
+//go:build windows
+
+package app
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// notifyPauseResumeSignals is a no-op on Windows, which has no SIGUSR1 or SIGUSR2.
+func notifyPauseResumeSignals(ctx context.Context, p *tea.Program) {}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"github.com/smtg-ai/claude-squad/log"
+	"github.com/smtg-ai/claude-squad/session"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// statusEntry is a session as reported by the /status endpoint. It uses the same serialization as the saved state,
+// plus the status as a readable string.
+type statusEntry struct {
+	session.InstanceData
+	State string `json:"state"`
+}
+
+// statusServer serves the state of the sessions over HTTP for dashboards and scripts. The sessions belong to the UI
+// loop, so the server only ever reads a snapshot that the loop refreshes.
+type statusServer struct {
+	mu        sync.RWMutex
+	snapshot  []statusEntry
+	updatedAt time.Time
+
+	server *http.Server
+}
+
+// startStatusServer starts serving /status and /healthz on addr
+func startStatusServer(addr string) (*statusServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start status server on %s: %w", addr, err)
+	}
+
+	s := &statusServer{snapshot: []statusEntry{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.ErrorLog.Printf("status server stopped: %v", err)
+		}
+	}()
+	log.InfoLog.Printf("status server listening on %s", listener.Addr())
+	return s, nil
+}
+
+// update replaces the snapshot served by /status
+func (s *statusServer) update(instances []*session.Instance) {
+	snapshot := make([]statusEntry, 0, len(instances))
+	for _, instance := range instances {
+		if !instance.Started() {
+			continue
+		}
+		data := instance.ToInstanceData()
+		// The diff can be large and isn't useful for monitoring
+		data.DiffStats.Content = ""
+		snapshot = append(snapshot, statusEntry{InstanceData: data, State: data.Status.String()})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot = snapshot
+	s.updatedAt = time.Now()
+}
+
+func (s *statusServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.RLock()
+	data, err := json.Marshal(struct {
+		UpdatedAt time.Time     `json:"updated_at"`
+		Sessions  []statusEntry `json:"sessions"`
+	}{s.updatedAt, s.snapshot})
+	s.mu.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func (s *statusServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// Close stops the server
+func (s *statusServer) Close() error {
+	return s.server.Close()
+}
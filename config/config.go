@@ -4,19 +4,49 @@ import (
 	"github.com/smtg-ai/claude-squad/log"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"os/user"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	ConfigFileName = "config.json"
+	// DefaultBranchNameTemplate names branches after the session title, behind the branch prefix
+	DefaultBranchNameTemplate = "{prefix}{title}"
 	defaultProgram = "claude"
+	// defaultContinuousModeMaxRuntimeMinutes is 4 hours
+	defaultContinuousModeMaxRuntimeMinutes = 240
+	defaultNudgePrompt = "Please summarize your current progress and continue."
+	defaultStatusHTTPHost = "127.0.0.1"
+	defaultMaxTitleLength = 32
+	defaultErrorHideMs = 3000
+	defaultMetadataIntervalMs = 500
+	defaultPreviewIntervalMs = 100
+	defaultCaptureTimeoutMs = 2000
+	defaultOutputLogMaxSizeKB = 10240
+	defaultListWidthPercent = 30
+	// MinListWidthPercent and MaxListWidthPercent bound the share of the width taken by the list
+	MinListWidthPercent = 15
+	MaxListWidthPercent = 70
+	defaultMaxRestartAttempts = 3
+	// defaultRestartCooldownSeconds is 5 minutes
+	defaultRestartCooldownSeconds = 300
+	// minMetadataIntervalMs and minPreviewIntervalMs keep short intervals from turning the updates into busy loops
+	minMetadataIntervalMs = 100
+	minPreviewIntervalMs = 20
+	minCaptureTimeoutMs = 100
 )
 
+// defaultProtectedBranches are the branches that are never deleted when protected_branches isn't set
+var defaultProtectedBranches = []string{"main", "master"}
+
 // GetConfigDir returns the path to the application's configuration directory
 func GetConfigDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -26,28 +56,165 @@ func GetConfigDir() (string, error) {
 	return filepath.Join(homeDir, ".claude-squad"), nil
 }
 
+// TemplateSpec describes a reusable set of options for creating new instances
+type TemplateSpec struct {
+	// Program is the program to run in the instance. Uses the default program if empty.
+	Program string `json:"program,omitempty"`
+	// Path is the path to the workspace, or the URL of a remote repository to clone. Uses the current directory if
+	// empty.
+	Path string `json:"path,omitempty"`
+	// Prompt is the initial prompt to send to the instance after it starts
+	Prompt string `json:"prompt,omitempty"`
+	// Remote is the git remote the branches of instances created from the template are pushed to, e.g. a personal
+	// fork. Uses origin if empty.
+	Remote string `json:"remote,omitempty"`
+	// StartupPrompt replaces startup_prompt from the config for instances created from the template
+	StartupPrompt string `json:"startup_prompt,omitempty"`
+	// AutoYes automatically accepts prompts in the instance
+	AutoYes bool `json:"auto_yes,omitempty"`
+	// WatchdogEnabled overrides the global watchdog setting if set
+	WatchdogEnabled *bool `json:"watchdog_enabled,omitempty"`
+}
+
 // Config represents the application configuration
 type Config struct {
 	// DefaultProgram is the default program to run in new instances
 	DefaultProgram string `json:"default_program"`
 	// AutoYes is a flag to automatically accept all prompts.
 	AutoYes bool `json:"auto_yes"`
+	// SafeMode starts with safe mode on: no AutoYes, watchdog, continuous mode, crash restarts or queued prompts
+	// until it's turned off in the UI.
+	SafeMode bool `json:"safe_mode,omitempty"`
 	// DaemonPollInterval is the interval (ms) at which the daemon polls sessions for autoyes mode.
 	DaemonPollInterval int `json:"daemon_poll_interval"`
 	// BranchPrefix is the prefix used for git branches created by the application.
 	BranchPrefix string `json:"branch_prefix"`
+	// BranchNameTemplate is how branch names are derived from session titles. {prefix} is replaced by BranchPrefix
+	// and {title} by the sanitized title, e.g. "feature/{title}". The tmux session name always uses the title.
+	// Defaults to "{prefix}{title}".
+	BranchNameTemplate string `json:"branch_name_template,omitempty"`
+	// MaxTitleLength is the maximum number of characters in a session title. Branch and tmux session names derived
+	// from long titles are truncated.
+	MaxTitleLength int `json:"max_title_length,omitempty"`
+	// PromptAfterCreate makes n ask for a prompt right after naming a new session, and N create one without a prompt,
+	// the other way around from the default.
+	PromptAfterCreate bool `json:"prompt_after_create,omitempty"`
+	// DeadSessionAction is what attaching to a session whose tmux session died does: "restart" starts its program
+	// again, "pause" pauses it, and empty asks which one.
+	DeadSessionAction string `json:"dead_session_action,omitempty"`
+	// ConfirmQuit asks for confirmation before quitting while sessions are running.
+	ConfirmQuit bool `json:"confirm_quit"`
+	// AutoInitRepo runs git init and creates an initial commit when claude-squad is started outside a git repository.
+	AutoInitRepo bool `json:"auto_init_repo,omitempty"`
+	// ClipboardEnabled allows copying to the system clipboard, e.g. the branch name on checkout. Disable it on
+	// machines without a clipboard; the text is shown in the UI instead.
+	ClipboardEnabled bool `json:"clipboard_enabled"`
+	// InterruptKey is the key sent to the program to interrupt it: "ctrl+c" or "esc"
+	InterruptKey string `json:"interrupt_key,omitempty"`
+	// CompactState leaves the diff content out of the saved state to keep the state file small. Only the line counts
+	// are saved; the diff itself is recomputed after loading. Paused instances keep their diff, since they have no
+	// worktree to recompute it from.
+	CompactState bool `json:"compact_state"`
+	// WorktreeBaseDir is the directory new worktrees are created in. Defaults to the worktrees directory inside
+	// the config directory if empty. A leading "~/" is expanded to the home directory.
+	WorktreeBaseDir string `json:"worktree_base_dir,omitempty"`
+	// OpaqueWorktreeNames names new worktree directories with random hex instead of the session title, so that titles
+	// don't show up on disk. Branches are still named after the title.
+	OpaqueWorktreeNames bool `json:"opaque_worktree_names,omitempty"`
+	// CloneDir is the directory remote repositories are cloned into when a session is created from a URL, e.g. a
+	// template with a git URL as its path. Defaults to the repos directory inside the config directory. A leading
+	// "~/" is expanded to the home directory.
+	CloneDir string `json:"clone_dir,omitempty"`
+	// StartupPrompt is sent to every new session once its program is ready for input, e.g. "Read CLAUDE.md before
+	// starting". It's sent before any other prompt, and not again when the session is resumed or restarted.
+	StartupPrompt string `json:"startup_prompt,omitempty"`
+	// NudgePrompt is the prompt sent to the selected instance by the nudge key
+	NudgePrompt string `json:"nudge_prompt"`
+	// StatusHTTPPort enables a local HTTP server with /status and /healthz endpoints on this port. 0 disables it.
+	StatusHTTPPort int `json:"status_http_port,omitempty"`
+	// StatusHTTPHost is the address the status server listens on. Defaults to 127.0.0.1 so that it's only reachable
+	// from this machine.
+	StatusHTTPHost string `json:"status_http_host,omitempty"`
+	// MetadataIntervalMs is how often (ms) the status, diff and watchdog of the sessions are updated. Raise it on slow
+	// disks, since every update runs git diff for each session. Defaults to 500, at least 100.
+	MetadataIntervalMs int `json:"metadata_interval_ms,omitempty"`
+	// PreviewIntervalMs is how often (ms) the preview of the selected session is refreshed. Defaults to 100, at
+	// least 20.
+	PreviewIntervalMs int `json:"preview_interval_ms,omitempty"`
+	// CaptureTimeoutMs is how long (ms) capturing the preview or checking the statuses of the sessions may take
+	// before tmux is killed, so that a hung tmux server doesn't stop the updates. The preview then shows that it's
+	// capturing, and the next update tries again. Defaults to 2000, at least 100.
+	CaptureTimeoutMs int `json:"capture_timeout_ms,omitempty"`
+	// ListWidthPercent is the share of the width taken by the list, the preview gets the rest. Defaults to 30, and is
+	// kept between 15 and 70. Resizing the split in the UI overrides it.
+	ListWidthPercent int `json:"list_width_percent,omitempty"`
+	// ErrorHideMs is how long (ms) errors stay in the error box before they're hidden. Defaults to 3 seconds.
+	ErrorHideMs int `json:"error_hide_ms,omitempty"`
+	// EventLogPath is a file or FIFO that session events (status changes, stalls, restarts and kills) are written to
+	// as newline-delimited JSON, for notifications and other integrations. Empty disables it.
+	EventLogPath string `json:"event_log_path,omitempty"`
+	// SaveTranscriptOnClose saves the full scrollback of a session to the transcripts directory inside the config
+	// directory before the session is paused or killed.
+	SaveTranscriptOnClose bool `json:"save_transcript_on_close,omitempty"`
+	// OutputLogDir is where the output of sessions with output logging turned on is written, one file per session.
+	// Defaults to the output-logs directory inside the config directory.
+	OutputLogDir string `json:"output_log_dir,omitempty"`
+	// OutputLogMaxSizeKB is how large an output log grows before it's rotated. The previous log is kept with a .1
+	// suffix. Defaults to 10 MB.
+	OutputLogMaxSizeKB int `json:"output_log_max_size_kb,omitempty"`
+	// CleanupZombieSessionsOnStart kills claude-squad tmux sessions that don't belong to any saved session when
+	// claude-squad starts. Leave it off when running several claude-squad processes at once: a session another process
+	// is still starting isn't saved yet.
+	CleanupZombieSessionsOnStart bool `json:"cleanup_zombie_sessions_on_start,omitempty"`
+	// ProgramWrapper is put in front of the program when starting and restarting sessions, e.g. "firejail --private=."
+	// to run agents in a sandbox. The program runs in the worktree; {worktree} is replaced by its path.
+	ProgramWrapper string `json:"program_wrapper,omitempty"`
+	// DisableLocalStats stops recording when sessions are created, paused and killed in stats.jsonl inside the config
+	// directory. The stats never leave this machine; "claude-squad stats" summarizes them.
+	DisableLocalStats bool `json:"disable_local_stats,omitempty"`
+	// SkipProgramCheck turns off checking that the program is on PATH before creating a session. Turn it on if the
+	// program is a shell alias or builtin.
+	SkipProgramCheck bool `json:"skip_program_check,omitempty"`
+	// ReadyPatterns are regular expressions that mark a session ready whenever its pane matches, e.g. the idle prompt
+	// of the program. They're keyed by the program as configured, e.g. "aider --model sonnet", or by the name of its
+	// command, e.g. "aider".
+	ReadyPatterns map[string]string `json:"ready_patterns,omitempty"`
+	// MergeBaseBranch is the branch sessions are merged into by the merge key. It has to be checked out in the main
+	// repository. Empty merges into whatever branch is checked out there.
+	MergeBaseBranch string `json:"merge_base_branch,omitempty"`
+	// MergeMode is how sessions are merged: "ff-only" only fast-forwards, "no-ff" always creates a merge commit, and
+	// empty fast-forwards if possible and creates a merge commit otherwise.
+	MergeMode string `json:"merge_mode,omitempty"`
+	// ProtectedBranches are branch names or glob patterns (e.g. "release/*") that are never deleted, even when
+	// killing a session together with its branch. Defaults to main and master.
+	ProtectedBranches []string `json:"protected_branches,omitempty"`
+	// AutoCommitIntervalMinutes commits the changes in the worktrees of running sessions at this interval, so that
+	// the work of a session that crashes isn't lost. The commits aren't pushed. 0 turns it off.
+	AutoCommitIntervalMinutes int `json:"auto_commit_interval_minutes,omitempty"`
 	
 	// Watchdog configuration
 	// WatchdogEnabled determines if watchdog monitoring is enabled by default for new instances
 	WatchdogEnabled bool `json:"watchdog_enabled"`
 	// StallTimeoutSeconds is how long to wait before considering a session stalled (in seconds)
 	StallTimeoutSeconds int `json:"stall_timeout_seconds"`
-	// MaxContinueAttempts is the maximum number of times to attempt recovery before giving up
+	// MaxContinueAttempts is the maximum number of times to attempt recovery before giving up. The count only starts
+	// over when the user gives the session input, e.g. a prompt.
 	MaxContinueAttempts int `json:"max_continue_attempts"`
+	// MaxRestartAttempts is how many times a crashed Claude Code session is restarted before giving up until the
+	// restart cooldown has passed. Manual restarts count too. Defaults to 3.
+	MaxRestartAttempts int `json:"max_restart_attempts,omitempty"`
+	// RestartCooldownSeconds is how long restarts wait after MaxRestartAttempts restarts. Defaults to 5 minutes.
+	RestartCooldownSeconds int `json:"restart_cooldown_seconds,omitempty"`
 	// ContinueCommands is the list of commands to try when attempting to unstall a session
 	ContinueCommands []string `json:"continue_commands"`
 	// ContinuousModeTimeoutSeconds is the more aggressive timeout for continuous mode (in seconds)
 	ContinuousModeTimeoutSeconds int `json:"continuous_mode_timeout_seconds"`
+	// ContinuousModeMaxRuntimeMinutes is a hard cap on how long continuous mode runs, even if it was enabled
+	// indefinitely. 0 uses the default of 4 hours.
+	ContinuousModeMaxRuntimeMinutes int `json:"continuous_mode_max_runtime_minutes"`
+
+	// Templates are named presets for creating new instances
+	Templates map[string]TemplateSpec `json:"templates,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
@@ -62,23 +229,219 @@ func DefaultConfig() *Config {
 		DefaultProgram:     program,
 		AutoYes:            false,
 		DaemonPollInterval: 1000,
-		BranchPrefix: func() string {
-			user, err := user.Current()
-			if err != nil || user == nil || user.Username == "" {
-				log.ErrorLog.Printf("failed to get current user: %v", err)
-				return "session/"
-			}
-			return fmt.Sprintf("%s/", strings.ToLower(user.Username))
-		}(),
+		BranchPrefix:       defaultBranchPrefix(),
+		BranchNameTemplate: DefaultBranchNameTemplate,
+		MaxTitleLength:     defaultMaxTitleLength,
+		ConfirmQuit:        true,
+		ClipboardEnabled:   true,
+		CompactState:       true,
+		NudgePrompt:        defaultNudgePrompt,
 		// Watchdog defaults
 		WatchdogEnabled:               true,
 		StallTimeoutSeconds:           300, // 5 minutes
 		MaxContinueAttempts:           3,
+		MaxRestartAttempts:            defaultMaxRestartAttempts,
+		RestartCooldownSeconds:        defaultRestartCooldownSeconds,
 		ContinueCommands:              []string{"continue", "yes", "y", "proceed", "\n"},
 		ContinuousModeTimeoutSeconds:  8, // 8 seconds for continuous mode
+		ContinuousModeMaxRuntimeMinutes: defaultContinuousModeMaxRuntimeMinutes,
 	}
 }
 
+// fallbackBranchPrefix is the branch prefix if the user name can't be used for it
+const fallbackBranchPrefix = "session/"
+
+// defaultBranchPrefix returns the name of the current user as a namespace for branches, e.g. "alice/"
+func defaultBranchPrefix() string {
+	user, err := user.Current()
+	if err != nil || user == nil || user.Username == "" {
+		log.ErrorLog.Printf("failed to get current user: %v", err)
+		return fallbackBranchPrefix
+	}
+	// User names can have characters that branch names can't, e.g. the backslash in "DOMAIN\user"
+	prefix, err := NormalizeBranchPrefix(strings.ToLower(user.Username))
+	if err != nil {
+		log.WarningLog.Printf("user name %q can't be used as a branch prefix, using %q: %v", user.Username,
+			fallbackBranchPrefix, err)
+		return fallbackBranchPrefix
+	}
+	return prefix
+}
+
+// invalidRefChars matches the characters git doesn't allow in branch names: control characters, space, ~, ^, :, ?, *,
+// [ and \
+var invalidRefChars = regexp.MustCompile(`[\x00-\x20\x7f~^:?*\[\\]`)
+
+// NormalizeBranchPrefix checks that prefix can start a branch name and returns it as it should be used. A prefix
+// that ends in a letter or digit, like "feat", is taken as a namespace and gets a trailing slash, "feat/". Prefixes
+// that end in a separator, like "feat/" or "feat-", are kept as they are. An empty prefix is allowed.
+func NormalizeBranchPrefix(prefix string) (string, error) {
+	if prefix == "" {
+		return "", nil
+	}
+	if loc := invalidRefChars.FindStringIndex(prefix); loc != nil {
+		return "", fmt.Errorf("branch prefix %q contains %q, which git doesn't allow in branch names", prefix,
+			prefix[loc[0]:loc[1]])
+	}
+	switch {
+	case strings.HasPrefix(prefix, "-") || strings.HasPrefix(prefix, "/"):
+		return "", fmt.Errorf("branch prefix %q can't start with %q", prefix, prefix[:1])
+	case strings.Contains(prefix, "..") || strings.Contains(prefix, "@{") || strings.Contains(prefix, "//"):
+		return "", fmt.Errorf("branch prefix %q can't contain \"..\", \"@{\" or \"//\"", prefix)
+	}
+	last := prefix[len(prefix)-1]
+	if ('a' <= last && last <= 'z') || ('A' <= last && last <= 'Z') || ('0' <= last && last <= '9') {
+		prefix += "/"
+	}
+	for _, part := range strings.Split(prefix, "/") {
+		if strings.HasPrefix(part, ".") || strings.HasSuffix(part, ".lock") {
+			return "", fmt.Errorf("branch prefix %q has a part that starts with \".\" or ends with \".lock\"", prefix)
+		}
+	}
+	return prefix, nil
+}
+
+// GetContinuousModeMaxRuntime returns the hard cap on how long continuous mode runs
+func (c *Config) GetContinuousModeMaxRuntime() time.Duration {
+	minutes := c.ContinuousModeMaxRuntimeMinutes
+	if minutes <= 0 {
+		// Older configs don't have this field. Never treat that as "no cap".
+		minutes = defaultContinuousModeMaxRuntimeMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// GetDefaultProgram returns the program to run in new instances. An empty default_program, e.g. after a bad edit of
+// the config file, would start a bare shell that looks ready forever, so it falls back to claude.
+func (c *Config) GetDefaultProgram() string {
+	if strings.TrimSpace(c.DefaultProgram) == "" {
+		log.WarningLog.Printf("default_program is empty in the config file, using %s", defaultProgram)
+		return defaultProgram
+	}
+	return c.DefaultProgram
+}
+
+// GetMaxTitleLength returns the maximum number of characters in a session title
+func (c *Config) GetMaxTitleLength() int {
+	if c.MaxTitleLength <= 0 {
+		return defaultMaxTitleLength
+	}
+	return c.MaxTitleLength
+}
+
+// GetMaxRestartAttempts returns how many times a session is restarted before giving up
+func (c *Config) GetMaxRestartAttempts() int {
+	if c.MaxRestartAttempts <= 0 {
+		return defaultMaxRestartAttempts
+	}
+	return c.MaxRestartAttempts
+}
+
+// GetRestartCooldown returns how long restarts wait after the maximum number of restarts
+func (c *Config) GetRestartCooldown() time.Duration {
+	if c.RestartCooldownSeconds <= 0 {
+		return defaultRestartCooldownSeconds * time.Second
+	}
+	return time.Duration(c.RestartCooldownSeconds) * time.Second
+}
+
+// GetMetadataInterval returns how often the metadata of the sessions is updated
+func (c *Config) GetMetadataInterval() time.Duration {
+	return intervalMs(c.MetadataIntervalMs, defaultMetadataIntervalMs, minMetadataIntervalMs)
+}
+
+// GetPreviewInterval returns how often the preview of the selected session is refreshed
+func (c *Config) GetPreviewInterval() time.Duration {
+	return intervalMs(c.PreviewIntervalMs, defaultPreviewIntervalMs, minPreviewIntervalMs)
+}
+
+// GetCaptureTimeout returns how long capturing the preview may take before it's shown as capturing
+func (c *Config) GetCaptureTimeout() time.Duration {
+	return intervalMs(c.CaptureTimeoutMs, defaultCaptureTimeoutMs, minCaptureTimeoutMs)
+}
+
+// intervalMs turns an interval in ms from the config into a duration. 0 uses the default, and anything shorter
+// than the minimum is raised to it.
+func intervalMs(ms int, defaultMs int, minMs int) time.Duration {
+	if ms <= 0 {
+		ms = defaultMs
+	} else if ms < minMs {
+		ms = minMs
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// GetListWidthPercent returns the share of the width taken by the list
+func (c *Config) GetListWidthPercent() int {
+	if c.ListWidthPercent <= 0 {
+		return defaultListWidthPercent
+	}
+	return ClampListWidthPercent(c.ListWidthPercent)
+}
+
+// ClampListWidthPercent keeps percent between MinListWidthPercent and MaxListWidthPercent
+func ClampListWidthPercent(percent int) int {
+	return min(max(percent, MinListWidthPercent), MaxListWidthPercent)
+}
+
+// GetErrorHideDuration returns how long errors stay in the error box
+func (c *Config) GetErrorHideDuration() time.Duration {
+	if c.ErrorHideMs <= 0 {
+		return defaultErrorHideMs * time.Millisecond
+	}
+	return time.Duration(c.ErrorHideMs) * time.Millisecond
+}
+
+// GetOutputLogMaxSize returns the size in bytes at which output logs are rotated
+func (c *Config) GetOutputLogMaxSize() int64 {
+	if c.OutputLogMaxSizeKB <= 0 {
+		return defaultOutputLogMaxSizeKB * 1024
+	}
+	return int64(c.OutputLogMaxSizeKB) * 1024
+}
+
+// GetAutoCommitInterval returns how often the changes of running sessions are committed, 0 if they aren't
+func (c *Config) GetAutoCommitInterval() time.Duration {
+	if c.AutoCommitIntervalMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(c.AutoCommitIntervalMinutes) * time.Minute
+}
+
+// GetNudgePrompt returns the prompt sent by the nudge key
+func (c *Config) GetNudgePrompt() string {
+	if strings.TrimSpace(c.NudgePrompt) == "" {
+		return defaultNudgePrompt
+	}
+	return c.NudgePrompt
+}
+
+// GetStatusHTTPAddr returns the address the status server listens on, or "" if it's disabled
+func (c *Config) GetStatusHTTPAddr() string {
+	if c.StatusHTTPPort <= 0 {
+		return ""
+	}
+	host := c.StatusHTTPHost
+	if host == "" {
+		host = defaultStatusHTTPHost
+	}
+	return net.JoinHostPort(host, strconv.Itoa(c.StatusHTTPPort))
+}
+
+// IsProtectedBranch returns true if branch matches one of the protected branch patterns
+func (c *Config) IsProtectedBranch(branch string) bool {
+	patterns := c.ProtectedBranches
+	if patterns == nil {
+		patterns = defaultProtectedBranches
+	}
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, branch); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
 // GetClaudeCommand attempts to find the "claude" command in the user's shell
 // It checks in the following order:
 // 1. Shell alias resolution: using "which" command
@@ -150,12 +513,21 @@ func LoadConfig() *Config {
 		return DefaultConfig()
 	}
 
-	var config Config
+	// Settings that are on by default stay on for config files written before they existed. Only these are filled in:
+	// DefaultConfig looks up the claude command, which is too slow for every load.
+	config := Config{ConfirmQuit: true, ClipboardEnabled: true, CompactState: true}
 	if err := json.Unmarshal(data, &config); err != nil {
 		log.ErrorLog.Printf("failed to parse config file: %v", err)
 		return DefaultConfig()
 	}
 
+	prefix, err := NormalizeBranchPrefix(config.BranchPrefix)
+	if err != nil {
+		prefix = defaultBranchPrefix()
+		log.WarningLog.Printf("ignoring branch_prefix in the config file, using %q: %v", prefix, err)
+	}
+	config.BranchPrefix = prefix
+
 	return &config
 }
 
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"github.com/smtg-ai/claude-squad/log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	log.Initialize(false)
+	defer log.Close()
+	os.Exit(m.Run())
+}
+
+func TestNormalizeBranchPrefix(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{prefix: "feat", want: "feat/"},
+		{prefix: "feat/", want: "feat/"},
+		{prefix: "team/alice", want: "team/alice/"},
+		{prefix: "feat-", want: "feat-"},
+		{prefix: "", want: ""},
+		{prefix: "bad:prefix", wantErr: true},
+		{prefix: "bad prefix", wantErr: true},
+		{prefix: `domain\user`, wantErr: true},
+		{prefix: "feat..", wantErr: true},
+		{prefix: "/feat", wantErr: true},
+		{prefix: "-feat", wantErr: true},
+		{prefix: "feat//", wantErr: true},
+		{prefix: ".feat/", wantErr: true},
+		{prefix: "feat.lock/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got, err := NormalizeBranchPrefix(tt.prefix)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadConfigKeepsDefaultsOfMissingSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".claude-squad")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	configPath := filepath.Join(configDir, ConfigFileName)
+
+	// A config file from before these settings existed
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"default_program": "claude"}`), 0644))
+	cfg := LoadConfig()
+	assert.True(t, cfg.ConfirmQuit)
+	assert.True(t, cfg.ClipboardEnabled)
+	assert.True(t, cfg.CompactState)
+
+	data := `{"confirm_quit": false, "clipboard_enabled": false, "compact_state": false}`
+	require.NoError(t, os.WriteFile(configPath, []byte(data), 0644))
+	cfg = LoadConfig()
+	assert.False(t, cfg.ConfirmQuit)
+	assert.False(t, cfg.ClipboardEnabled)
+	assert.False(t, cfg.CompactState)
+}
+
+func TestLoadConfigValidatesBranchPrefix(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".claude-squad")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	write := func(prefix string) {
+		t.Helper()
+		data := `{"branch_prefix": "` + prefix + `"}`
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, ConfigFileName), []byte(data), 0644))
+	}
+
+	write("feat")
+	assert.Equal(t, "feat/", LoadConfig().BranchPrefix)
+
+	write("feat/")
+	assert.Equal(t, "feat/", LoadConfig().BranchPrefix)
+
+	// An invalid prefix falls back to the default
+	write("bad:prefix")
+	assert.Equal(t, defaultBranchPrefix(), LoadConfig().BranchPrefix)
+}
+
+func TestIntervalMs(t *testing.T) {
+	tests := []struct {
+		name string
+		ms   int
+		want time.Duration
+	}{
+		{name: "zero uses the default", ms: 0, want: 500 * time.Millisecond},
+		{name: "negative uses the default", ms: -5, want: 500 * time.Millisecond},
+		{name: "below the minimum is raised to it", ms: 10, want: 100 * time.Millisecond},
+		{name: "the minimum is kept", ms: 100, want: 100 * time.Millisecond},
+		{name: "above the minimum is kept", ms: 1500, want: 1500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, intervalMs(tt.ms, 500, 100))
+		})
+	}
+}
+
+func TestIntervalGetters(t *testing.T) {
+	var cfg Config
+	assert.Equal(t, defaultMetadataIntervalMs*time.Millisecond, cfg.GetMetadataInterval())
+	assert.Equal(t, defaultPreviewIntervalMs*time.Millisecond, cfg.GetPreviewInterval())
+	assert.Equal(t, defaultCaptureTimeoutMs*time.Millisecond, cfg.GetCaptureTimeout())
+
+	cfg = Config{MetadataIntervalMs: -1, PreviewIntervalMs: -1, CaptureTimeoutMs: -1}
+	assert.Equal(t, defaultMetadataIntervalMs*time.Millisecond, cfg.GetMetadataInterval())
+	assert.Equal(t, defaultPreviewIntervalMs*time.Millisecond, cfg.GetPreviewInterval())
+	assert.Equal(t, defaultCaptureTimeoutMs*time.Millisecond, cfg.GetCaptureTimeout())
+
+	cfg = Config{MetadataIntervalMs: 1, PreviewIntervalMs: 1, CaptureTimeoutMs: 1}
+	assert.Equal(t, minMetadataIntervalMs*time.Millisecond, cfg.GetMetadataInterval())
+	assert.Equal(t, minPreviewIntervalMs*time.Millisecond, cfg.GetPreviewInterval())
+	assert.Equal(t, minCaptureTimeoutMs*time.Millisecond, cfg.GetCaptureTimeout())
+
+	cfg = Config{MetadataIntervalMs: 1000, PreviewIntervalMs: 250, CaptureTimeoutMs: 5000}
+	assert.Equal(t, time.Second, cfg.GetMetadataInterval())
+	assert.Equal(t, 250*time.Millisecond, cfg.GetPreviewInterval())
+	assert.Equal(t, 5*time.Second, cfg.GetCaptureTimeout())
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 const (
@@ -29,6 +30,14 @@ type AppState interface {
 	GetHelpScreensSeen() uint32
 	// SetHelpScreensSeen updates the bitmask of seen help screens
 	SetHelpScreensSeen(seen uint32) error
+	// GetFocusMode returns true if the list was collapsed to the selected session
+	GetFocusMode() bool
+	// SetFocusMode updates whether the list is collapsed to the selected session
+	SetFocusMode(focus bool) error
+	// GetListWidthPercent returns the share of the width taken by the list, 0 if it was never resized
+	GetListWidthPercent() int
+	// SetListWidthPercent updates the share of the width taken by the list
+	SetListWidthPercent(percent int) error
 }
 
 // StateManager combines instance storage and app state management
@@ -39,8 +48,14 @@ type StateManager interface {
 
 // State represents the application state that persists between sessions
 type State struct {
+	// mu guards the fields below and serializes writes to the state file
+	mu sync.Mutex
 	// HelpScreensSeen is a bitmask tracking which help screens have been shown
 	HelpScreensSeen uint32 `json:"help_screens_seen"`
+	// FocusMode is true if the list is collapsed to the selected session
+	FocusMode bool `json:"focus_mode,omitempty"`
+	// ListWidthPercent is the share of the width taken by the list. 0 uses the config.
+	ListWidthPercent int `json:"list_width_percent,omitempty"`
 	// Instances stores the serialized instance data as raw JSON
 	InstancesData json.RawMessage `json:"instances"`
 }
@@ -88,6 +103,13 @@ func LoadState() *State {
 
 // SaveState saves the state to disk
 func SaveState(state *State) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return saveState(state)
+}
+
+// saveState saves the state to disk. The caller must hold state.mu.
+func saveState(state *State) error {
 	configDir, err := GetConfigDir()
 	if err != nil {
 		return fmt.Errorf("failed to get config directory: %w", err)
@@ -103,37 +125,108 @@ func SaveState(state *State) error {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
-	return os.WriteFile(statePath, data, 0644)
+	return writeFileAtomic(statePath, data, 0644)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place, so that path is never left
+// half-written if the process is killed mid-write.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	// Clean up the temporary file if anything goes wrong. After the rename, this is a no-op.
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set permissions on temporary file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
 }
 
 // InstanceStorage interface implementation
 
 // SaveInstances saves the raw instance data
 func (s *State) SaveInstances(instancesJSON json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.InstancesData = instancesJSON
-	return SaveState(s)
+	return saveState(s)
 }
 
 // GetInstances returns the raw instance data
 func (s *State) GetInstances() json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.InstancesData
 }
 
 // DeleteAllInstances removes all stored instances
 func (s *State) DeleteAllInstances() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.InstancesData = json.RawMessage("[]")
-	return SaveState(s)
+	return saveState(s)
 }
 
 // AppState interface implementation
 
 // GetHelpScreensSeen returns the bitmask of seen help screens
 func (s *State) GetHelpScreensSeen() uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.HelpScreensSeen
 }
 
 // SetHelpScreensSeen updates the bitmask of seen help screens
 func (s *State) SetHelpScreensSeen(seen uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.HelpScreensSeen = seen
-	return SaveState(s)
+	return saveState(s)
+}
+
+// GetFocusMode returns true if the list was collapsed to the selected session
+func (s *State) GetFocusMode() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.FocusMode
+}
+
+// SetFocusMode updates whether the list is collapsed to the selected session
+func (s *State) SetFocusMode(focus bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.FocusMode = focus
+	return saveState(s)
+}
+
+// GetListWidthPercent returns the share of the width taken by the list, 0 if it was never resized
+func (s *State) GetListWidthPercent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ListWidthPercent
+}
+
+// SetListWidthPercent updates the share of the width taken by the list
+func (s *State) SetListWidthPercent(percent int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ListWidthPercent = percent
+	return saveState(s)
 }
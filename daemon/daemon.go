@@ -18,11 +18,14 @@ import (
 // It's expected that the main process kills the daemon when the main process starts.
 func RunDaemon(cfg *config.Config) error {
 	log.InfoLog.Printf("starting daemon")
+	session.SetConfig(cfg)
+	session.SetSafeMode(cfg.SafeMode)
 	state := config.LoadState()
 	storage, err := session.NewStorage(state)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
+	storage.SetCompact(cfg.CompactState)
 
 	instances, err := storage.LoadInstances()
 	if err != nil {
@@ -48,7 +51,8 @@ func RunDaemon(cfg *config.Config) error {
 			for _, instance := range instances {
 				// We only store started instances, but check anyway.
 				if instance.Started() && !instance.Paused() {
-					if _, hasPrompt := instance.HasUpdated(); hasPrompt {
+					updated, hasPrompt := instance.HasUpdated()
+					if hasPrompt {
 						instance.TapEnter()
 						if err := instance.UpdateDiffStats(); err != nil {
 							if everyN.ShouldLog() {
@@ -56,6 +60,15 @@ func RunDaemon(cfg *config.Config) error {
 							}
 						}
 					}
+					if updated || hasPrompt {
+						instance.SetStatus(session.Running)
+					} else if instance.Status != session.Ready {
+						// Like the TUI, send the next queued prompt once the instance finishes its current task
+						instance.SetStatus(session.Ready)
+						if _, err := instance.SendQueuedPrompt(); err != nil && everyN.ShouldLog() {
+							log.WarningLog.Printf("could not send queued prompt to %s: %v", instance.Title, err)
+						}
+					}
 				}
 			}
 
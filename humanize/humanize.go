@@ -0,0 +1,37 @@
+package humanize
+
+import (
+	"fmt"
+	"time"
+)
+
+// RelativeTime describes how long ago t was, e.g. "just now", "2m ago", "3h ago" or "5d ago". Times more than a week
+// ago are shown as a date. Returns "never" for the zero time.
+func RelativeTime(t time.Time) string {
+	return relativeTime(t, time.Now())
+}
+
+func relativeTime(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	d := now.Sub(t)
+	switch {
+	case d < 10*time.Second:
+		// Includes times slightly in the future, e.g. from clock adjustments
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 7*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case t.Year() == now.Year():
+		return t.Format("Jan 2")
+	default:
+		return t.Format("Jan 2, 2006")
+	}
+}
@@ -0,0 +1,31 @@
+package humanize
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		t        time.Time
+		expected string
+	}{
+		{time.Time{}, "never"},
+		{now, "just now"},
+		{now.Add(5 * time.Second), "just now"},
+		{now.Add(-9 * time.Second), "just now"},
+		{now.Add(-42 * time.Second), "42s ago"},
+		{now.Add(-2*time.Minute - 30*time.Second), "2m ago"},
+		{now.Add(-3*time.Hour - 59*time.Minute), "3h ago"},
+		{now.Add(-50 * time.Hour), "2d ago"},
+		{now.Add(-30 * 24 * time.Hour), "May 16"},
+		{now.AddDate(-1, 0, 0), "Jun 15, 2024"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, relativeTime(tt.t, now), tt.t.String())
+	}
+}
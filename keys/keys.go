@@ -19,6 +19,7 @@ const (
 
 	KeyTab        // Tab is a special keybinding for switching between panes.
 	KeySubmitName // SubmitName is a special keybinding for submitting the name of a new instance.
+	KeyToggleAutoYes // ToggleAutoYes is a special keybinding for toggling AutoYes while naming a new instance.
 
 	KeyCheckout
 	KeyResume
@@ -27,6 +28,38 @@ const (
 	KeyHelp   // Key for showing help screen
 	KeyContinuousMode // Key for toggling continuous mode
 	KeyRestart // Key for restarting Claude Code with session restore
+	KeyImportBranch // Key for creating a session from an existing branch
+	KeyTemplate // Key for creating a session from a template
+	KeyRefreshDiff // Key for refreshing the diff of the selected session
+	KeyInterrupt // Key for interrupting the program running in the selected session
+	KeyTag // Key for cycling the color tag of the selected session
+	KeyAttachSplit // Key for attaching to the selected session in a tmux split pane
+	KeyNextReady // Key for jumping to the next session waiting for input
+	KeyNextRunning // Key for jumping to the next running session
+	KeyNudge // Key for sending the nudge prompt to the selected session
+	KeyQueuePrompt // Key for queueing a prompt for the selected session
+	KeyKillBranch // Key for killing the selected session and deleting its branch
+	KeySearch // Key for searching the preview content
+	KeyResumeAttach // Key for resuming the selected session and attaching to it
+	KeyResumeAll // Key for resuming all paused sessions
+	KeyFocus // Key for toggling focus mode, which hides all but the selected session
+	KeyErrors // Key for showing the recent errors
+	KeyClearError // Key for clearing the error box
+	KeyTranscripts // Key for browsing the saved session transcripts
+	KeyAttention // Key for toggling the view of sessions that need attention
+	KeyCleanupSessions // Key for killing leftover tmux sessions that don't belong to any session
+	KeyGrowList // Key for making the list wider and the preview narrower
+	KeyShrinkList // Key for making the list narrower and the preview wider
+	KeyCopyPreview // Key for copying the preview of the selected session to the clipboard
+	KeyMerge // Key for merging the branch of the selected session into the base branch
+	KeySendKeys // Key for sending raw tmux keys to the selected session
+	KeyPing // Key for checking that the program of the selected session is responsive
+	KeySafeMode // Key for turning all automatic actions on sessions off and on
+	KeyRemote // Key for setting the git remote the selected session is pushed to
+	KeyRestartStalled // Key for restarting all stalled or crashed sessions
+	KeyDestructiveLog // Key for showing the recent destructive git operations
+	KeyTailOutput // Key for toggling logging the output of the selected session to a file
+	KeyDebugInstance // Key for showing the stored data of the selected session, for debugging. Not in the help.
 
 	// Diff keybindings
 	KeyShiftUp
@@ -59,6 +92,38 @@ var GlobalKeyStringsMap = map[string]KeyName{
 	"?":          KeyHelp,
 	"ctrl+g":     KeyContinuousMode,
 	"ctrl+r":     KeyRestart,
+	"b":          KeyImportBranch,
+	"t":          KeyTemplate,
+	"f":          KeyRefreshDiff,
+	"i":          KeyInterrupt,
+	"L":          KeyTag,
+	"v":          KeyAttachSplit,
+	"w":          KeyNextReady,
+	"W":          KeyNextRunning,
+	"u":          KeyNudge,
+	"a":          KeyQueuePrompt,
+	"X":          KeyKillBranch,
+	"/":          KeySearch,
+	"R":          KeyResumeAttach,
+	"alt+r":      KeyResumeAll,
+	"F":          KeyFocus,
+	"e":          KeyErrors,
+	"ctrl+l":     KeyClearError,
+	"H":          KeyTranscripts,
+	"A":          KeyAttention,
+	"Z":          KeyCleanupSessions,
+	">":          KeyGrowList,
+	"<":          KeyShrinkList,
+	"y":          KeyCopyPreview,
+	"M":          KeyMerge,
+	"K":          KeySendKeys,
+	"P":          KeyPing,
+	"S":          KeySafeMode,
+	"G":          KeyRemote,
+	"alt+ctrl+r": KeyRestartStalled,
+	"U":          KeyDestructiveLog,
+	"T":          KeyTailOutput,
+	"alt+d":      KeyDebugInstance,
 }
 
 // GlobalkeyBindings is a global, immutable map of KeyName tot keybinding.
@@ -127,6 +192,134 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("ctrl+r"),
 		key.WithHelp("ctrl+r", "restart"),
 	),
+	KeyImportBranch: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "import branch"),
+	),
+	KeyTemplate: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "new from template"),
+	),
+	KeyRefreshDiff: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "refresh diff"),
+	),
+	KeyInterrupt: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "interrupt"),
+	),
+	KeyTag: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "tag"),
+	),
+	KeyAttachSplit: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "attach in split"),
+	),
+	KeyNextReady: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "next waiting"),
+	),
+	KeyNextRunning: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "next running"),
+	),
+	KeyNudge: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "nudge"),
+	),
+	KeyQueuePrompt: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "queue prompt"),
+	),
+	KeyKillBranch: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "kill + delete branch"),
+	),
+	KeySearch: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	KeyResumeAttach: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "resume + attach"),
+	),
+	KeyResumeAll: key.NewBinding(
+		key.WithKeys("alt+r"),
+		key.WithHelp("alt+r", "resume all"),
+	),
+	KeyFocus: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "focus"),
+	),
+	KeyErrors: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "errors"),
+	),
+	KeyClearError: key.NewBinding(
+		key.WithKeys("ctrl+l"),
+		key.WithHelp("ctrl+l", "clear error"),
+	),
+	KeyTranscripts: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "transcripts"),
+	),
+	KeyAttention: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "needs attention"),
+	),
+	KeyCleanupSessions: key.NewBinding(
+		key.WithKeys("Z"),
+		key.WithHelp("Z", "clean up tmux"),
+	),
+	KeyGrowList: key.NewBinding(
+		key.WithKeys(">"),
+		key.WithHelp(">", "wider list"),
+	),
+	KeyShrinkList: key.NewBinding(
+		key.WithKeys("<"),
+		key.WithHelp("<", "wider preview"),
+	),
+	KeyCopyPreview: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy preview"),
+	),
+	KeyMerge: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "merge"),
+	),
+	KeySendKeys: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "send keys"),
+	),
+	KeyPing: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "ping"),
+	),
+	KeySafeMode: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "safe mode"),
+	),
+	KeyRemote: key.NewBinding(
+		key.WithKeys("G"),
+		key.WithHelp("G", "set remote"),
+	),
+	KeyRestartStalled: key.NewBinding(
+		key.WithKeys("alt+ctrl+r"),
+		key.WithHelp("alt+ctrl+r", "restart stalled"),
+	),
+	KeyDestructiveLog: key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "deleted branches"),
+	),
+	KeyTailOutput: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "log output"),
+	),
+	KeyDebugInstance: key.NewBinding(
+		key.WithKeys("alt+d"),
+		key.WithHelp("alt+d", "debug"),
+	),
 
 	// -- Special keybindings --
 
@@ -134,4 +327,8 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "submit name"),
 	),
+	KeyToggleAutoYes: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "toggle auto-yes"),
+	),
 }
@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -28,6 +29,7 @@ var (
 	programFlag string
 	autoYesFlag bool
 	daemonFlag  bool
+	initFlag    bool
 	rootCmd     = &cobra.Command{
 		Use:   "claude-squad",
 		Short: "Claude Squad - Manage multiple AI agents like Claude Code, Aider, Codex, and Amp.",
@@ -49,15 +51,21 @@ var (
 				return fmt.Errorf("failed to get current directory: %w", err)
 			}
 
+			cfg := config.LoadConfig()
+
 			if !git.IsGitRepo(currentDir) {
-				return fmt.Errorf("error: claude-squad must be run from within a git repository")
+				if !initFlag && !cfg.AutoInitRepo {
+					return fmt.Errorf("error: claude-squad must be run from within a git repository (use --init to create one)")
+				}
+				if err := git.InitRepo(currentDir); err != nil {
+					return err
+				}
+				fmt.Printf("Initialized git repository in %s\n", currentDir)
 			}
 
-			cfg := config.LoadConfig()
-
 			// Program flag overrides config
-			program := cfg.DefaultProgram
-			if programFlag != "" {
+			program := cfg.GetDefaultProgram()
+			if strings.TrimSpace(programFlag) != "" {
 				program = programFlag
 			}
 			// AutoYes flag overrides config
@@ -72,6 +80,12 @@ var (
 			
 			if autoYes {
 				defer func() {
+					// The daemon answers prompts in the background, which safe mode turned on with S rules out. It
+					// only reads safe_mode from the config, so don't launch it at all.
+					if session.SafeMode() {
+						log.InfoLog.Printf("safe mode is on, not launching the auto-yes daemon")
+						return
+					}
 					if err := daemon.LaunchDaemon(); err != nil {
 						log.ErrorLog.Printf("failed to launch daemon: %v", err)
 					}
@@ -119,6 +133,124 @@ var (
 		},
 	}
 
+	exportCmd = &cobra.Command{
+		Use:   "export <file>",
+		Short: "Export all sessions to a file so they can be imported on another machine",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			exported, err := storage.ExportState(args[0])
+			if err != nil {
+				return err
+			}
+
+			for _, data := range exported.Instances {
+				if data.Status != session.Paused {
+					fmt.Printf("warning: %s is not paused, uncommitted changes won't be exported\n", data.Title)
+				}
+			}
+			fmt.Printf("Exported %d sessions to %s\n", len(exported.Instances), args[0])
+			fmt.Println("Push their branches, then run 'claude-squad import' from a clone of the repository")
+			return nil
+		},
+	}
+
+	importCmd = &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import sessions exported on another machine as paused sessions of the current repository",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			currentDir, err := filepath.Abs(".")
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			if !git.IsGitRepo(currentDir) {
+				return fmt.Errorf("error: import must be run from within a clone of the exported repository")
+			}
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			titles, err := storage.ImportState(args[0], currentDir)
+			if err != nil {
+				return err
+			}
+
+			if len(titles) == 0 {
+				fmt.Println("No sessions imported: all exported sessions already exist")
+				return nil
+			}
+			fmt.Printf("Imported %d sessions as paused: %s\n", len(titles), strings.Join(titles, ", "))
+			fmt.Println("Resume them in claude-squad to set up their worktrees")
+			return nil
+		},
+	}
+
+	attachCmd = &cobra.Command{
+		Use:   "attach <title>",
+		Short: "Attach to the tmux session of a running session by its title",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			data, err := storage.FindInstanceData(args[0])
+			if err != nil {
+				return err
+			}
+			if data.Status == session.Paused {
+				return fmt.Errorf("session '%s' is paused: resume it in claude-squad first", data.Title)
+			}
+			return tmux.AttachTerminal(data.Title)
+		},
+	}
+
+	statsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Summarize the local stats of created, paused and killed sessions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := session.StatsPath()
+			if err != nil {
+				return err
+			}
+			records, err := session.ReadStats(path)
+			if err != nil {
+				return err
+			}
+			if len(records) == 0 {
+				fmt.Printf("No stats recorded yet in %s\n", path)
+				return nil
+			}
+
+			summary := session.SummarizeStats(records)
+			fmt.Println("Sessions created per day:")
+			for _, day := range summary.Days {
+				fmt.Printf("  %s  %d\n", day.Day, day.Sessions)
+			}
+			fmt.Printf("Sessions: %d created, %d killed\n", summary.Created, summary.Killed)
+			if summary.Killed > 0 {
+				fmt.Printf("Average lifetime: %s\n", summary.AverageLifetime.Round(time.Minute))
+			}
+			fmt.Printf("Lines changed: +%d -%d\n", summary.Added, summary.Removed)
+			return nil
+		},
+	}
+
 	debugCmd = &cobra.Command{
 		Use:   "debug",
 		Short: "Print debug information like config paths",
@@ -161,6 +293,8 @@ func init() {
 		"Program to run in new instances (e.g. 'aider --model ollama_chat/gemma3:1b')")
 	rootCmd.Flags().BoolVarP(&autoYesFlag, "autoyes", "y", false,
 		"[experimental] If enabled, all instances will automatically accept prompts")
+	rootCmd.Flags().BoolVar(&initFlag, "init", false,
+		"Initialize a git repository with an initial commit if the current directory isn't one")
 	rootCmd.Flags().BoolVar(&daemonFlag, "daemon", false, "Run a program that loads all sessions"+
 		" and runs autoyes mode on them.")
 
@@ -173,6 +307,10 @@ func init() {
 	rootCmd.AddCommand(debugCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(resetCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(attachCmd)
+	rootCmd.AddCommand(statsCmd)
 }
 
 func main() {
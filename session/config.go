@@ -0,0 +1,24 @@
+package session
+
+import (
+	"github.com/smtg-ai/claude-squad/config"
+	"sync/atomic"
+)
+
+// appConfig is the config the instances take their settings from, see SetConfig
+var appConfig atomic.Pointer[config.Config]
+
+// SetConfig sets the config all instances take their settings from, e.g. the program wrapper and the ready patterns.
+// It's loaded once at startup rather than read from disk every time a setting is needed.
+func SetConfig(cfg *config.Config) {
+	appConfig.Store(cfg)
+}
+
+// currentConfig returns the config set with SetConfig. If none was set, it's loaded from disk the first time.
+func currentConfig() *config.Config {
+	if cfg := appConfig.Load(); cfg != nil {
+		return cfg
+	}
+	appConfig.CompareAndSwap(nil, config.LoadConfig())
+	return appConfig.Load()
+}
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"github.com/smtg-ai/claude-squad/log"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of change an Event reports
+type EventType string
+
+const (
+	// EventStatusChanged is published when the status of an instance changes, e.g. from running to ready or paused
+	EventStatusChanged EventType = "status_changed"
+	// EventStalled is published when the watchdog finds an instance stalled and tries to unstall it
+	EventStalled EventType = "stalled"
+	// EventRestarted is published after the program of an instance was restarted, by the user or after a crash
+	EventRestarted EventType = "restarted"
+	// EventKilled is published when an instance is killed
+	EventKilled EventType = "killed"
+)
+
+// eventBufferSize is how many events a subscriber can fall behind before events are dropped for it
+const eventBufferSize = 64
+
+// Event is a change to an instance, published to the subscribers of the event stream
+type Event struct {
+	Type    EventType `json:"type"`
+	Time    time.Time `json:"time"`
+	Title   string    `json:"title"`
+	Branch  string    `json:"branch,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	Program string    `json:"program,omitempty"`
+	// Status is the status of the instance after the change
+	Status string `json:"status"`
+	// PreviousStatus is the status before the change. Only set for status changes.
+	PreviousStatus string `json:"previous_status,omitempty"`
+	// Message has details, e.g. the stall attempt
+	Message string `json:"message,omitempty"`
+}
+
+// eventBus fans out events to the subscribers. Publishing never blocks: a subscriber that doesn't keep up misses events
+// instead of stalling the UI.
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+var events = &eventBus{subscribers: make(map[chan Event]struct{})}
+
+// Subscribe returns a channel that receives the events of all instances, and a function that stops the subscription
+// and closes the channel.
+func Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+	events.mu.Lock()
+	events.subscribers[ch] = struct{}{}
+	events.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			events.mu.Lock()
+			delete(events.subscribers, ch)
+			events.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (b *eventBus) publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// newEvent returns an event about i with its current metadata
+func (i *Instance) newEvent(eventType EventType, message string) Event {
+	return Event{
+		Type:    eventType,
+		Time:    time.Now(),
+		Title:   i.Title,
+		Branch:  i.Branch,
+		Path:    i.Path,
+		Program: i.Program,
+		Status:  i.Status.String(),
+		Message: message,
+	}
+}
+
+// publishEvent publishes an event about i
+func (i *Instance) publishEvent(eventType EventType, message string) {
+	events.publish(i.newEvent(eventType, message))
+}
+
+// StartEventLog writes every event as a line of JSON to the file at path, which may also be a FIFO. The file is
+// appended to and created if needed. Opening a FIFO waits for a reader, so it happens in the background; events until
+// then are buffered and dropped once the buffer is full. The returned function stops writing.
+func StartEventLog(path string) func() {
+	ch, stop := Subscribe()
+	go func() {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			log.ErrorLog.Printf("failed to open event log: %v", err)
+			stop()
+			return
+		}
+		defer file.Close()
+
+		encoder := json.NewEncoder(file)
+		for event := range ch {
+			if err := encoder.Encode(event); err != nil {
+				log.ErrorLog.Printf("failed to write to event log, stopping it: %v", err)
+				stop()
+				return
+			}
+		}
+	}()
+	return stop
+}
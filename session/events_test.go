@@ -0,0 +1,80 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveEvent(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-ch:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func TestSubscribeStatusChanges(t *testing.T) {
+	ch, stop := Subscribe()
+	defer stop()
+
+	instance := &Instance{Title: "events", Branch: "me/events", Program: "claude", Status: Running}
+	instance.SetStatus(Running)
+	instance.SetStatus(Ready)
+
+	// Setting the same status again isn't a change
+	event := receiveEvent(t, ch)
+	assert.Equal(t, EventStatusChanged, event.Type)
+	assert.Equal(t, "events", event.Title)
+	assert.Equal(t, "me/events", event.Branch)
+	assert.Equal(t, "running", event.PreviousStatus)
+	assert.Equal(t, "ready", event.Status)
+
+	instance.publishEvent(EventStalled, "unstall attempt 1")
+	event = receiveEvent(t, ch)
+	assert.Equal(t, EventStalled, event.Type)
+	assert.Equal(t, "unstall attempt 1", event.Message)
+
+	stop()
+	_, open := <-ch
+	assert.False(t, open)
+	// Stopping twice is fine, and publishing after stopping doesn't block or panic
+	stop()
+	instance.SetStatus(Running)
+}
+
+func TestStartEventLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	stop := StartEventLog(path)
+	defer stop()
+
+	instance := &Instance{Title: "logged", Status: Running}
+	instance.SetStatus(Paused)
+
+	var event Event
+	require.Eventually(t, func() bool {
+		file, err := os.Open(path)
+		if err != nil {
+			return false
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if err := json.Unmarshal(scanner.Bytes(), &event); err == nil && event.Title == "logged" {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, EventStatusChanged, event.Type)
+	assert.Equal(t, "paused", event.Status)
+}
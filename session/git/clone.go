@@ -0,0 +1,133 @@
+package git
+
+import (
+	"github.com/smtg-ai/claude-squad/config"
+	"github.com/smtg-ai/claude-squad/log"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// scpLikeURL matches git's scp-like syntax for SSH remotes, e.g. "git@github.com:owner/repo.git"
+var scpLikeURL = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^/]`)
+
+// remoteURLPrefixes are the URL schemes git clones from
+var remoteURLPrefixes = []string{"https://", "http://", "ssh://", "git://", "git+ssh://"}
+
+// authFailureMessages are printed by git and ssh when the remote needs credentials that aren't available
+var authFailureMessages = []string{
+	"authentication failed",
+	"could not read username",
+	"could not read password",
+	"permission denied (publickey",
+	"terminal prompts disabled",
+	"host key verification failed",
+}
+
+// IsRemoteURL returns true if path is the URL of a remote repository rather than a local path
+func IsRemoteURL(path string) bool {
+	for _, prefix := range remoteURLPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return scpLikeURL.MatchString(path)
+}
+
+// CloneDir returns the directory a remote repository is cloned to: clone_dir from the config, or the repos directory
+// inside the config directory, followed by the host and path of the URL, e.g. "github.com/owner/repo".
+func CloneDir(url string) (string, error) {
+	baseDir := config.LoadConfig().CloneDir
+	if baseDir == "" {
+		configDir, err := config.GetConfigDir()
+		if err != nil {
+			return "", err
+		}
+		baseDir = filepath.Join(configDir, "repos")
+	}
+	baseDir, err := resolveBaseDir(baseDir)
+	if err != nil {
+		return "", err
+	}
+	return cloneDir(baseDir, url)
+}
+
+func cloneDir(baseDir string, url string) (string, error) {
+	name := url
+	for _, prefix := range remoteURLPrefixes {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	// Drop the user, e.g. "git@" or "user:token@", and turn the scp-like "host:path" into "host/path"
+	if idx := strings.Index(name, "@"); idx >= 0 && idx < strings.IndexAny(name+"/", "/") {
+		name = name[idx+1:]
+	}
+	if scpLikeURL.MatchString(url) {
+		name = strings.Replace(name, ":", "/", 1)
+	}
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "/"), ".git")
+
+	var parts []string
+	for _, part := range strings.Split(name, "/") {
+		// Ports and anything that could escape the base directory don't belong in the path
+		part = strings.ReplaceAll(part, ":", "-")
+		if part != "" && part != "." && part != ".." {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("cannot derive a directory name from %s", url)
+	}
+	return filepath.Join(append([]string{baseDir}, parts...)...), nil
+}
+
+// CloneRepo clones url into dir. If dir already has a clone of url, it's reused as it is. Git isn't allowed to prompt
+// for credentials, since there's no terminal for it, so missing credentials fail right away with an error that says
+// so.
+func CloneRepo(url string, dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		output, err := exec.Command("git", "-C", dir, "remote", "get-url", "origin").Output()
+		if err != nil {
+			return fmt.Errorf("%s already exists and is not a clone of %s", dir, url)
+		}
+		if origin := strings.TrimSpace(string(output)); origin != url {
+			return fmt.Errorf("%s already exists and is a clone of %s, not %s", dir, origin, url)
+		}
+		log.InfoLog.Printf("reusing clone of %s in %s", url, dir)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for clone: %w", err)
+	}
+	cmd := exec.Command("git", "clone", url, dir)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// Don't leave a partial clone behind, it would be reused next time
+		_ = os.RemoveAll(dir)
+		if isAuthFailure(string(output)) {
+			return fmt.Errorf("authentication failed cloning %s. Set up credentials for it (e.g. gh auth login or an "+
+				"SSH key) and try again: %s", url, strings.TrimSpace(string(output)))
+		}
+		return fmt.Errorf("failed to clone %s: %s (%w)", url, strings.TrimSpace(string(output)), err)
+	}
+	log.InfoLog.Printf("cloned %s into %s", url, dir)
+	return nil
+}
+
+// isAuthFailure returns true if the output of git clone says that the remote needs credentials
+func isAuthFailure(output string) bool {
+	output = strings.ToLower(output)
+	for _, msg := range authFailureMessages {
+		if strings.Contains(output, msg) {
+			return true
+		}
+	}
+	return false
+}
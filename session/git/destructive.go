@@ -0,0 +1,162 @@
+package git
+
+import (
+	"github.com/smtg-ai/claude-squad/config"
+	"github.com/smtg-ai/claude-squad/log"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const destructiveLogFileName = "destructive.jsonl"
+
+// maxDestructiveRecords is how many records the destructive operations log keeps. Older records are dropped, by then
+// the reflog has usually expired anyway.
+const maxDestructiveRecords = 200
+
+// DestructiveOp is a git operation that loses work if it was a mistake
+type DestructiveOp string
+
+const (
+	// OpDeleteBranch is the deletion of a branch. SHA is its tip, which can be checked out again until git
+	// garbage collects it.
+	OpDeleteBranch DestructiveOp = "delete_branch"
+	// OpRemoveWorktree is the forced removal of a worktree. SHA is its HEAD; uncommitted changes are gone.
+	OpRemoveWorktree DestructiveOp = "remove_worktree"
+)
+
+// DestructiveRecord is a line of the destructive operations log
+type DestructiveRecord struct {
+	Operation DestructiveOp `json:"operation"`
+	Time      time.Time     `json:"time"`
+	Repo      string        `json:"repo"`
+	Branch    string        `json:"branch"`
+	SHA       string        `json:"sha,omitempty"`
+	Path      string        `json:"path,omitempty"`
+}
+
+// String describes the record, including how to get the branch back
+func (r DestructiveRecord) String() string {
+	switch r.Operation {
+	case OpDeleteBranch:
+		return fmt.Sprintf("deleted branch %s at %s in %s (git branch %s %s)", r.Branch, shortSHA(r.SHA), r.Repo,
+			r.Branch, r.SHA)
+	case OpRemoveWorktree:
+		return fmt.Sprintf("removed worktree %s of %s at %s", r.Path, r.Branch, shortSHA(r.SHA))
+	}
+	return fmt.Sprintf("%s %s at %s", r.Operation, r.Branch, shortSHA(r.SHA))
+}
+
+// shortSHA abbreviates sha for display
+func shortSHA(sha string) string {
+	if sha == "" {
+		return "unknown commit"
+	}
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+// DestructiveLogPath returns the path of the destructive operations log
+func DestructiveLogPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, destructiveLogFileName), nil
+}
+
+// recordDestructive adds a record of op on the branch of g to the destructive operations log. sha is the commit the
+// branch or worktree was at. Failing to record it is only logged, it doesn't stop the operation.
+func (g *GitWorktree) recordDestructive(op DestructiveOp, sha string) {
+	path, err := DestructiveLogPath()
+	if err != nil {
+		log.WarningLog.Printf("could not record %s of %s: %v", op, g.branchName, err)
+		return
+	}
+	record := DestructiveRecord{Operation: op, Time: time.Now(), Repo: g.repoPath, Branch: g.branchName, SHA: sha}
+	if op == OpRemoveWorktree {
+		record.Path = g.worktreePath
+	}
+	if err := appendDestructive(path, record); err != nil {
+		log.WarningLog.Printf("could not record %s of %s: %v", op, g.branchName, err)
+	}
+}
+
+// revParse returns the commit ref points to in path, or an empty string if it can't be resolved
+func (g *GitWorktree) revParse(path string, ref string) string {
+	output, err := g.runGitCommand(path, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+// appendDestructive adds record to the log at path, dropping the oldest records beyond maxDestructiveRecords. The
+// log is small, so it's simply rewritten, under a lock so that other claude-squad processes don't lose their records.
+func appendDestructive(path string, record DestructiveRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock destructive operations log: %w", err)
+	}
+	defer unlock()
+
+	records, err := ReadDestructiveLog(path)
+	if err != nil {
+		return err
+	}
+	records = append(records, record)
+	if len(records) > maxDestructiveRecords {
+		records = records[len(records)-maxDestructiveRecords:]
+	}
+
+	var b strings.Builder
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	// Write a copy and rename it, so that a crash halfway doesn't lose the records that were there
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadDestructiveLog reads the records of the destructive operations log at path, oldest first. Lines that don't
+// parse are skipped. A missing log has no records.
+func ReadDestructiveLog(path string) ([]DestructiveRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open destructive operations log: %w", err)
+	}
+	defer file.Close()
+
+	var records []DestructiveRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record DestructiveRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err == nil {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read destructive operations log: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,99 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCleanupRecordsDestructiveOps(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := newTestRepo(t, "one\n")
+	base := runGit(t, repo, "rev-parse", "HEAD")
+	worktreePath := filepath.Join(t.TempDir(), "worktree")
+	runGit(t, repo, "worktree", "add", "-q", "-b", "session/test", worktreePath)
+	if err := os.WriteFile(filepath.Join(worktreePath, "file.txt"), []byte("two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, worktreePath, "commit", "-q", "-am", "change")
+	tip := runGit(t, repo, "rev-parse", "session/test")
+
+	worktree := NewGitWorktreeFromStorage(repo, worktreePath, "test", "session/test", base, false)
+	if err := worktree.Cleanup(); err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+
+	path, err := DestructiveLogPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadDestructiveLog(path)
+	if err != nil {
+		t.Fatalf("ReadDestructiveLog() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("ReadDestructiveLog() = %v, want the worktree removal and the branch deletion", records)
+	}
+	if records[0].Operation != OpRemoveWorktree || records[0].Path != worktreePath || records[0].SHA != tip {
+		t.Errorf("first record = %+v, want the removal of %s at %s", records[0], worktreePath, tip)
+	}
+	if records[1].Operation != OpDeleteBranch || records[1].Branch != "session/test" || records[1].SHA != tip {
+		t.Errorf("second record = %+v, want the deletion of session/test at %s", records[1], tip)
+	}
+
+	// The branch can be recovered from the SHA
+	runGit(t, repo, "branch", "session/test", records[1].SHA)
+}
+
+func TestDestructiveLogConcurrentAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "destructive.jsonl")
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- appendDestructive(path, DestructiveRecord{Operation: OpDeleteBranch, Branch: fmt.Sprintf("branch-%d", i)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("appendDestructive() error = %v", err)
+		}
+	}
+
+	records, err := ReadDestructiveLog(path)
+	if err != nil {
+		t.Fatalf("ReadDestructiveLog() error = %v", err)
+	}
+	if len(records) != writers {
+		t.Errorf("ReadDestructiveLog() = %d records, want all %d", len(records), writers)
+	}
+}
+
+func TestDestructiveLogKeepsNewestRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "destructive.jsonl")
+	for i := 0; i < maxDestructiveRecords+5; i++ {
+		record := DestructiveRecord{Operation: OpDeleteBranch, Time: time.Now(), Branch: fmt.Sprintf("branch-%d", i)}
+		if err := appendDestructive(path, record); err != nil {
+			t.Fatalf("appendDestructive() error = %v", err)
+		}
+	}
+
+	records, err := ReadDestructiveLog(path)
+	if err != nil {
+		t.Fatalf("ReadDestructiveLog() error = %v", err)
+	}
+	if len(records) != maxDestructiveRecords {
+		t.Fatalf("ReadDestructiveLog() = %d records, want %d", len(records), maxDestructiveRecords)
+	}
+	if records[0].Branch != "branch-5" || records[len(records)-1].Branch != fmt.Sprintf("branch-%d", maxDestructiveRecords+4) {
+		t.Errorf("ReadDestructiveLog() kept %s to %s, want the newest", records[0].Branch, records[len(records)-1].Branch)
+	}
+}
@@ -1,9 +1,15 @@
 package git
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 )
 
+// ErrBaseCommitNotSet is the error of a diff of a worktree whose setup never completed, so that there's no commit to
+// diff against. CompleteSetup fixes it.
+var ErrBaseCommitNotSet = errors.New("base commit SHA not set")
+
 // DiffStats holds statistics about the changes in a diff
 type DiffStats struct {
 	// Content is the full diff content
@@ -24,6 +30,10 @@ func (d *DiffStats) IsEmpty() bool {
 // Diff returns the git diff between the worktree and the base branch along with statistics
 func (g *GitWorktree) Diff() *DiffStats {
 	stats := &DiffStats{}
+	if g.baseCommitSHA == "" {
+		stats.Error = ErrBaseCommitNotSet
+		return stats
+	}
 
 	// -N stages untracked files (intent to add), including them in the diff
 	_, err := g.runGitCommand(g.worktreePath, "add", "-N", ".")
@@ -37,15 +47,123 @@ func (g *GitWorktree) Diff() *DiffStats {
 		stats.Error = err
 		return stats
 	}
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
-			stats.Added++
-		} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
-			stats.Removed++
+	return newDiffStats(content)
+}
+
+// BranchDiff returns the diff between the base commit and the tip of the branch. Unlike Diff, it doesn't need the
+// worktree, so it works for paused sessions, and it only includes committed changes.
+func (g *GitWorktree) BranchDiff() *DiffStats {
+	if g.baseCommitSHA == "" {
+		return &DiffStats{Error: ErrBaseCommitNotSet}
+	}
+	content, err := g.runGitCommand(g.repoPath, "--no-pager", "diff", g.GetBaseCommitSHA(), g.branchName)
+	if err != nil {
+		return &DiffStats{Error: err}
+	}
+	return newDiffStats(content)
+}
+
+// newDiffStats counts the added and removed lines of a diff. Changes to binary files would only show up as garbage, so
+// they're summarized by a line instead and don't count towards the added and removed lines.
+func newDiffStats(content string) *DiffStats {
+	stats := &DiffStats{}
+	var b strings.Builder
+	for _, file := range splitDiffFiles(content) {
+		if name, ok := binaryDiffFile(file); ok {
+			b.WriteString(fmt.Sprintf("Binary file %s changed\n", name))
+			continue
+		}
+		for _, line := range strings.Split(file, "\n") {
+			if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+				stats.Added++
+			} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
+				stats.Removed++
+			}
 		}
+		b.WriteString(file)
 	}
-	stats.Content = content
+	stats.Content = b.String()
 
 	return stats
 }
+
+// splitDiffFiles splits a diff into the parts for each file, each starting with its "diff --git" line. Anything before
+// the first file is a part of its own.
+func splitDiffFiles(content string) []string {
+	var files []string
+	start := 0
+	for _, idx := range diffFileStarts(content) {
+		if idx > start {
+			files = append(files, content[start:idx])
+		}
+		start = idx
+	}
+	if start < len(content) {
+		files = append(files, content[start:])
+	}
+	return files
+}
+
+// diffFileStarts returns the offsets of the "diff --git" lines in content
+func diffFileStarts(content string) []int {
+	const header = "diff --git "
+	var starts []int
+	for offset := 0; offset < len(content); {
+		if strings.HasPrefix(content[offset:], header) {
+			starts = append(starts, offset)
+		}
+		next := strings.IndexByte(content[offset:], '\n')
+		if next < 0 {
+			break
+		}
+		offset += next + 1
+	}
+	return starts
+}
+
+// binaryDiffFile returns the name of the file and true if the diff of a file is for a binary file: git says so, or
+// the diff looks binary, e.g. because a diff driver treats the file as text.
+func binaryDiffFile(file string) (string, bool) {
+	header, body, _ := strings.Cut(file, "\n")
+	if !strings.HasPrefix(header, "diff --git ") {
+		return "", false
+	}
+	binary := looksBinary(body)
+	for _, line := range strings.Split(body, "\n") {
+		if line == "GIT binary patch" || (strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ")) {
+			binary = true
+		}
+	}
+	if !binary {
+		return "", false
+	}
+	name := strings.TrimPrefix(header, "diff --git ")
+	if idx := strings.LastIndex(name, " b/"); idx >= 0 {
+		name = name[idx+len(" b/"):]
+	}
+	return name, true
+}
+
+// binaryControlPercent is the share of control characters above which text is considered binary
+const binaryControlPercent = 10
+
+// looksBinary returns true if text has a NUL byte, like git's own check, or too many control characters to be text.
+// Text files can have the odd control character, e.g. a bell or backspace in a log, so a few of them are fine.
+func looksBinary(text string) bool {
+	if strings.IndexByte(text, 0) >= 0 {
+		return true
+	}
+	var runes, control int
+	for _, r := range text {
+		runes++
+		if isControlRune(r) {
+			control++
+		}
+	}
+	return control*100 > runes*binaryControlPercent
+}
+
+// isControlRune returns true for control characters, other than the whitespace and escape codes found in text
+func isControlRune(r rune) bool {
+	return r < 0x20 && !strings.ContainsRune("\t\n\r\f\v\x1b", r)
+}
@@ -0,0 +1,144 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBranchDiff(t *testing.T) {
+	repo := newTestRepo(t, "one\ntwo\n")
+	base := runGit(t, repo, "rev-parse", "HEAD")
+
+	// Commit a change on the branch and leave the repo on the base branch, like a paused session
+	runGit(t, repo, "checkout", "-q", "-b", "session/test")
+	if err := os.WriteFile(filepath.Join(repo, "file.txt"), []byte("one\nthree\nfour\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "commit", "-q", "-am", "change")
+	runGit(t, repo, "checkout", "-q", base)
+
+	worktree := NewGitWorktreeFromStorage(repo, filepath.Join(t.TempDir(), "removed"), "test", "session/test", base, false)
+	stats := worktree.BranchDiff()
+	if stats.Error != nil {
+		t.Fatalf("BranchDiff() error = %v", stats.Error)
+	}
+	if stats.Added != 2 || stats.Removed != 1 {
+		t.Errorf("BranchDiff() = +%d -%d, want +2 -1", stats.Added, stats.Removed)
+	}
+	if !strings.Contains(stats.Content, "+three") {
+		t.Errorf("BranchDiff() content = %q, want the branch's change", stats.Content)
+	}
+}
+
+func TestCompleteSetup(t *testing.T) {
+	repo := newTestRepo(t, "one\n")
+	head := runGit(t, repo, "rev-parse", "HEAD")
+
+	// A worktree that exists, with a change in it, but whose base commit was never recorded
+	worktreePath := filepath.Join(t.TempDir(), "worktree")
+	runGit(t, repo, "worktree", "add", "-q", "-b", "session/test", worktreePath)
+	if err := os.WriteFile(filepath.Join(worktreePath, "file.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	worktree := NewGitWorktreeFromStorage(repo, worktreePath, "test", "session/test", "", false)
+
+	if stats := worktree.Diff(); !errors.Is(stats.Error, ErrBaseCommitNotSet) {
+		t.Fatalf("Diff() error = %v, want ErrBaseCommitNotSet", stats.Error)
+	}
+	if err := worktree.CompleteSetup(); err != nil {
+		t.Fatalf("CompleteSetup() error = %v", err)
+	}
+	if got := worktree.GetBaseCommitSHA(); got != head {
+		t.Errorf("GetBaseCommitSHA() = %q, want %q", got, head)
+	}
+	stats := worktree.Diff()
+	if stats.Error != nil {
+		t.Fatalf("Diff() error = %v", stats.Error)
+	}
+	if stats.Added != 1 || stats.Removed != 0 {
+		t.Errorf("Diff() = +%d -%d, want the change in the worktree to be kept", stats.Added, stats.Removed)
+	}
+}
+
+func TestDiffSummarizesBinaryFiles(t *testing.T) {
+	repo := newTestRepo(t, "one\n")
+	head := runGit(t, repo, "rev-parse", "HEAD")
+	worktreePath := filepath.Join(t.TempDir(), "worktree")
+	runGit(t, repo, "worktree", "add", "-q", "-b", "session/test", worktreePath)
+	worktree := NewGitWorktreeFromStorage(repo, worktreePath, "test", "session/test", head, false)
+
+	// A text change and a new image
+	if err := os.WriteFile(filepath.Join(worktreePath, "file.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+	if err := os.WriteFile(filepath.Join(worktreePath, "image.png"), png, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := worktree.Diff()
+	if stats.Error != nil {
+		t.Fatalf("Diff() error = %v", stats.Error)
+	}
+	if stats.Added != 1 || stats.Removed != 0 {
+		t.Errorf("Diff() = +%d -%d, want only the text change counted", stats.Added, stats.Removed)
+	}
+	if !strings.Contains(stats.Content, "Binary file image.png changed") {
+		t.Errorf("Diff() content = %q, want a summary of the image change", stats.Content)
+	}
+	if !strings.Contains(stats.Content, "+two") {
+		t.Errorf("Diff() content = %q, want the text change", stats.Content)
+	}
+	if looksBinary(stats.Content) {
+		t.Errorf("Diff() content = %q, want no binary content", stats.Content)
+	}
+}
+
+func TestNewDiffStatsSummarizesBinaryTextDiffs(t *testing.T) {
+	// A diff driver that treats a binary file as text puts its bytes in the diff
+	content := "diff --git a/data.bin b/data.bin\nindex 1111111..2222222 100644\n--- a/data.bin\n+++ b/data.bin\n" +
+		"@@ -1 +1 @@\n-\x00\x01old\n+\x00\x02new\n"
+	stats := newDiffStats(content)
+	if stats.Added != 0 || stats.Removed != 0 {
+		t.Errorf("newDiffStats() = +%d -%d, want binary lines left out", stats.Added, stats.Removed)
+	}
+	if stats.Content != "Binary file data.bin changed\n" {
+		t.Errorf("newDiffStats() content = %q, want a summary", stats.Content)
+	}
+}
+
+func TestLooksBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{name: "plain text", text: "one\ntwo\n", want: false},
+		{name: "whitespace and escape codes", text: "\tone\r\n\x1b[31mtwo\x1b[0m\f\v\n", want: false},
+		{name: "a few control characters", text: "downloading dependencies\nprogress 10%\x08\x08\x0820%\nbuild finished\x07\n", want: false},
+		{name: "NUL byte", text: "one\x00two\n", want: true},
+		{name: "mostly control characters", text: "\x01\x02\x03\x04ab\n", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := looksBinary(tt.text); got != tt.want {
+				t.Errorf("looksBinary(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDiffStatsCountsTextWithControlCharacters(t *testing.T) {
+	content := "diff --git a/build.log b/build.log\nindex 1111111..2222222 100644\n--- a/build.log\n+++ b/build.log\n" +
+		"@@ -1 +1,2 @@\n-building\n+building\x07\n+done\n"
+	stats := newDiffStats(content)
+	if stats.Added != 2 || stats.Removed != 1 {
+		t.Errorf("newDiffStats() = +%d -%d, want +2 -1", stats.Added, stats.Removed)
+	}
+	if stats.Content != content {
+		t.Errorf("newDiffStats() content = %q, want the diff unchanged", stats.Content)
+	}
+}
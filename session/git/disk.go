@@ -0,0 +1,89 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// diskFullMessages are what git and the OS print when a write fails because the disk or the quota is full
+var diskFullMessages = []string{
+	"no space left on device",
+	"disk quota exceeded",
+	"not enough space on the disk",
+}
+
+// DiskFullError is returned when a worktree couldn't be set up because the disk is full. The partial worktree has
+// been removed, so setting up again works once space is freed.
+type DiskFullError struct {
+	// Path is the worktree path
+	Path string
+	// Free is a description of the free space on the disk of the worktree, empty if it couldn't be checked
+	Free string
+	Err  error
+}
+
+func (e *DiskFullError) Error() string {
+	msg := fmt.Sprintf("disk full while setting up the worktree at %s", e.Path)
+	if e.Free != "" {
+		msg += fmt.Sprintf(" (%s free)", e.Free)
+	}
+	return msg + ". Free up some space and try again"
+}
+
+func (e *DiskFullError) Unwrap() error {
+	return e.Err
+}
+
+// isDiskFullError returns true if err was caused by a full disk or quota
+func isDiskFullError(err error) bool {
+	if errors.Is(err, syscall.ENOSPC) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, diskFull := range diskFullMessages {
+		if strings.Contains(msg, diskFull) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeFreeSpace returns the free space on the filesystem of path, e.g. "12.5 MB", or "" if it can't be checked.
+// path doesn't have to exist; the closest parent that does is checked.
+func describeFreeSpace(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return ""
+		}
+		path = parent
+	}
+	free, err := freeSpace(path)
+	if err != nil {
+		return ""
+	}
+	return formatBytes(free)
+}
+
+// formatBytes formats a number of bytes with one decimal, e.g. "1.5 GB"
+func formatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TB", value)
+}
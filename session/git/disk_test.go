@@ -0,0 +1,56 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestIsDiskFullError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("git command failed: fatal: could not write index: No space left on device (exit status 128)"), true},
+		{fmt.Errorf("failed to create worktree: %w", syscall.ENOSPC), true},
+		{errors.New("error: unable to write file: Disk quota exceeded"), true},
+		{errors.New("fatal: 'session/foo' is already checked out at '/tmp/x'"), false},
+	}
+	for _, tt := range tests {
+		if got := isDiskFullError(tt.err); got != tt.want {
+			t.Errorf("isDiskFullError(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDiskFullError(t *testing.T) {
+	err := &DiskFullError{Path: "/tmp/wt", Free: "12.0 KB", Err: syscall.ENOSPC}
+	if !errors.Is(err, syscall.ENOSPC) {
+		t.Error("DiskFullError doesn't unwrap to the cause")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "disk full") || !strings.Contains(msg, "12.0 KB free") {
+		t.Errorf("Error() = %q, want the disk full message with the free space", msg)
+	}
+
+	// The free space is checked on the closest existing parent
+	if free := describeFreeSpace(filepath.Join(t.TempDir(), "not", "created")); free == "" {
+		t.Error("describeFreeSpace() = \"\", want the free space of the temp dir")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		512:             "512 B",
+		1536:            "1.5 KB",
+		5 * 1024 * 1024: "5.0 MB",
+		3 << 40:         "3.0 TB",
+	}
+	for bytes, want := range tests {
+		if got := formatBytes(bytes); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
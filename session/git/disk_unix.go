@@ -0,0 +1,16 @@
+//go:build !windows
+
+package git
+
+import (
+	"syscall"
+)
+
+// freeSpace returns the number of bytes available to unprivileged users on the filesystem that holds path
+func freeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
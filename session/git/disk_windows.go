@@ -0,0 +1,12 @@
+//go:build windows
+
+package git
+
+import (
+	"fmt"
+)
+
+// freeSpace isn't supported on Windows
+func freeSpace(path string) (uint64, error) {
+	return 0, fmt.Errorf("checking free space is not supported on Windows")
+}
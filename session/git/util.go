@@ -1,7 +1,10 @@
 package git
 
 import (
+	"github.com/smtg-ai/claude-squad/config"
+	"crypto/sha256"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
@@ -10,10 +13,20 @@ import (
 	"github.com/go-git/go-git/v5"
 )
 
+// maxSanitizedNameLength is the longest name derived from a session title, so that long titles still give
+// reasonable branch names and worktree paths
+const maxSanitizedNameLength = 64
+
+// truncatedNameHashLength is how many hex digits of the hash of the full title end a truncated name
+const truncatedNameHashLength = 8
+
 // sanitizeBranchName transforms an arbitrary string into a Git branch name friendly string.
 // Note: Git branch names have several rules, so this function uses a simple approach
-// by allowing only a safe subset of characters.
+// by allowing only a safe subset of characters. Long names are truncated and end in a short hash of s instead, so
+// that titles with the same beginning still get different branches.
 func sanitizeBranchName(s string) string {
+	original := s
+
 	// Convert to lower-case
 	s = strings.ToLower(s)
 
@@ -29,12 +42,31 @@ func sanitizeBranchName(s string) string {
 	reDash := regexp.MustCompile(`-+`)
 	s = reDash.ReplaceAllString(s, "-")
 
-	// Trim leading and trailing dashes or slashes to avoid issues
+	// Only ASCII is left, so this can't split a character
+	if len(s) > maxSanitizedNameLength {
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(original)))[:truncatedNameHashLength]
+		s = strings.TrimRight(s[:maxSanitizedNameLength-truncatedNameHashLength-1], "-/.") + "-" + hash
+	}
+
+	// Trim leading and trailing dashes or slashes to avoid issues. A truncated name can also end in a dot, which git
+	// doesn't allow for a ref component.
 	s = strings.Trim(s, "-/")
+	s = strings.TrimRight(s, ".")
 
 	return s
 }
 
+// branchNameFromTemplate derives a branch name from a session title. In template, {prefix} is replaced by prefix and
+// {title} by the sanitized title. A template without {title} would give every session the same branch, so it falls
+// back to the default template.
+func branchNameFromTemplate(template string, prefix string, title string) string {
+	if !strings.Contains(template, "{title}") {
+		template = config.DefaultBranchNameTemplate
+	}
+	name := strings.ReplaceAll(template, "{prefix}", prefix)
+	return strings.ReplaceAll(name, "{title}", sanitizeBranchName(title))
+}
+
 // checkGHCLI checks if GitHub CLI is installed and configured
 func checkGHCLI() error {
 	// Check if gh is installed
@@ -67,6 +99,82 @@ func IsGitRepo(path string) bool {
 	}
 }
 
+// InitRepo turns path into a git repository and commits its current contents so that worktrees can be created
+// from HEAD. If no git identity is configured, a placeholder one is used for the initial commit.
+func InitRepo(path string) error {
+	if out, err := exec.Command("git", "-C", path, "init").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to initialize git repository: %s (%w)", out, err)
+	}
+	if out, err := exec.Command("git", "-C", path, "add", "-A").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stage files for initial commit: %s (%w)", out, err)
+	}
+
+	args := []string{"-C", path}
+	if out, err := exec.Command("git", "-C", path, "config", "user.email").Output(); err != nil || strings.TrimSpace(string(out)) == "" {
+		args = append(args, "-c", "user.name=Claude Squad", "-c", "user.email=claude-squad@localhost")
+	}
+	args = append(args, "commit", "--allow-empty", "-m", "Initial commit")
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create initial commit: %s (%w)", out, err)
+	}
+	return nil
+}
+
+// RepoCondition describes what state the repository's checkout is in
+type RepoCondition string
+
+const (
+	// RepoClean means a branch is checked out and no operation is in progress
+	RepoClean RepoCondition = "clean"
+	// RepoDetached means HEAD points at a commit rather than a branch
+	RepoDetached RepoCondition = "detached HEAD"
+	// RepoRebasing means a rebase is in progress
+	RepoRebasing RepoCondition = "rebase"
+	// RepoMerging means a merge is in progress
+	RepoMerging RepoCondition = "merge"
+	// RepoCherryPicking means a cherry-pick is in progress
+	RepoCherryPicking RepoCondition = "cherry-pick"
+	// RepoReverting means a revert is in progress
+	RepoReverting RepoCondition = "revert"
+)
+
+// InProgress returns true if an operation like a rebase is in progress. HEAD doesn't point at a finished commit
+// then, so new worktrees shouldn't be created from it.
+func (c RepoCondition) InProgress() bool {
+	return c != RepoClean && c != RepoDetached
+}
+
+// RepoState returns the state of the repository at repoPath, e.g. whether a rebase is in progress
+func RepoState(repoPath string) (RepoCondition, error) {
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find git directory of %s: %w", repoPath, err)
+	}
+	gitDir := strings.TrimSpace(string(out))
+
+	// HEAD is detached during a rebase, so look for operations in progress first
+	markers := []struct {
+		name      string
+		condition RepoCondition
+	}{
+		{"rebase-merge", RepoRebasing},
+		{"rebase-apply", RepoRebasing},
+		{"MERGE_HEAD", RepoMerging},
+		{"CHERRY_PICK_HEAD", RepoCherryPicking},
+		{"REVERT_HEAD", RepoReverting},
+	}
+	for _, marker := range markers {
+		if _, err := os.Stat(filepath.Join(gitDir, marker.name)); err == nil {
+			return marker.condition, nil
+		}
+	}
+
+	if err := exec.Command("git", "-C", repoPath, "symbolic-ref", "-q", "HEAD").Run(); err != nil {
+		return RepoDetached, nil
+	}
+	return RepoClean, nil
+}
+
 func findGitRepoRoot(path string) (string, error) {
 	currentPath := path
 	for {
@@ -1,9 +1,39 @@
 package git
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
+// newTestRepo creates a repository in a temporary directory with file.txt committed with content
+func newTestRepo(t *testing.T, content string) string {
+	t.Helper()
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "file.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitRepo(repo); err != nil {
+		t.Fatalf("InitRepo() error = %v", err)
+	}
+	return repo
+}
+
+// runGit runs git in dir with a test identity and returns its trimmed output, failing the test on error
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@localhost"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %s (%v)", args, out, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
 func TestSanitizeBranchName(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -60,6 +90,12 @@ func TestSanitizeBranchName(t *testing.T) {
 			input:    "USER/Feature Branch!@#$%^&*()/v1.0",
 			expected: "user/feature-branch/v1.0",
 		},
+		{
+			name:  "long string is truncated with a hash",
+			input: strings.Repeat("long title ", 10),
+			expected: strings.Repeat("long-title-", 5)[:54] + "-" +
+				fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Repeat("long title ", 10))))[:truncatedNameHashLength],
+		},
 	}
 
 	for _, tt := range tests {
@@ -70,4 +106,125 @@ func TestSanitizeBranchName(t *testing.T) {
 			}
 		})
 	}
+
+	// Long titles with the same beginning don't share a branch
+	first := sanitizeBranchName(strings.Repeat("long title ", 10) + "first")
+	second := sanitizeBranchName(strings.Repeat("long title ", 10) + "second")
+	if first == second {
+		t.Errorf("long titles got the same branch name %q", first)
+	}
+	if len(first) > maxSanitizedNameLength {
+		t.Errorf("sanitizeBranchName gave %q, longer than %d", first, maxSanitizedNameLength)
+	}
+}
+
+func TestBranchNameFromTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "empty template keeps the default",
+			template: "",
+			expected: "user/fix-login-bug",
+		},
+		{
+			name:     "default template",
+			template: "{prefix}{title}",
+			expected: "user/fix-login-bug",
+		},
+		{
+			name:     "fixed prefix",
+			template: "feature/{title}",
+			expected: "feature/fix-login-bug",
+		},
+		{
+			name:     "prefix and fixed part",
+			template: "{prefix}feature/{title}",
+			expected: "user/feature/fix-login-bug",
+		},
+		{
+			name:     "template without title falls back to the default",
+			template: "feature/{prefix}",
+			expected: "user/fix-login-bug",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := branchNameFromTemplate(tt.template, "user/", "Fix Login Bug")
+			if got != tt.expected {
+				t.Errorf("branchNameFromTemplate(%q) = %q, want %q", tt.template, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewWorktreePath(t *testing.T) {
+	dir := t.TempDir()
+
+	named, err := newWorktreePath(dir, "Secret Customer Fix", false)
+	if err != nil {
+		t.Fatalf("newWorktreePath() error = %v", err)
+	}
+	if filepath.Dir(named) != dir || !strings.HasPrefix(filepath.Base(named), "secret-customer-fix_") {
+		t.Errorf("newWorktreePath() = %q, want it named after the title in %s", named, dir)
+	}
+
+	opaque, err := newWorktreePath(dir, "Secret Customer Fix", true)
+	if err != nil {
+		t.Fatalf("newWorktreePath() error = %v", err)
+	}
+	if filepath.Dir(opaque) != dir || strings.Contains(strings.ToLower(opaque), "secret") {
+		t.Errorf("newWorktreePath() = %q, want an opaque name in %s", opaque, dir)
+	}
+	other, err := newWorktreePath(dir, "Secret Customer Fix", true)
+	if err != nil {
+		t.Fatalf("newWorktreePath() error = %v", err)
+	}
+	if other == opaque {
+		t.Errorf("newWorktreePath() returned %q twice, want unique paths", opaque)
+	}
+}
+
+func TestRepoState(t *testing.T) {
+	repo := t.TempDir()
+	if err := InitRepo(repo); err != nil {
+		t.Fatalf("InitRepo() error = %v", err)
+	}
+
+	check := func(want RepoCondition) {
+		t.Helper()
+		got, err := RepoState(repo)
+		if err != nil {
+			t.Fatalf("RepoState() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("RepoState() = %q, want %q", got, want)
+		}
+	}
+
+	check(RepoClean)
+
+	runGit(t, repo, "checkout", "--detach")
+	check(RepoDetached)
+
+	// A rebase also detaches HEAD, but the rebase is what matters
+	rebaseDir := filepath.Join(repo, ".git", "rebase-merge")
+	if err := os.Mkdir(rebaseDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	check(RepoRebasing)
+	if !RepoRebasing.InProgress() || RepoDetached.InProgress() {
+		t.Errorf("only operations should be in progress")
+	}
+
+	if err := os.Remove(rebaseDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".git", "MERGE_HEAD"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	check(RepoMerging)
 }
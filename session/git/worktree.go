@@ -3,12 +3,23 @@ package git
 import (
 	"github.com/smtg-ai/claude-squad/config"
 	"github.com/smtg-ai/claude-squad/log"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 )
 
 func getWorktreeDirectory() (string, error) {
+	if baseDir := config.LoadConfig().WorktreeBaseDir; baseDir != "" {
+		return resolveBaseDir(baseDir)
+	}
+
 	configDir, err := config.GetConfigDir()
 	if err != nil {
 		return "", err
@@ -17,6 +28,41 @@ func getWorktreeDirectory() (string, error) {
 	return filepath.Join(configDir, "worktrees"), nil
 }
 
+// newWorktreePath returns a new, unique path in worktreeDir for the worktree of the session. It's named after the
+// session, unless opaque is set: then it's random, so that the title doesn't show up on disk. The path is stored with
+// the session, so the naming can change without breaking existing sessions.
+func newWorktreePath(worktreeDir string, sessionName string, opaque bool) (string, error) {
+	if opaque {
+		random := make([]byte, 8)
+		if _, err := rand.Read(random); err != nil {
+			return "", fmt.Errorf("failed to generate worktree name: %w", err)
+		}
+		return filepath.Join(worktreeDir, "wt_"+hex.EncodeToString(random)), nil
+	}
+	return filepath.Join(worktreeDir, sanitizeBranchName(sessionName)+"_"+fmt.Sprintf("%x", time.Now().UnixNano())), nil
+}
+
+// resolveBaseDir expands a leading "~/" and makes a directory from the config, like the worktree directory, absolute
+// so that git records a stable path for what's created in it. The directory is created if needed.
+func resolveBaseDir(baseDir string) (string, error) {
+	if baseDir == "~" || strings.HasPrefix(baseDir, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand directory %s: %w", baseDir, err)
+		}
+		baseDir = filepath.Join(homeDir, strings.TrimPrefix(baseDir, "~"))
+	}
+
+	absDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory %s: %w", baseDir, err)
+	}
+	if err := os.MkdirAll(absDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", absDir, err)
+	}
+	return absDir, nil
+}
+
 // GitWorktree manages git worktree operations for a session
 type GitWorktree struct {
 	// Path to the repository
@@ -29,23 +75,34 @@ type GitWorktree struct {
 	branchName string
 	// Base commit hash for the worktree
 	baseCommitSHA string
+	// existingBranch is true if the branch was imported rather than created for this session.
+	// Cleanup never deletes an imported branch.
+	existingBranch bool
+	// remote is the remote the branch is pushed to. Empty means origin.
+	remote string
+	// branchOnOrigin is true if NewGitWorktreeForImport found the branch only on origin. CreateImportBranch creates
+	// the local branch for it.
+	branchOnOrigin bool
 }
 
-func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName string, branchName string, baseCommitSHA string) *GitWorktree {
+// defaultRemote is the remote branches are pushed to unless another one is set
+const defaultRemote = "origin"
+
+func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName string, branchName string, baseCommitSHA string, existingBranch bool) *GitWorktree {
 	return &GitWorktree{
-		repoPath:      repoPath,
-		worktreePath:  worktreePath,
-		sessionName:   sessionName,
-		branchName:    branchName,
-		baseCommitSHA: baseCommitSHA,
+		repoPath:       repoPath,
+		worktreePath:   worktreePath,
+		sessionName:    sessionName,
+		branchName:     branchName,
+		baseCommitSHA:  baseCommitSHA,
+		existingBranch: existingBranch,
 	}
 }
 
 // NewGitWorktree creates a new GitWorktree instance
 func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, branchname string, err error) {
 	cfg := config.LoadConfig()
-	sanitizedName := sanitizeBranchName(sessionName)
-	branchName := fmt.Sprintf("%s%s", cfg.BranchPrefix, sanitizedName)
+	branchName := branchNameFromTemplate(cfg.BranchNameTemplate, cfg.BranchPrefix, sessionName)
 
 	// Convert repoPath to absolute path
 	absPath, err := filepath.Abs(repoPath)
@@ -60,13 +117,28 @@ func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, bra
 		return nil, "", err
 	}
 
+	// New worktrees start from HEAD. A detached HEAD is fine, the worktree starts from the current commit, but in the
+	// middle of e.g. a rebase HEAD isn't a commit the user meant to build on.
+	state, err := RepoState(repoPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if state.InProgress() {
+		return nil, "", fmt.Errorf("a %s is in progress in %s: finish or abort it before creating a session", state, repoPath)
+	}
+	if state == RepoDetached {
+		log.InfoLog.Printf("HEAD is detached in %s, starting the worktree from the current commit", repoPath)
+	}
+
 	worktreeDir, err := getWorktreeDirectory()
 	if err != nil {
 		return nil, "", err
 	}
 
-	worktreePath := filepath.Join(worktreeDir, sanitizedName)
-	worktreePath = worktreePath + "_" + fmt.Sprintf("%x", time.Now().UnixNano())
+	worktreePath, err := newWorktreePath(worktreeDir, sessionName, cfg.OpaqueWorktreeNames)
+	if err != nil {
+		return nil, "", err
+	}
 
 	return &GitWorktree{
 		repoPath:     repoPath,
@@ -76,6 +148,131 @@ func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, bra
 	}, branchName, nil
 }
 
+// NewGitWorktreeFromBranch creates a new GitWorktree for an existing branch. Unlike NewGitWorktree, no new
+// branch is created: Setup checks out branchName into the worktree as-is.
+func NewGitWorktreeFromBranch(repoPath string, sessionName string, branchName string) (*GitWorktree, error) {
+	branchName = strings.TrimSpace(branchName)
+	if branchName == "" {
+		return nil, fmt.Errorf("branch name cannot be empty")
+	}
+
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		log.ErrorLog.Printf("git worktree path abs error, falling back to repoPath %s: %s", repoPath, err)
+		absPath = repoPath
+	}
+
+	repoPath, err = findGitRepoRoot(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName(branchName), false); err != nil {
+		return nil, fmt.Errorf("branch %s does not exist: %w", branchName, err)
+	}
+
+	worktreeDir, err := getWorktreeDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	worktreePath, err := newWorktreePath(worktreeDir, sessionName, config.LoadConfig().OpaqueWorktreeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GitWorktree{
+		repoPath:       repoPath,
+		sessionName:    sessionName,
+		branchName:     branchName,
+		worktreePath:   worktreePath,
+		existingBranch: true,
+	}, nil
+}
+
+// NewGitWorktreeForImport creates a GitWorktree for a session exported from another machine. The branch has to exist
+// in the repository at repoPath, either as a local branch or as a branch on origin, in which case CreateImportBranch
+// creates a local branch from it. Nothing is changed in the repository, so that all sessions can be checked before
+// importing any. The worktree itself is created when the session is resumed.
+func NewGitWorktreeForImport(repoPath string, sessionName string, branchName string, baseCommitSHA string, existingBranch bool) (*GitWorktree, error) {
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		log.ErrorLog.Printf("git worktree path abs error, falling back to repoPath %s: %s", repoPath, err)
+		absPath = repoPath
+	}
+
+	repoPath, err = findGitRepoRoot(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	worktreeDir, err := getWorktreeDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	worktreePath, err := newWorktreePath(worktreeDir, sessionName, config.LoadConfig().OpaqueWorktreeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &GitWorktree{
+		repoPath:       repoPath,
+		sessionName:    sessionName,
+		branchName:     branchName,
+		worktreePath:   worktreePath,
+		baseCommitSHA:  baseCommitSHA,
+		existingBranch: existingBranch,
+	}
+
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName(branchName), false); err != nil {
+		if _, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branchName), false); err != nil {
+			return nil, fmt.Errorf("branch %s not found locally or on origin (push it from the other machine and fetch it here)", branchName)
+		}
+		tree.branchOnOrigin = true
+	}
+
+	// The base commit may not exist in this clone. Without it, Setup falls back to the merge base with HEAD.
+	if baseCommitSHA != "" {
+		if _, err := repo.CommitObject(plumbing.NewHash(baseCommitSHA)); err != nil {
+			tree.baseCommitSHA = ""
+		}
+	}
+
+	return tree, nil
+}
+
+// CreateImportBranch creates the local branch of an imported session from origin, if NewGitWorktreeForImport found it
+// only there. It returns true if it created the branch.
+func (g *GitWorktree) CreateImportBranch() (bool, error) {
+	if !g.branchOnOrigin {
+		return false, nil
+	}
+	if _, err := g.runGitCommand(g.repoPath, "branch", g.branchName, "origin/"+g.branchName); err != nil {
+		return false, fmt.Errorf("failed to create branch %s from origin: %w", g.branchName, err)
+	}
+	g.branchOnOrigin = false
+	return true, nil
+}
+
+// DeleteImportBranch deletes a branch created by CreateImportBranch, to roll back an import that failed. It's a copy
+// of the branch on origin, so nothing is lost.
+func (g *GitWorktree) DeleteImportBranch() error {
+	if _, err := g.runGitCommand(g.repoPath, "branch", "-D", g.branchName); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", g.branchName, err)
+	}
+	return nil
+}
+
 // GetWorktreePath returns the path to the worktree
 func (g *GitWorktree) GetWorktreePath() string {
 	return g.worktreePath
@@ -96,7 +293,33 @@ func (g *GitWorktree) GetRepoName() string {
 	return filepath.Base(g.repoPath)
 }
 
+// SetRemote sets the remote the branch is pushed to. Empty means origin.
+func (g *GitWorktree) SetRemote(remote string) {
+	g.remote = remote
+}
+
+// GetRemote returns the remote the branch is pushed to
+func (g *GitWorktree) GetRemote() string {
+	if g.remote == "" {
+		return defaultRemote
+	}
+	return g.remote
+}
+
+// CheckRemote returns an error if remote isn't configured in the repository
+func (g *GitWorktree) CheckRemote(remote string) error {
+	if _, err := g.runGitCommand(g.repoPath, "remote", "get-url", remote); err != nil {
+		return fmt.Errorf("remote %s is not configured in %s, add it with git remote add", remote, g.repoPath)
+	}
+	return nil
+}
+
 // GetBaseCommitSHA returns the base commit SHA for the worktree
 func (g *GitWorktree) GetBaseCommitSHA() string {
 	return g.baseCommitSHA
 }
+
+// IsExistingBranch returns true if the worktree was created from an imported branch
+func (g *GitWorktree) IsExistingBranch() bool {
+	return g.existingBranch
+}
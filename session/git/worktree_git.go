@@ -3,7 +3,9 @@ package git
 import (
 	"github.com/smtg-ai/claude-squad/log"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -20,10 +22,65 @@ func (g *GitWorktree) runGitCommand(path string, args ...string) (string, error)
 	return string(output), nil
 }
 
-// PushChanges commits and pushes changes in the worktree to the remote branch
+// HookError is returned when a git hook rejects a commit or a push. Output holds what the hook printed, which usually
+// explains what needs fixing.
+type HookError struct {
+	// Hook is the name of the hook that failed, e.g. "pre-push"
+	Hook string
+	// Output is the combined output of the failed git command, including the hook's own output
+	Output string
+	Err    error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("git %s hook failed: %s", e.Hook, e.Output)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// hookInstalled returns true if the repository has an executable hook with the given name. core.hooksPath is taken
+// into account.
+func (g *GitWorktree) hookInstalled(hook string) bool {
+	output, err := g.runGitCommand(g.worktreePath, "rev-parse", "--git-path", "hooks/"+hook)
+	if err != nil {
+		return false
+	}
+	path := strings.TrimSpace(output)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.worktreePath, path)
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
+
+// asHookError turns the failure of a git command into a HookError if the given hook is installed and the output
+// doesn't point at another cause. A push rejected by the remote, for example, isn't a hook failure.
+func (g *GitWorktree) asHookError(hook string, output []byte, err error) error {
+	out := strings.TrimSpace(string(output))
+	if strings.Contains(out, "[rejected]") || strings.Contains(out, "[remote rejected]") || !g.hookInstalled(hook) {
+		return nil
+	}
+	// Drop git's own summary line, it doesn't add anything to the hook's output
+	lines := strings.Split(out, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "error: failed to push some refs") {
+			kept = append(kept, line)
+		}
+	}
+	return &HookError{Hook: hook, Output: strings.TrimSpace(strings.Join(kept, "\n")), Err: err}
+}
+
+// PushChanges commits and pushes changes in the worktree to the remote branch. Branches are synced with gh when they go
+// to origin; other remotes, e.g. a personal fork, are pushed to with git alone.
 func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
-	if err := checkGHCLI(); err != nil {
-		return err
+	remote := g.GetRemote()
+	if remote == defaultRemote {
+		if err := checkGHCLI(); err != nil {
+			return err
+		}
 	}
 
 	// Check if there are any changes to commit
@@ -33,38 +90,33 @@ func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
 	}
 
 	if isDirty {
-		// Stage all changes
-		if _, err := g.runGitCommand(g.worktreePath, "add", "."); err != nil {
-			log.ErrorLog.Print(err)
-			return fmt.Errorf("failed to stage changes: %w", err)
-		}
-
-		// Create commit
-		if _, err := g.runGitCommand(g.worktreePath, "commit", "-m", commitMessage, "--no-verify"); err != nil {
-			log.ErrorLog.Print(err)
-			return fmt.Errorf("failed to commit changes: %w", err)
+		if err := g.CommitChanges(commitMessage); err != nil {
+			return err
 		}
 	}
 
-	// First push the branch to remote to ensure it exists
-	pushCmd := exec.Command("gh", "repo", "sync", "--source", "-b", g.branchName)
-	pushCmd.Dir = g.worktreePath
-	if err := pushCmd.Run(); err != nil {
-		// If sync fails, try creating the branch on remote first
-		gitPushCmd := exec.Command("git", "push", "-u", "origin", g.branchName)
-		gitPushCmd.Dir = g.worktreePath
-		if pushOutput, pushErr := gitPushCmd.CombinedOutput(); pushErr != nil {
-			log.ErrorLog.Print(pushErr)
-			return fmt.Errorf("failed to push branch: %s (%w)", pushOutput, pushErr)
+	if remote != defaultRemote {
+		if err := g.push(remote); err != nil {
+			return err
+		}
+	} else {
+		// First push the branch to remote to ensure it exists
+		pushCmd := exec.Command("gh", "repo", "sync", "--source", "-b", g.branchName)
+		pushCmd.Dir = g.worktreePath
+		if err := pushCmd.Run(); err != nil {
+			// If sync fails, try creating the branch on remote first
+			if err := g.push(remote); err != nil {
+				return err
+			}
 		}
-	}
 
-	// Now sync with remote
-	syncCmd := exec.Command("gh", "repo", "sync", "-b", g.branchName)
-	syncCmd.Dir = g.worktreePath
-	if output, err := syncCmd.CombinedOutput(); err != nil {
-		log.ErrorLog.Print(err)
-		return fmt.Errorf("failed to sync changes: %s (%w)", output, err)
+		// Now sync with remote
+		syncCmd := exec.Command("gh", "repo", "sync", "-b", g.branchName)
+		syncCmd.Dir = g.worktreePath
+		if output, err := syncCmd.CombinedOutput(); err != nil {
+			log.ErrorLog.Print(err)
+			return fmt.Errorf("failed to sync changes: %s (%w)", output, err)
+		}
 	}
 
 	// Open the branch in the browser
@@ -78,6 +130,175 @@ func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
 	return nil
 }
 
+// push pushes the branch to remote and sets it as the upstream
+func (g *GitWorktree) push(remote string) error {
+	gitPushCmd := exec.Command("git", "push", "-u", remote, g.branchName)
+	gitPushCmd.Dir = g.worktreePath
+	if pushOutput, pushErr := gitPushCmd.CombinedOutput(); pushErr != nil {
+		log.ErrorLog.Print(pushErr)
+		if hookErr := g.asHookError("pre-push", pushOutput, pushErr); hookErr != nil {
+			return hookErr
+		}
+		return fmt.Errorf("failed to push branch to %s: %s (%w)", remote, pushOutput, pushErr)
+	}
+	return nil
+}
+
+// PushPreview is what PushChanges would commit and push, without changing anything
+type PushPreview struct {
+	// Files are the uncommitted changes that would be committed, in git status --porcelain format, e.g. "M  main.go"
+	// or "?? new.go"
+	Files []string
+	// Remote is the remote the branch is pushed to
+	Remote string
+	// RemoteURL is the URL of the remote, empty if it isn't configured
+	RemoteURL string
+	// Branch is the branch that is pushed
+	Branch string
+	// NewBranch is true if the remote doesn't have the branch yet, as far as the last fetch knows
+	NewBranch bool
+	// Unpushed is the number of commits on the branch that the remote doesn't have, not counting the commit of the
+	// uncommitted changes
+	Unpushed int
+}
+
+// Target returns where the branch is pushed to, e.g. "origin/session/foo"
+func (p PushPreview) Target() string {
+	return p.Remote + "/" + p.Branch
+}
+
+// PreviewChanges returns what PushChanges would commit and push. It only reads the repository and doesn't contact
+// the remote, so whether the branch exists there is as of the last fetch.
+func (g *GitWorktree) PreviewChanges() (PushPreview, error) {
+	remote := g.GetRemote()
+	preview := PushPreview{Remote: remote, Branch: g.branchName}
+
+	output, err := g.runGitCommand(g.worktreePath, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return PushPreview{}, fmt.Errorf("failed to check worktree status: %w", err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			preview.Files = append(preview.Files, line)
+		}
+	}
+
+	if url, err := g.runGitCommand(g.worktreePath, "remote", "get-url", remote); err == nil {
+		preview.RemoteURL = strings.TrimSpace(url)
+	}
+
+	// Count the commits from the remote branch, or from the commit the session started at if it was never pushed
+	from := remote + "/" + g.branchName
+	if _, err := g.runGitCommand(g.worktreePath, "show-ref", "--verify", "--quiet", "refs/remotes/"+from); err != nil {
+		preview.NewBranch = true
+		from = g.baseCommitSHA
+	}
+	if from != "" {
+		count, err := g.runGitCommand(g.worktreePath, "rev-list", "--count", from+".."+g.branchName)
+		if err != nil {
+			return PushPreview{}, fmt.Errorf("failed to count unpushed commits: %w", err)
+		}
+		if _, err := fmt.Sscanf(strings.TrimSpace(count), "%d", &preview.Unpushed); err != nil {
+			return PushPreview{}, fmt.Errorf("failed to parse rev-list output %q: %w", count, err)
+		}
+	}
+	return preview, nil
+}
+
+// CommitChanges stages and commits all changes in the worktree, skipping the commit hooks
+func (g *GitWorktree) CommitChanges(commitMessage string) error {
+	if _, err := g.runGitCommand(g.worktreePath, "add", "."); err != nil {
+		log.ErrorLog.Print(err)
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if _, err := g.runGitCommand(g.worktreePath, "commit", "-m", commitMessage, "--no-verify"); err != nil {
+		log.ErrorLog.Print(err)
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return nil
+}
+
+// MergeMode is how MergeIntoBase merges the branch, matching the merge_mode config
+type MergeMode string
+
+const (
+	// MergeDefault fast-forwards if possible and creates a merge commit otherwise
+	MergeDefault MergeMode = ""
+	// MergeFastForwardOnly only fast-forwards, and fails if the base has commits the branch doesn't have
+	MergeFastForwardOnly MergeMode = "ff-only"
+	// MergeNoFastForward always creates a merge commit
+	MergeNoFastForward MergeMode = "no-ff"
+)
+
+// MergeConflictError is returned by MergeIntoBase when the merge has conflicts. The merge is left in progress in the
+// main repository, to be resolved by hand or aborted with AbortMerge.
+type MergeConflictError struct {
+	Branch string
+	Base   string
+	// Files are the files with conflicts
+	Files []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("merging %s into %s has conflicts in %s. Resolve them in the main repository and commit, or "+
+		"abort the merge", e.Branch, e.Base, strings.Join(e.Files, ", "))
+}
+
+// MergeIntoBase merges the branch into base in the main repository and returns the name of the base branch. base has
+// to be checked out there already; empty merges into whatever branch is checked out. Uncommitted changes in the
+// worktree aren't merged, commit them first.
+func (g *GitWorktree) MergeIntoBase(base string, mode MergeMode) (string, error) {
+	var modeArgs []string
+	switch mode {
+	case MergeDefault:
+	case MergeFastForwardOnly, MergeNoFastForward:
+		modeArgs = []string{"--" + string(mode)}
+	default:
+		return "", fmt.Errorf("unknown merge mode %q, use %q or %q", mode, MergeFastForwardOnly, MergeNoFastForward)
+	}
+
+	if state, err := RepoState(g.repoPath); err != nil {
+		return "", err
+	} else if state.InProgress() {
+		return "", fmt.Errorf("a %s is in progress in %s, finish it before merging", state, g.repoPath)
+	}
+	output, err := g.runGitCommand(g.repoPath, "branch", "--show-current")
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch: %w", err)
+	}
+	current := strings.TrimSpace(output)
+	switch {
+	case current == "":
+		return "", fmt.Errorf("HEAD is detached in %s, check out the branch to merge into first", g.repoPath)
+	case base != "" && current != base:
+		return "", fmt.Errorf("%s is checked out in %s, check out %s to merge into it", current, g.repoPath, base)
+	case current == g.branchName:
+		return "", fmt.Errorf("%s is checked out in %s, check out the branch to merge into first", current, g.repoPath)
+	}
+
+	args := append(append([]string{"merge", "--no-edit"}, modeArgs...), g.branchName)
+	if _, err := g.runGitCommand(g.repoPath, args...); err != nil {
+		conflicts, diffErr := g.runGitCommand(g.repoPath, "diff", "--name-only", "--diff-filter=U")
+		if diffErr == nil && strings.TrimSpace(conflicts) != "" {
+			conflictErr := &MergeConflictError{Branch: g.branchName, Base: current}
+			for _, file := range strings.Split(strings.TrimSpace(conflicts), "\n") {
+				conflictErr.Files = append(conflictErr.Files, file)
+			}
+			return current, conflictErr
+		}
+		return current, fmt.Errorf("failed to merge %s into %s: %w", g.branchName, current, err)
+	}
+	return current, nil
+}
+
+// AbortMerge aborts a merge in progress in the main repository, e.g. after MergeIntoBase had conflicts
+func (g *GitWorktree) AbortMerge() error {
+	if _, err := g.runGitCommand(g.repoPath, "merge", "--abort"); err != nil {
+		return fmt.Errorf("failed to abort merge: %w", err)
+	}
+	return nil
+}
+
 // IsDirty checks if the worktree has uncommitted changes
 func (g *GitWorktree) IsDirty() (bool, error) {
 	output, err := g.runGitCommand(g.worktreePath, "status", "--porcelain")
@@ -87,6 +308,48 @@ func (g *GitWorktree) IsDirty() (bool, error) {
 	return len(output) > 0, nil
 }
 
+// DivergenceInfo describes how the worktree branch relates to another ref
+type DivergenceInfo struct {
+	// Ahead is the number of commits on the branch that are not on the ref
+	Ahead int
+	// Behind is the number of commits on the ref that are not on the branch
+	Behind int
+}
+
+// IsBehind returns true if the ref has commits the branch doesn't have
+func (d DivergenceInfo) IsBehind() bool {
+	return d.Behind > 0
+}
+
+// IsDiverged returns true if both the branch and the ref have commits the other doesn't have
+func (d DivergenceInfo) IsDiverged() bool {
+	return d.Ahead > 0 && d.Behind > 0
+}
+
+// Divergence compares the worktree branch against ref. The ref is resolved in the main repository, so "HEAD" is
+// the branch currently checked out there rather than the worktree's own HEAD.
+func (g *GitWorktree) Divergence(ref string) (DivergenceInfo, error) {
+	output, err := g.runGitCommand(g.repoPath, "rev-list", "--left-right", "--count", g.branchName+"..."+ref)
+	if err != nil {
+		return DivergenceInfo{}, fmt.Errorf("failed to compare branch %s with %s: %w", g.branchName, ref, err)
+	}
+
+	var info DivergenceInfo
+	if _, err := fmt.Sscanf(strings.TrimSpace(output), "%d %d", &info.Ahead, &info.Behind); err != nil {
+		return DivergenceInfo{}, fmt.Errorf("failed to parse rev-list output %q: %w", output, err)
+	}
+	return info, nil
+}
+
+// IsBehind returns the number of commits on ref that are missing from the worktree branch
+func (g *GitWorktree) IsBehind(ref string) (int, error) {
+	info, err := g.Divergence(ref)
+	if err != nil {
+		return 0, err
+	}
+	return info.Behind, nil
+}
+
 // IsBranchCheckedOut checks if the instance branch is currently checked out
 func (g *GitWorktree) IsBranchCheckedOut() (bool, error) {
 	output, err := g.runGitCommand(g.repoPath, "branch", "--show-current")
@@ -96,6 +359,21 @@ func (g *GitWorktree) IsBranchCheckedOut() (bool, error) {
 	return strings.TrimSpace(string(output)) == g.branchName, nil
 }
 
+// DeleteBranch force deletes the branch from the main repository, including commits that were never pushed or
+// merged. The worktree must be removed first. Deleting a branch that doesn't exist is not an error.
+func (g *GitWorktree) DeleteBranch() error {
+	if _, err := g.runGitCommand(g.repoPath, "show-ref", "--verify", "--quiet", "refs/heads/"+g.branchName); err != nil {
+		return nil
+	}
+	tip := g.revParse(g.repoPath, "refs/heads/"+g.branchName)
+	if _, err := g.runGitCommand(g.repoPath, "branch", "-D", g.branchName); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", g.branchName, err)
+	}
+	g.recordDestructive(OpDeleteBranch, tip)
+	log.InfoLog.Printf("deleted branch %s", g.branchName)
+	return nil
+}
+
 // OpenBranchURL opens the branch URL in the default browser
 func (g *GitWorktree) OpenBranchURL() error {
 	// Check if GitHub CLI is available
@@ -103,7 +381,14 @@ func (g *GitWorktree) OpenBranchURL() error {
 		return err
 	}
 
-	cmd := exec.Command("gh", "browse", "--branch", g.branchName)
+	args := []string{"browse", "--branch", g.branchName}
+	if remote := g.GetRemote(); remote != defaultRemote {
+		// gh browses origin unless it's told which repository the branch went to
+		if url, err := g.runGitCommand(g.repoPath, "remote", "get-url", remote); err == nil {
+			args = append(args, "--repo", strings.TrimSpace(url))
+		}
+	}
+	cmd := exec.Command("gh", args...)
 	cmd.Dir = g.worktreePath
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to open branch URL: %w", err)
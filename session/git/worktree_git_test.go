@@ -0,0 +1,127 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestPreviewChanges(t *testing.T) {
+	repo := newTestRepo(t, "one\n")
+	base := runGit(t, repo, "rev-parse", "HEAD")
+	runGit(t, repo, "checkout", "-q", "-b", "session/test")
+	if err := os.WriteFile(filepath.Join(repo, "file.txt"), []byte("two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "commit", "-q", "-am", "change")
+	if err := os.MkdirAll(filepath.Join(repo, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "dir", "new.txt"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	worktree := NewGitWorktreeFromStorage(repo, repo, "test", "session/test", base, false)
+	preview, err := worktree.PreviewChanges()
+	if err != nil {
+		t.Fatalf("PreviewChanges() error = %v", err)
+	}
+	if len(preview.Files) != 1 || preview.Files[0] != "?? dir/new.txt" {
+		t.Errorf("PreviewChanges() files = %q, want the untracked file", preview.Files)
+	}
+	if !preview.NewBranch || preview.Unpushed != 1 {
+		t.Errorf("PreviewChanges() = new %v, %d unpushed, want a new branch with 1 commit", preview.NewBranch, preview.Unpushed)
+	}
+	if preview.Target() != "origin/session/test" || preview.RemoteURL != "" {
+		t.Errorf("PreviewChanges() target = %s (%q), want origin/session/test without a URL", preview.Target(), preview.RemoteURL)
+	}
+
+	// Nothing was committed
+	if status := runGit(t, repo, "status", "--porcelain"); status == "" {
+		t.Error("PreviewChanges() changed the worktree")
+	}
+}
+
+func TestMergeIntoBase(t *testing.T) {
+	repo := newTestRepo(t, "one\n")
+	commit := func(content string, message string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, "file.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, repo, "commit", "-q", "-am", message)
+	}
+	base := runGit(t, repo, "branch", "--show-current")
+	// Merges run git directly, without the identity runGit passes
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@localhost")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@localhost")
+
+	runGit(t, repo, "checkout", "-q", "-b", "session/ff")
+	commit("two\n", "fast-forward")
+	runGit(t, repo, "checkout", "-q", base)
+	worktree := NewGitWorktreeFromStorage(repo, filepath.Join(t.TempDir(), "removed"), "ff", "session/ff", "", false)
+
+	if _, err := worktree.MergeIntoBase("not-checked-out", MergeDefault); err == nil {
+		t.Error("MergeIntoBase() into a branch that isn't checked out succeeded")
+	}
+	if _, err := worktree.MergeIntoBase("", "squash"); err == nil {
+		t.Error("MergeIntoBase() with an unknown mode succeeded")
+	}
+	merged, err := worktree.MergeIntoBase("", MergeFastForwardOnly)
+	if err != nil {
+		t.Fatalf("MergeIntoBase() error = %v", err)
+	}
+	if merged != base || runGit(t, repo, "show", "HEAD:file.txt") != "two" {
+		t.Errorf("MergeIntoBase() merged into %s, want %s fast-forwarded to the branch", merged, base)
+	}
+
+	// Conflicting changes leave the merge in progress until it's aborted
+	runGit(t, repo, "checkout", "-q", "-b", "session/conflict")
+	commit("three\n", "conflict on the branch")
+	runGit(t, repo, "checkout", "-q", base)
+	commit("four\n", "conflict on the base")
+	worktree = NewGitWorktreeFromStorage(repo, filepath.Join(t.TempDir(), "removed"), "conflict", "session/conflict", "", false)
+	_, err = worktree.MergeIntoBase(base, MergeDefault)
+	conflictErr, ok := err.(*MergeConflictError)
+	if !ok {
+		t.Fatalf("MergeIntoBase() error = %v, want a MergeConflictError", err)
+	}
+	if len(conflictErr.Files) != 1 || conflictErr.Files[0] != "file.txt" {
+		t.Errorf("MergeConflictError files = %q, want file.txt", conflictErr.Files)
+	}
+	if state, _ := RepoState(repo); state != RepoMerging {
+		t.Errorf("RepoState() = %s after a conflict, want %s", state, RepoMerging)
+	}
+	if err := worktree.AbortMerge(); err != nil {
+		t.Fatalf("AbortMerge() error = %v", err)
+	}
+	if state, _ := RepoState(repo); state != RepoClean {
+		t.Errorf("RepoState() = %s after aborting, want %s", state, RepoClean)
+	}
+}
+
+func TestPushChangesToRemote(t *testing.T) {
+	repo := newTestRepo(t, "one\n")
+	fork := t.TempDir()
+	runGit(t, fork, "init", "-q", "--bare")
+	runGit(t, repo, "remote", "add", "fork", fork)
+	runGit(t, repo, "branch", "session/test")
+
+	worktree := NewGitWorktreeFromStorage(repo, repo, "test", "session/test", "", false)
+	if err := worktree.CheckRemote("upstream"); err == nil {
+		t.Errorf("CheckRemote(upstream) = nil, want an error for a remote that isn't configured")
+	}
+	if err := worktree.CheckRemote("fork"); err != nil {
+		t.Fatalf("CheckRemote(fork) error = %v", err)
+	}
+	worktree.SetRemote("fork")
+	if err := worktree.PushChanges("update", false); err != nil {
+		t.Fatalf("PushChanges() error = %v", err)
+	}
+	if out, err := exec.Command("git", "-C", fork, "rev-parse", "--verify", "refs/heads/session/test").CombinedOutput(); err != nil {
+		t.Errorf("branch not pushed to the fork: %s (%v)", out, err)
+	}
+}
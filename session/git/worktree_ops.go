@@ -12,8 +12,75 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
-// Setup creates a new worktree for the session
+// Setup creates a new worktree for the session. If that fails because of stale worktree administrative files, the
+// worktree links are repaired and setup is tried once more. If the disk is full, the partial worktree is removed and
+// a DiskFullError is returned.
 func (g *GitWorktree) Setup() error {
+	err := g.setup()
+	if err != nil && isStaleWorktreeError(err) {
+		log.WarningLog.Printf("worktree setup failed because of stale worktree files, repairing and retrying: %v", err)
+		if repairErr := g.Repair(); repairErr != nil {
+			return fmt.Errorf("%w (repair failed: %v)", err, repairErr)
+		}
+		err = g.setup()
+	}
+	if err != nil && isDiskFullError(err) {
+		return g.diskFull(err)
+	}
+	return err
+}
+
+// diskFull removes what was written of the worktree before the disk filled up, so that setting up again works once
+// space is freed, and returns a DiskFullError for err. The branch is kept: it may have existed before.
+func (g *GitWorktree) diskFull(err error) error {
+	log.ErrorLog.Printf("disk full while setting up worktree %s: %v", g.worktreePath, err)
+	if _, statErr := os.Stat(g.worktreePath); statErr == nil {
+		if removeErr := os.RemoveAll(g.worktreePath); removeErr != nil {
+			log.ErrorLog.Printf("failed to remove partial worktree %s: %v", g.worktreePath, removeErr)
+		}
+	}
+	if pruneErr := g.Prune(); pruneErr != nil {
+		log.ErrorLog.Print(pruneErr)
+	}
+	return &DiskFullError{Path: g.worktreePath, Free: describeFreeSpace(g.worktreePath), Err: err}
+}
+
+// staleWorktreeErrors are the messages git prints when .git/worktrees still has entries for worktrees whose
+// directory is gone
+var staleWorktreeErrors = []string{
+	"missing but already registered worktree",
+	"missing but locked worktree",
+	"is already checked out at",
+	"is already used by worktree at",
+	"is not a working tree",
+}
+
+// isStaleWorktreeError returns true if err looks like it was caused by stale worktree administrative files, which
+// Repair can fix
+func isStaleWorktreeError(err error) bool {
+	for _, msg := range staleWorktreeErrors {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Repair fixes stale worktree administrative files in .git/worktrees, e.g. after a worktree directory was deleted
+// by hand. Entries for worktrees whose directory is gone are pruned, and the links between the repository and this
+// worktree are repaired if the worktree still exists.
+func (g *GitWorktree) Repair() error {
+	// Repair before pruning: if this worktree was moved, its entry points to a directory that's gone and would be pruned
+	if _, err := os.Stat(g.worktreePath); err == nil {
+		if _, err := g.runGitCommand(g.repoPath, "worktree", "repair", g.worktreePath); err != nil {
+			return fmt.Errorf("failed to repair worktree %s: %w", g.worktreePath, err)
+		}
+	}
+	return g.Prune()
+}
+
+// setup creates a new worktree for the session
+func (g *GitWorktree) setup() error {
 	// Check if branch exists first
 	repo, err := git.PlainOpen(g.repoPath)
 	if err != nil {
@@ -30,6 +97,22 @@ func (g *GitWorktree) Setup() error {
 	return g.SetupNewWorktree()
 }
 
+// CompleteSetup finishes the setup of a worktree that was left without a base commit, e.g. because setup was
+// interrupted or the base commit is gone from the repository. An existing worktree is kept as it is, along with its
+// changes, and the base commit becomes the point where the branch forked from HEAD. A missing worktree is set up again.
+func (g *GitWorktree) CompleteSetup() error {
+	if _, err := os.Stat(g.worktreePath); err != nil {
+		g.baseCommitSHA = ""
+		return g.Setup()
+	}
+	output, err := g.runGitCommand(g.repoPath, "merge-base", "HEAD", g.branchName)
+	if err != nil {
+		return fmt.Errorf("failed to find base commit for branch %s: %w", g.branchName, err)
+	}
+	g.baseCommitSHA = strings.TrimSpace(output)
+	return nil
+}
+
 // SetupFromExistingBranch creates a worktree from an existing branch
 func (g *GitWorktree) SetupFromExistingBranch() error {
 	// Ensure worktrees directory exists
@@ -41,6 +124,15 @@ func (g *GitWorktree) SetupFromExistingBranch() error {
 	// Clean up any existing worktree first
 	_, _ = g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath) // Ignore error if worktree doesn't exist
 
+	// Imported branches have no base commit yet. Diff against the point where the branch forked from HEAD.
+	if g.baseCommitSHA == "" {
+		output, err := g.runGitCommand(g.repoPath, "merge-base", "HEAD", g.branchName)
+		if err != nil {
+			return fmt.Errorf("failed to find base commit for branch %s: %w", g.branchName, err)
+		}
+		g.baseCommitSHA = strings.TrimSpace(output)
+	}
+
 	// Create a new worktree from the existing branch
 	if _, err := g.runGitCommand(g.repoPath, "worktree", "add", g.worktreePath, g.branchName); err != nil {
 		return fmt.Errorf("failed to create worktree from branch %s: %w", g.branchName, err)
@@ -100,9 +192,12 @@ func (g *GitWorktree) Cleanup() error {
 
 	// Check if worktree path exists before attempting removal
 	if _, err := os.Stat(g.worktreePath); err == nil {
+		head := g.revParse(g.worktreePath, "HEAD")
 		// Remove the worktree using git command
 		if _, err := g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath); err != nil {
 			errs = append(errs, err)
+		} else {
+			g.recordDestructive(OpRemoveWorktree, head)
 		}
 	} else if !os.IsNotExist(err) {
 		// Only append error if it's not a "not exists" error
@@ -118,10 +213,14 @@ func (g *GitWorktree) Cleanup() error {
 
 	branchRef := plumbing.NewBranchReferenceName(g.branchName)
 
-	// Check if branch exists before attempting removal
-	if _, err := repo.Reference(branchRef, false); err == nil {
+	// Check if branch exists before attempting removal. Imported branches are left in place.
+	if g.existingBranch {
+		log.InfoLog.Printf("keeping imported branch %s", g.branchName)
+	} else if ref, err := repo.Reference(branchRef, false); err == nil {
 		if err := repo.Storer.RemoveReference(branchRef); err != nil {
 			errs = append(errs, fmt.Errorf("failed to remove branch %s: %w", g.branchName, err))
+		} else {
+			g.recordDestructive(OpDeleteBranch, ref.Hash().String())
 		}
 	} else if err != plumbing.ErrReferenceNotFound {
 		errs = append(errs, fmt.Errorf("error checking branch %s existence: %w", g.branchName, err))
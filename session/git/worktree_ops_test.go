@@ -0,0 +1,123 @@
+package git
+
+import (
+	"github.com/smtg-ai/claude-squad/log"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	log.Initialize(false)
+	defer log.Close()
+	os.Exit(m.Run())
+}
+
+func TestIsStaleWorktreeError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "branch checked out at a deleted worktree",
+			err:  errors.New("fatal: 'session/test' is already checked out at '/tmp/gone'"),
+			want: true,
+		},
+		{
+			name: "missing worktree still registered",
+			err:  errors.New("fatal: '/tmp/gone' is a missing but already registered worktree;\nuse 'add -f' to override, or 'prune' or 'remove' to clear"),
+			want: true,
+		},
+		{
+			name: "unrelated error",
+			err:  errors.New("fatal: invalid reference: session/test"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isStaleWorktreeError(tt.err); got != tt.want {
+				t.Errorf("isStaleWorktreeError(%q) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetupRepairsStaleWorktree(t *testing.T) {
+	repo := newTestRepo(t, "one\n")
+	head := runGit(t, repo, "rev-parse", "HEAD")
+
+	// The branch is still checked out at a worktree whose directory was deleted by hand
+	gone := filepath.Join(t.TempDir(), "gone")
+	runGit(t, repo, "worktree", "add", "-q", "-b", "session/test", gone)
+	if err := os.RemoveAll(gone); err != nil {
+		t.Fatal(err)
+	}
+
+	worktreePath := filepath.Join(t.TempDir(), "worktree")
+	worktree := NewGitWorktreeFromStorage(repo, worktreePath, "test", "session/test", head, false)
+	if err := worktree.setup(); err == nil || !isStaleWorktreeError(err) {
+		t.Fatalf("first setup() error = %v, want a stale worktree error", err)
+	}
+
+	if err := worktree.Setup(); err != nil {
+		t.Fatalf("Setup() error = %v, want it to repair and retry", err)
+	}
+	if _, err := os.Stat(filepath.Join(worktreePath, "file.txt")); err != nil {
+		t.Errorf("worktree wasn't set up: %v", err)
+	}
+	if list := runGit(t, repo, "worktree", "list"); strings.Contains(list, gone) {
+		t.Errorf("worktree list = %q, want the deleted worktree pruned", list)
+	}
+}
+
+func TestSetupFailsWhenRepairDoesNotHelp(t *testing.T) {
+	repo := newTestRepo(t, "one\n")
+	head := runGit(t, repo, "rev-parse", "HEAD")
+
+	// The branch is checked out in the repository itself, which repairing doesn't change
+	runGit(t, repo, "checkout", "-q", "-b", "session/test")
+
+	worktree := NewGitWorktreeFromStorage(repo, filepath.Join(t.TempDir(), "worktree"), "test", "session/test", head, false)
+	err := worktree.Setup()
+	if err == nil || !isStaleWorktreeError(err) {
+		t.Fatalf("Setup() error = %v, want the stale worktree error from the retry", err)
+	}
+}
+
+func TestRepair(t *testing.T) {
+	repo := newTestRepo(t, "one\n")
+	head := runGit(t, repo, "rev-parse", "HEAD")
+
+	// One worktree was deleted and this one was moved, so the repository's links to both are stale
+	gone := filepath.Join(t.TempDir(), "gone")
+	runGit(t, repo, "worktree", "add", "-q", "-b", "session/gone", gone)
+	if err := os.RemoveAll(gone); err != nil {
+		t.Fatal(err)
+	}
+	moved := filepath.Join(t.TempDir(), "moved")
+	runGit(t, repo, "worktree", "add", "-q", "-b", "session/test", moved)
+	worktreePath := filepath.Join(t.TempDir(), "worktree")
+	if err := os.Rename(moved, worktreePath); err != nil {
+		t.Fatal(err)
+	}
+
+	worktree := NewGitWorktreeFromStorage(repo, worktreePath, "test", "session/test", head, false)
+	if err := worktree.Repair(); err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+
+	list := runGit(t, repo, "worktree", "list")
+	if strings.Contains(list, gone) {
+		t.Errorf("worktree list = %q, want the deleted worktree pruned", list)
+	}
+	if !strings.Contains(list, worktreePath) {
+		t.Errorf("worktree list = %q, want the moved worktree linked again", list)
+	}
+	if branch := runGit(t, worktreePath, "branch", "--show-current"); branch != "session/test" {
+		t.Errorf("moved worktree is on %q, want session/test", branch)
+	}
+}
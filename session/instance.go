@@ -1,20 +1,23 @@
 package session
 
 import (
+	"github.com/smtg-ai/claude-squad/humanize"
 	"github.com/smtg-ai/claude-squad/log"
 	"github.com/smtg-ai/claude-squad/session/git"
 	"github.com/smtg-ai/claude-squad/session/tmux"
 	"path/filepath"
 
+	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
-
-	"github.com/atotto/clipboard"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Status int
@@ -30,6 +33,22 @@ const (
 	Paused
 )
 
+// String returns the lowercase name of the status, e.g. "ready"
+func (s Status) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Ready:
+		return "ready"
+	case Loading:
+		return "loading"
+	case Paused:
+		return "paused"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
 // Instance is a running instance of claude code.
 type Instance struct {
 	// Mutex for thread-safe access to continuous mode fields
@@ -45,6 +64,9 @@ type Instance struct {
 	Status Status
 	// Program is the program to run in the instance.
 	Program string
+	// command is Program split into the command and its args, saved so that restarts keep the args. Nil for
+	// instances saved before it existed.
+	command *ProgramCommand
 	// Height is the height of the instance.
 	Height int
 	// Width is the width of the instance.
@@ -55,11 +77,39 @@ type Instance struct {
 	UpdatedAt time.Time
 	// AutoYes is true if the instance should automatically press enter when prompted.
 	AutoYes bool
-	// Prompt is the initial prompt to pass to the instance on startup
+	// Prompt is the initial prompt to pass to the instance on startup. It's queued after the startup prompt when the
+	// instance is started for the first time.
 	Prompt string
+	// Tag is a color used to visually group instances. Empty if untagged.
+	Tag string
+	// Remote is the git remote the branch is pushed to, e.g. a personal fork. Empty means origin.
+	Remote string
+	// TailOutput is true if the output of the instance is appended to its output log as it changes
+	TailOutput bool
+	// outputLog writes the output while TailOutput is set. It's created on the first write.
+	outputLog *outputLog
+	// maxTitleLength is the maximum number of characters SetTitle accepts. 0 means no limit.
+	maxTitleLength int
+	// cloneURL is the remote repository cloned into Path when the instance is first started, empty for local ones
+	cloneURL string
 
 	// DiffStats stores the current git diff statistics
 	diffStats *git.DiffStats
+	// divergence stores how the branch relates to the branch checked out in the main repository, as of divergenceAt
+	divergence   *git.DivergenceInfo
+	divergenceAt time.Time
+	// checkedOut is true if the branch is checked out in the main repository, as of checkedOutAt
+	checkedOut   bool
+	checkedOutAt time.Time
+	// setupIncompleteSince is when the worktree was first found without a base commit, zero if it has one
+	setupIncompleteSince time.Time
+	// viewedDiffHash is the hash of the diff when the user last viewed the instance, empty if they never did
+	viewedDiffHash string
+	// diffHash caches the hash of diffHashOf, so that the list doesn't hash the diff on every render
+	diffHash   string
+	diffHashOf *git.DiffStats
+	// lastAutoCommit is when AutoCommit last checked the worktree for changes
+	lastAutoCommit time.Time
 
 	// Watchdog functionality
 	// LastActivityTime tracks when the session last had meaningful activity
@@ -76,10 +126,30 @@ type Instance struct {
 	ContinuousModeDuration time.Duration
 	// LastContentHash tracks content changes to detect stalls
 	lastContentHash string
+	// continueSentAt is when the watchdog last sent a continue command
+	continueSentAt time.Time
+	// sessionGone is true if the last ApplyPaneCheck found that the tmux session doesn't exist anymore
+	sessionGone bool
+	// lastBusyIndicator is the busy indicator lines the watchdog saw last, empty if there were none
+	lastBusyIndicator string
 	// RestartAttempts tracks how many times we've tried to restart this session
 	RestartAttempts int
 	// LastRestartTime tracks when we last attempted a restart
 	LastRestartTime time.Time
+	// restarting is true while a manual restart is in progress. Guarded by mu.
+	restarting bool
+	// failedPings is how many pings in a row the program didn't react to, without producing output since. Guarded by
+	// mu.
+	failedPings int
+	// compacting is true while Claude Code is compacting the conversation, as of the last stall check
+	compacting bool
+	// promptSince is when a prompt that AutoYes doesn't answer was first seen, zero if there's none
+	promptSince time.Time
+	// promptQueue holds prompts waiting to be sent, one each time the instance becomes ready. Guarded by mu.
+	promptQueue []string
+	// startupPrompt is queued when the instance is started for the first time. startup_prompt from the config is used
+	// if it's empty.
+	startupPrompt string
 	// Cache for formatted duration string
 	cachedDurationString string
 	cachedDurationTime   time.Time
@@ -93,6 +163,107 @@ type Instance struct {
 	gitWorktree *git.GitWorktree
 }
 
+// queueInitialPrompts puts the startup prompt and then the initial prompt at the front of the queue, so that they're
+// sent as soon as the program is ready for input rather than typed into it while it's still starting
+func (i *Instance) queueInitialPrompts() {
+	if strings.TrimSpace(i.Prompt) != "" {
+		i.EnqueuePromptFront(i.Prompt)
+	}
+	prompt := i.startupPrompt
+	if strings.TrimSpace(prompt) == "" {
+		prompt = currentConfig().StartupPrompt
+	}
+	if strings.TrimSpace(prompt) != "" {
+		i.EnqueuePromptFront(prompt)
+	}
+}
+
+// EnqueuePrompt adds a prompt to the queue. Queued prompts are sent one at a time, each time the instance becomes
+// ready for input.
+func (i *Instance) EnqueuePrompt(prompt string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.promptQueue = append(i.promptQueue, prompt)
+}
+
+// EnqueuePromptFront puts a prompt back at the front of the queue, e.g. after failing to send it
+func (i *Instance) EnqueuePromptFront(prompt string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.promptQueue = append([]string{prompt}, i.promptQueue...)
+}
+
+// DequeuePrompt removes and returns the next queued prompt. It returns false if the queue is empty.
+func (i *Instance) DequeuePrompt() (string, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if len(i.promptQueue) == 0 {
+		return "", false
+	}
+	prompt := i.promptQueue[0]
+	i.promptQueue = i.promptQueue[1:]
+	return prompt, true
+}
+
+// SendQueuedPrompt sends the next queued prompt, if any, and marks the instance as running. Callers send it once the
+// instance becomes ready for input: the TUI and the daemon both do. In safe mode, prompts stay queued. It returns
+// true if a prompt was sent; one that fails to send is put back at the front of the queue.
+func (i *Instance) SendQueuedPrompt() (bool, error) {
+	if SafeMode() {
+		return false, nil
+	}
+	prompt, ok := i.DequeuePrompt()
+	if !ok {
+		return false, nil
+	}
+	if err := i.SendPrompt(prompt); err != nil {
+		i.EnqueuePromptFront(prompt)
+		return false, err
+	}
+	// The user queued it, so it's their input
+	i.ResetStallCount()
+	// The instance is about to start working. Don't wait for the next check to notice.
+	i.SetStatus(Running)
+	return true, nil
+}
+
+// QueuedPrompts returns a copy of the prompts waiting to be sent
+func (i *Instance) QueuedPrompts() []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if len(i.promptQueue) == 0 {
+		return nil
+	}
+	return append([]string(nil), i.promptQueue...)
+}
+
+// QueueLength returns the number of prompts waiting to be sent
+func (i *Instance) QueueLength() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.promptQueue)
+}
+
+// Tags are the colors an instance can be tagged with, in the order CycleTag goes through them
+var Tags = []string{"red", "orange", "yellow", "green", "blue", "purple"}
+
+// CycleTag moves the instance to the next tag, going back to untagged after the last one
+func (i *Instance) CycleTag() string {
+	next := ""
+	if i.Tag == "" {
+		next = Tags[0]
+	} else {
+		for idx, tag := range Tags {
+			if tag == i.Tag && idx+1 < len(Tags) {
+				next = Tags[idx+1]
+				break
+			}
+		}
+	}
+	i.Tag = next
+	return next
+}
+
 // ToInstanceData converts an Instance to its serializable form
 func (i *Instance) ToInstanceData() InstanceData {
 	data := InstanceData{
@@ -106,6 +277,10 @@ func (i *Instance) ToInstanceData() InstanceData {
 		UpdatedAt: time.Now(),
 		Program:   i.Program,
 		AutoYes:   i.AutoYes,
+		Tag:       i.Tag,
+		Remote:    i.Remote,
+		TailOutput: i.TailOutput,
+		ViewedDiffHash: i.viewedDiffHash,
 		WatchdogEnabled: i.WatchdogEnabled,
 		ContinuousMode: i.ContinuousMode,
 		ContinuousModeStartTime: i.ContinuousModeStartTime,
@@ -114,6 +289,8 @@ func (i *Instance) ToInstanceData() InstanceData {
 		StallCount: i.StallCount,
 		RestartAttempts: i.RestartAttempts,
 		LastRestartTime: i.LastRestartTime,
+		PromptQueue: i.QueuedPrompts(),
+		ProgramCommand: i.command,
 	}
 
 	// Only include worktree data if gitWorktree is initialized
@@ -124,6 +301,7 @@ func (i *Instance) ToInstanceData() InstanceData {
 			SessionName:   i.Title,
 			BranchName:    i.gitWorktree.GetBranchName(),
 			BaseCommitSHA: i.gitWorktree.GetBaseCommitSHA(),
+			ExistingBranch: i.gitWorktree.IsExistingBranch(),
 		}
 	}
 
@@ -151,6 +329,10 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 		CreatedAt: data.CreatedAt,
 		UpdatedAt: data.UpdatedAt,
 		Program:   data.Program,
+		Tag:       data.Tag,
+		Remote:    data.Remote,
+		TailOutput: data.TailOutput,
+		viewedDiffHash: data.ViewedDiffHash,
 		WatchdogEnabled: data.WatchdogEnabled,
 		ContinuousMode: data.ContinuousMode,
 		ContinuousModeStartTime: data.ContinuousModeStartTime,
@@ -159,12 +341,15 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 		StallCount: data.StallCount,
 		RestartAttempts: data.RestartAttempts,
 		LastRestartTime: data.LastRestartTime,
+		promptQueue: data.PromptQueue,
+		command: data.ProgramCommand,
 		gitWorktree: git.NewGitWorktreeFromStorage(
 			data.Worktree.RepoPath,
 			data.Worktree.WorktreePath,
 			data.Worktree.SessionName,
 			data.Worktree.BranchName,
 			data.Worktree.BaseCommitSHA,
+			data.Worktree.ExistingBranch,
 		),
 		diffStats: &git.DiffStats{
 			Added:   data.DiffStats.Added,
@@ -173,9 +358,11 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 		},
 	}
 
+	instance.gitWorktree.SetRemote(data.Remote)
+
 	if instance.Paused() {
 		instance.started = true
-		instance.tmuxSession = tmux.NewTmuxSession(instance.Title, instance.Program)
+		instance.tmuxSession = instance.newTmuxSession(instance.commandLine())
 	} else {
 		if err := instance.Start(false); err != nil {
 			return nil, err
@@ -189,19 +376,52 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 type InstanceOptions struct {
 	// Title is the title of the instance.
 	Title string
-	// Path is the path to the workspace.
+	// Path is the path to the workspace. It can also be the URL of a remote repository, which is cloned when the
+	// instance starts, or reused if it was cloned before.
 	Path string
 	// Program is the program to run in the instance (e.g. "claude", "aider --model ollama_chat/gemma3:1b")
 	Program string
 	// If AutoYes is true, then
 	AutoYes bool
+	// Branch is an existing branch to check out in the worktree. If empty, a new branch is created
+	// from the session title.
+	Branch string
+	// MaxTitleLength is the maximum number of characters in the title, see ValidateTitle. 0 means no limit.
+	MaxTitleLength int
+	// StartupPrompt is sent once the program is ready after the instance is created. startup_prompt from the config is
+	// used if it's empty.
+	StartupPrompt string
+	// Prompt is the task for the program, sent after the startup prompt. Together they create a session that starts
+	// working right away, without sending the prompt separately. Both are queued when the instance starts and sent
+	// with SendQueuedPrompt once the program is ready, by the TUI or by the daemon.
+	Prompt string
+	// Remote is the git remote the branch is pushed to. Empty means origin.
+	Remote string
 }
 
 func NewInstance(opts InstanceOptions) (*Instance, error) {
 	t := time.Now()
 
+	if strings.TrimSpace(opts.Program) == "" {
+		return nil, fmt.Errorf("no program to run: set default_program in the config file or pass --program")
+	}
+	command, err := ParseProgram(opts.Program)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remote repositories are cloned when the instance starts. Until then, the path is where the clone goes.
+	var cloneURL string
+	path := opts.Path
+	if git.IsRemoteURL(path) {
+		cloneURL = path
+		if path, err = git.CloneDir(cloneURL); err != nil {
+			return nil, fmt.Errorf("failed to get clone directory for %s: %w", cloneURL, err)
+		}
+	}
+
 	// Convert path to absolute
-	absPath, err := filepath.Abs(opts.Path)
+	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
@@ -210,15 +430,174 @@ func NewInstance(opts InstanceOptions) (*Instance, error) {
 		Title:     opts.Title,
 		Status:    Ready,
 		Path:      absPath,
+		Branch:    opts.Branch,
 		Program:   opts.Program,
+		command:   &command,
 		Height:    0,
 		Width:     0,
 		CreatedAt: t,
 		UpdatedAt: t,
-		AutoYes:   false,
+		AutoYes:   opts.AutoYes,
+
+		maxTitleLength: opts.MaxTitleLength,
+		cloneURL:       cloneURL,
+		startupPrompt:  opts.StartupPrompt,
+		Prompt:         opts.Prompt,
+		Remote:         strings.TrimSpace(opts.Remote),
 	}, nil
 }
 
+// CloneURL returns the remote repository that's cloned into Path when the instance is first started, empty for local
+// ones
+func (i *Instance) CloneURL() string {
+	return i.cloneURL
+}
+
+// programCommand returns the program split into the command and its args. Programs are checked when the instance is
+// created, but older saved instances might not parse. Those are treated as a single command.
+func (i *Instance) programCommand() ProgramCommand {
+	if i.command != nil {
+		return *i.command
+	}
+	command, err := ParseProgram(i.Program)
+	if err != nil {
+		return ProgramCommand{Command: i.Program}
+	}
+	return command
+}
+
+// readyPattern returns the ready_patterns entry from the config for the program, looked up by the program as
+// configured and then by the name of its command. It's nil if there's none or it isn't a valid regular expression.
+func (i *Instance) readyPattern() *regexp.Regexp {
+	patterns := currentConfig().ReadyPatterns
+	pattern, ok := patterns[i.Program]
+	if !ok {
+		pattern, ok = patterns[filepath.Base(i.programCommand().Command)]
+	}
+	if !ok || pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		log.WarningLog.Printf("ignoring invalid ready pattern %q for %s: %v", pattern, i.Program, err)
+		return nil
+	}
+	return re
+}
+
+// newTmuxSession creates the tmux session that runs commandLine for the instance. If the program has a ready pattern,
+// the session is ready whenever its pane matches, colors aside.
+func (i *Instance) newTmuxSession(commandLine string) *tmux.TmuxSession {
+	tmuxSession := tmux.NewTmuxSession(i.Title, commandLine)
+	// The command line may be wrapped, the program is what claude and aider are recognized by. Claude Code is
+	// recognized with its args or through a launcher too.
+	programName := i.Program
+	if i.isClaude() {
+		programName = tmux.ProgramClaude
+	}
+	tmuxSession.SetProgramName(programName)
+	if pattern := i.readyPattern(); pattern != nil {
+		tmuxSession.SetReadyCheck(func(content string) bool {
+			return pattern.MatchString(StripANSI(content))
+		})
+	}
+	return tmuxSession
+}
+
+// commandLine returns the command line that tmux runs for the program, inside the program wrapper if one is set
+func (i *Instance) commandLine() string {
+	return i.wrapCommandLine(i.programLine())
+}
+
+// wrapCommandLine prefixes commandLine with the program_wrapper from the config
+func (i *Instance) wrapCommandLine(commandLine string) string {
+	worktreePath := ""
+	if i.gitWorktree != nil {
+		worktreePath = i.gitWorktree.GetWorktreePath()
+	}
+	return WrapCommandLine(currentConfig().ProgramWrapper, commandLine, worktreePath)
+}
+
+// programLine returns the command line of the program
+func (i *Instance) programLine() string {
+	if i.command != nil {
+		return i.command.String()
+	}
+	command, err := ParseProgram(i.Program)
+	if err != nil {
+		// Run programs that don't parse as they are, like before programs were parsed
+		log.WarningLog.Printf("failed to parse program of '%s': %v", i.Title, err)
+		return i.Program
+	}
+	return command.String()
+}
+
+// isClaude returns true if the instance runs Claude Code: the command is claude, or claude is run through a launcher
+// like npx or env. Claude as the value of an option, e.g. in "aider --model claude", doesn't count.
+func (i *Instance) isClaude() bool {
+	command := i.programCommand()
+	if strings.Contains(strings.ToLower(command.Name()), tmux.ProgramClaude) {
+		return true
+	}
+	prev := command.Command
+	for _, arg := range command.Args {
+		if isClaudeArg(prev, arg) {
+			return true
+		}
+		prev = arg
+	}
+	return false
+}
+
+// resumeLine returns the command line that restarts Claude Code with its conversation sessionNumber. It's the
+// command line the program was started with plus "-r <session>", so that the quoting stays the same. Only commands
+// that already pick a conversation are rebuilt, to replace that.
+func (i *Instance) resumeLine(sessionNumber string) string {
+	command := i.programCommand()
+	if command.hasResumeFlag() {
+		return command.WithResume(sessionNumber).String()
+	}
+	return i.programLine() + " -r " + shellQuote(sessionNumber)
+}
+
+// ValidateTitle returns an error if title is longer than maxLength characters. 0 means no limit.
+func ValidateTitle(title string, maxLength int) error {
+	if maxLength > 0 && utf8.RuneCountInString(title) > maxLength {
+		return fmt.Errorf("title cannot be longer than %d characters", maxLength)
+	}
+	return nil
+}
+
+// CheckNotInWorktree returns an error if path is inside the worktree of one of the given instances. Creating an
+// instance there would nest a worktree inside another one, which breaks git.
+func CheckNotInWorktree(path string, instances []*Instance) error {
+	path = resolvePath(path)
+	for _, instance := range instances {
+		if instance.gitWorktree == nil || instance.gitWorktree.GetWorktreePath() == "" {
+			continue
+		}
+		worktreePath := resolvePath(instance.gitWorktree.GetWorktreePath())
+		rel, err := filepath.Rel(worktreePath, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return fmt.Errorf("%s is inside the worktree of session '%s': run claude-squad from the repository root (%s) instead",
+			path, instance.Title, instance.gitWorktree.GetRepoPath())
+	}
+	return nil
+}
+
+// resolvePath makes path absolute and resolves symlinks where possible, so that paths can be compared
+func resolvePath(path string) string {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path
+}
+
 func (i *Instance) RepoName() (string, error) {
 	if !i.started {
 		return "", fmt.Errorf("cannot get repo name for instance that has not been started")
@@ -227,7 +606,14 @@ func (i *Instance) RepoName() (string, error) {
 }
 
 func (i *Instance) SetStatus(status Status) {
+	previous := i.Status
 	i.Status = status
+	if previous != status {
+		i.UpdatedAt = time.Now()
+		event := i.newEvent(EventStatusChanged, "")
+		event.PreviousStatus = previous.String()
+		events.publish(event)
+	}
 }
 
 // firstTimeSetup is true if this is a new instance. Otherwise, it's one loaded from storage.
@@ -236,10 +622,20 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 		return fmt.Errorf("instance title cannot be empty")
 	}
 
-	tmuxSession := tmux.NewTmuxSession(i.Title, i.Program)
-	i.tmuxSession = tmuxSession
+	if firstTimeSetup && i.cloneURL != "" {
+		if err := git.CloneRepo(i.cloneURL, i.Path); err != nil {
+			return err
+		}
+	}
 
-	if firstTimeSetup {
+	if firstTimeSetup && i.Branch != "" {
+		// Import an existing branch instead of creating a new one.
+		gitWorktree, err := git.NewGitWorktreeFromBranch(i.Path, i.Title, i.Branch)
+		if err != nil {
+			return fmt.Errorf("failed to create git worktree: %w", err)
+		}
+		i.gitWorktree = gitWorktree
+	} else if firstTimeSetup {
 		gitWorktree, branchName, err := git.NewGitWorktree(i.Path, i.Title)
 		if err != nil {
 			return fmt.Errorf("failed to create git worktree: %w", err)
@@ -247,6 +643,16 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 		i.gitWorktree = gitWorktree
 		i.Branch = branchName
 	}
+	if firstTimeSetup && i.Remote != "" {
+		if err := i.gitWorktree.CheckRemote(i.Remote); err != nil {
+			return err
+		}
+		i.gitWorktree.SetRemote(i.Remote)
+	}
+
+	// The program wrapper may refer to the worktree, so the tmux session is set up once it's known
+	tmuxSession := i.newTmuxSession(i.commandLine())
+	i.tmuxSession = tmuxSession
 
 	// Setup error handler to cleanup resources on any error
 	var setupErr error
@@ -257,6 +663,10 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 			}
 		} else {
 			i.started = true
+			if firstTimeSetup {
+				i.recordStat(StatCreated)
+				i.queueInitialPrompts()
+			}
 			// Initialize watchdog for restored instances if enabled
 			if i.WatchdogEnabled {
 				i.InitializeWatchdog(true)
@@ -300,11 +710,15 @@ func (i *Instance) Kill() error {
 		return nil
 	}
 
+	i.publishEvent(EventKilled, "")
+	i.recordStat(StatKilled)
+
 	var errs []error
 
 	// Always try to cleanup both resources, even if one fails
 	// Clean up tmux session first since it's using the git worktree
 	if i.tmuxSession != nil {
+		i.saveTranscriptOnClose()
 		if err := i.tmuxSession.Close(); err != nil {
 			errs = append(errs, fmt.Errorf("failed to close tmux session: %w", err))
 		}
@@ -336,31 +750,160 @@ func (i *Instance) combineErrors(errs []error) error {
 	return fmt.Errorf("%s", errMsg)
 }
 
-// Close is an alias for Kill to maintain backward compatibility
+// Close is an alias for Kill to maintain backward compatibility. Like Kill, closing an instance that was never started
+// succeeds without doing anything.
 func (i *Instance) Close() error {
-	if !i.started {
-		return fmt.Errorf("cannot close instance that has not been started")
-	}
 	return i.Kill()
 }
 
 func (i *Instance) Preview() (string, error) {
+	return i.PreviewCapturer()(context.Background())
+}
+
+// PreviewCapturer returns a function that captures the preview like Preview does, to run in the background so that a
+// hung tmux server can't block the caller. The function doesn't touch the instance, so the caller can keep updating
+// the instance meanwhile. The capture is killed once the context passed to the function is done.
+func (i *Instance) PreviewCapturer() func(ctx context.Context) (string, error) {
 	if !i.started || i.Status == Paused {
-		return "", nil
+		return func(context.Context) (string, error) { return "", nil }
 	}
-	return i.tmuxSession.CapturePaneContent()
+	return i.tmuxSession.CapturePaneContentContext
+}
+
+// PreviewText returns the preview as plain text, without colors and trailing blank lines. It's empty for instances
+// that haven't started or are paused.
+func (i *Instance) PreviewText() (string, error) {
+	content, err := i.Preview()
+	if err != nil {
+		return "", err
+	}
+	return plainText(content), nil
+}
+
+// plainText returns pane content without colors and trailing blank lines
+func plainText(content string) string {
+	return strings.TrimRight(StripANSI(content), " \t\n")
 }
 
 func (i *Instance) HasUpdated() (updated bool, hasPrompt bool) {
 	if !i.started {
 		return false, false
 	}
-	return i.tmuxSession.HasUpdated()
+	content, err := i.tmuxSession.CapturePaneContent()
+	if err != nil {
+		log.ErrorLog.Printf("error capturing pane content in status monitor: %v", err)
+		return false, false
+	}
+	return i.updateFromPane(content)
+}
+
+// PaneCheck is the pane of an instance, captured in the background for the metadata tick
+type PaneCheck struct {
+	Content string
+	Err     error
+	// SessionGone is true if the capture failed because the tmux session doesn't exist anymore
+	SessionGone bool
+}
+
+// PaneChecker returns a function that captures the pane for ApplyPaneCheck, or nil if the instance isn't running.
+// Like PreviewCapturer, the function doesn't touch the instance, so it can run in the background.
+func (i *Instance) PaneChecker() func(ctx context.Context) PaneCheck {
+	if !i.started || i.Status == Paused {
+		return nil
+	}
+	tmuxSession := i.tmuxSession
+	return func(ctx context.Context) PaneCheck {
+		content, err := tmuxSession.CapturePaneContentContext(ctx)
+		if err != nil {
+			// Make sure the session is really gone, a capture can also fail while the tmux server is busy
+			return PaneCheck{Err: err, SessionGone: isSessionGoneError(err) && !tmuxSession.DoesSessionExistContext(ctx)}
+		}
+		return PaneCheck{Content: content}
+	}
+}
+
+// ApplyPaneCheck updates the instance from a pane captured by PaneChecker. Like HasUpdated, it returns whether the
+// pane changed since the last check and whether it shows a prompt.
+func (i *Instance) ApplyPaneCheck(check PaneCheck) (updated bool, hasPrompt bool) {
+	i.sessionGone = check.SessionGone
+	if check.Err != nil {
+		log.ErrorLog.Printf("error capturing pane content in status monitor: %v", check.Err)
+		return false, false
+	}
+	return i.updateFromPane(check.Content)
+}
+
+// updateFromPane checks the captured pane content for changes and prompts
+func (i *Instance) updateFromPane(content string) (updated bool, hasPrompt bool) {
+	updated, hasPrompt = i.tmuxSession.CheckContent(content)
+	if updated {
+		// Output means the program is alive, whatever the last ping said
+		i.recordPing(true)
+		if i.TailOutput {
+			i.tailOutput(plainText(content))
+		}
+	}
+	return updated, hasPrompt
+}
+
+// isSessionGoneError returns true if err from capturing a pane says that the tmux session doesn't exist
+func isSessionGoneError(err error) bool {
+	return strings.Contains(err.Error(), "exit status 1") ||
+		strings.Contains(err.Error(), "no session found") ||
+		strings.Contains(err.Error(), "can't find session")
+}
+
+// pingTimeout is how long Ping waits for the program to react
+const pingTimeout = 3 * time.Second
+
+// hungPings is how many pings in a row a Claude Code program must not react to, without producing output in between,
+// before DetectCrashAndRestart restarts it as hung. A single failed ping can also be a program that's briefly busy.
+const hungPings = 2
+
+// Ping checks whether the program is alive and listening by typing a space into it and erasing it again. It returns
+// true if the program reacted, whether it's working or idle waiting for input, and false if it's hung. A hung program is
+// marked unresponsive until it produces output again. Once it failed hungPings pings in a row, a hung Claude Code
+// program is restarted by DetectCrashAndRestart.
+func (i *Instance) Ping() (bool, error) {
+	if !i.started || i.Status == Paused {
+		return false, fmt.Errorf("cannot ping instance that has not been started or is paused")
+	}
+	responsive, err := i.tmuxSession.Ping(pingTimeout)
+	if err != nil {
+		return false, fmt.Errorf("failed to ping '%s': %w", i.Title, err)
+	}
+	i.recordPing(responsive)
+	return responsive, nil
+}
+
+// Unresponsive returns true if the program didn't react to the last ping and hasn't produced output since
+func (i *Instance) Unresponsive() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.failedPings > 0
+}
+
+// hung returns true if the program didn't react to hungPings pings in a row and hasn't produced output since
+func (i *Instance) hung() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.failedPings >= hungPings
+}
+
+// recordPing counts a ping the program didn't react to, or clears the count once it shows signs of life
+func (i *Instance) recordPing(responsive bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if responsive {
+		i.failedPings = 0
+	} else {
+		i.failedPings++
+	}
 }
 
 // TapEnter sends an enter key press to the tmux session if AutoYes is enabled.
 func (i *Instance) TapEnter() {
-	if !i.started || !i.AutoYes {
+	if !i.started || !i.AutoYes || SafeMode() {
 		return
 	}
 	if err := i.tmuxSession.TapEnter(); err != nil {
@@ -368,6 +911,23 @@ func (i *Instance) TapEnter() {
 	}
 }
 
+// Interrupt stops what the program is doing without killing the session, e.g. a runaway generation. key is the
+// keystroke to send: "esc", or "ctrl+c" if empty.
+func (i *Instance) Interrupt(key string) error {
+	if !i.started || i.Status == Paused {
+		return fmt.Errorf("cannot interrupt instance that has not been started or is paused")
+	}
+
+	switch key {
+	case "", "ctrl+c":
+		return i.tmuxSession.TapCtrlC()
+	case "esc":
+		return i.tmuxSession.TapEsc()
+	default:
+		return fmt.Errorf("unsupported interrupt key %q: use \"ctrl+c\" or \"esc\"", key)
+	}
+}
+
 func (i *Instance) Attach() (chan struct{}, error) {
 	if !i.started {
 		return nil, fmt.Errorf("cannot attach instance that has not been started")
@@ -375,6 +935,14 @@ func (i *Instance) Attach() (chan struct{}, error) {
 	return i.tmuxSession.Attach()
 }
 
+// AttachSplit opens the instance's session in a tmux pane next to claude-squad. See tmux.TmuxSession.AttachSplit.
+func (i *Instance) AttachSplit() error {
+	if !i.started {
+		return fmt.Errorf("cannot attach instance that has not been started")
+	}
+	return i.tmuxSession.AttachSplit()
+}
+
 func (i *Instance) SetPreviewSize(width, height int) error {
 	if !i.started || i.Status == Paused {
 		return fmt.Errorf("cannot set preview size for instance that has not been started or " +
@@ -401,6 +969,9 @@ func (i *Instance) SetTitle(title string) error {
 	if i.started {
 		return fmt.Errorf("cannot change title of a started instance")
 	}
+	if err := ValidateTitle(title, i.maxTitleLength); err != nil {
+		return err
+	}
 	i.Title = title
 	return nil
 }
@@ -440,8 +1011,10 @@ func (i *Instance) Pause() error {
 		}
 	}
 
-	// Close tmux session first since it's using the git worktree
-	if err := i.tmuxSession.Close(); err != nil {
+	i.saveTranscriptOnClose()
+
+	// Close tmux session first since it's using the git worktree. A session that already died has nothing to close.
+	if err := i.tmuxSession.Close(); err != nil && i.tmuxSession.DoesSessionExist() {
 		errs = append(errs, fmt.Errorf("failed to close tmux session: %w", err))
 		log.ErrorLog.Print(err)
 		// Return early if we can't close tmux to avoid corrupted state
@@ -470,11 +1043,102 @@ func (i *Instance) Pause() error {
 		return err
 	}
 
+	// The diff of a paused instance isn't updated anymore, so take it from the branch, which now has everything
+	// including the pause commit. If that fails, the last diff of the worktree is kept.
+	if stats := i.gitWorktree.BranchDiff(); stats.Error != nil {
+		log.WarningLog.Printf("could not get the diff of branch %s: %v", i.gitWorktree.GetBranchName(), stats.Error)
+	} else {
+		i.diffStats = stats
+	}
+
 	i.SetStatus(Paused)
-	_ = clipboard.WriteAll(i.gitWorktree.GetBranchName())
+	i.recordStat(StatPaused)
+	return nil
+}
+
+// MergeIntoBase commits the changes in the worktree, like pausing does, and merges the branch into base in the main
+// repository. It returns the name of the base branch. See git.GitWorktree.MergeIntoBase.
+func (i *Instance) MergeIntoBase(base string, mode git.MergeMode) (string, error) {
+	if !i.started {
+		return "", fmt.Errorf("cannot merge instance that has not been started")
+	}
+	if i.Status != Paused {
+		dirty, err := i.gitWorktree.IsDirty()
+		if err != nil {
+			return "", fmt.Errorf("failed to check if worktree is dirty: %w", err)
+		}
+		if dirty {
+			commitMsg := fmt.Sprintf("[claudesquad] update from '%s' on %s (merge)", i.Title, time.Now().Format(time.RFC822))
+			if err := i.gitWorktree.CommitChanges(commitMsg); err != nil {
+				return "", err
+			}
+		}
+	}
+	return i.gitWorktree.MergeIntoBase(base, mode)
+}
+
+// SetRemote sets the git remote the branch is pushed to. Empty means origin. The remote has to be configured in the
+// repository.
+func (i *Instance) SetRemote(remote string) error {
+	remote = strings.TrimSpace(remote)
+	if i.gitWorktree != nil {
+		if remote != "" {
+			if err := i.gitWorktree.CheckRemote(remote); err != nil {
+				return err
+			}
+		}
+		i.gitWorktree.SetRemote(remote)
+	}
+	i.Remote = remote
 	return nil
 }
 
+// RecreateSession starts a new tmux session in the existing worktree. Use it after the tmux server died and took the
+// session with it; the worktree and branch are still intact.
+func (i *Instance) RecreateSession() error {
+	if !i.started || i.Status == Paused {
+		return fmt.Errorf("cannot recreate session for instance that has not been started or is paused")
+	}
+	if _, err := os.Stat(i.gitWorktree.GetWorktreePath()); err != nil {
+		return fmt.Errorf("worktree for '%s' is missing: %w", i.Title, err)
+	}
+
+	i.tmuxSession = i.newTmuxSession(i.commandLine())
+	if err := i.tmuxSession.Start(i.gitWorktree.GetWorktreePath()); err != nil {
+		return fmt.Errorf("failed to recreate tmux session for '%s': %w", i.Title, err)
+	}
+	i.sessionGone = false
+
+	i.SetStatus(Running)
+	return nil
+}
+
+// RestartDeadSession starts the program again after its tmux session died, e.g. with the tmux server. Claude Code
+// resumes its conversation if it can, counting towards maxAttempts like ManualRestart; otherwise, and for other
+// programs, the program starts fresh in the worktree. Once Claude Code ran out of restarts, it fails like
+// ManualRestart does.
+func (i *Instance) RestartDeadSession(maxAttempts int, cooldown time.Duration) error {
+	if !i.started || i.Status == Paused {
+		return fmt.Errorf("cannot restart instance that has not been started or is paused")
+	}
+	if i.TmuxAlive() {
+		return fmt.Errorf("the tmux session of '%s' is still running", i.Title)
+	}
+	if i.isClaude() {
+		err := i.ManualRestart(maxAttempts, cooldown)
+		if err == nil {
+			return nil
+		}
+		// Starting fresh doesn't get around the limit, the user has to wait like for any other restart
+		var limitErr *restartLimitError
+		if errors.As(err, &limitErr) {
+			return err
+		}
+		log.WarningLog.Printf("could not resume the conversation of '%s', starting Claude Code fresh: %v", i.Title, err)
+	}
+	return i.RecreateSession()
+}
+
 // Resume recreates the worktree and restarts the tmux session
 func (i *Instance) Resume() error {
 	if !i.started {
@@ -513,6 +1177,64 @@ func (i *Instance) Resume() error {
 	return nil
 }
 
+// currentDiffHash returns the hash of the current diff, empty if there's no diff
+func (i *Instance) currentDiffHash() string {
+	if i.diffStats == nil || i.diffStats.Content == "" {
+		return ""
+	}
+	if i.diffHashOf != i.diffStats {
+		i.diffHash = i.hashContent(i.diffStats.Content)
+		i.diffHashOf = i.diffStats
+	}
+	return i.diffHash
+}
+
+// MarkDiffViewed records the current diff as seen by the user, e.g. because the instance is selected
+func (i *Instance) MarkDiffViewed() {
+	i.viewedDiffHash = i.currentDiffHash()
+}
+
+// DiffChangedSinceViewed returns true if the diff changed since the user last viewed the instance, e.g. because the
+// agent made progress while the user was away
+func (i *Instance) DiffChangedSinceViewed() bool {
+	hash := i.currentDiffHash()
+	return hash != "" && hash != i.viewedDiffHash
+}
+
+// autoCommitMessage is the message of the commits made by AutoCommit
+const autoCommitMessage = "[claudesquad] autosave"
+
+// AutoCommit commits the changes in the worktree if interval has passed since the last time it did, so that the work
+// of a session that crashes is in a commit. The commit isn't pushed. Paused instances and a zero interval are skipped,
+// and nothing is committed in safe mode. It returns whether it committed.
+func (i *Instance) AutoCommit(interval time.Duration) (bool, error) {
+	if interval <= 0 || !i.started || i.Status == Paused || SafeMode() {
+		return false, nil
+	}
+	now := time.Now()
+	if i.lastAutoCommit.IsZero() {
+		// Start the clock, the first commit is an interval from now
+		i.lastAutoCommit = now
+		return false, nil
+	}
+	if now.Sub(i.lastAutoCommit) < interval {
+		return false, nil
+	}
+	i.lastAutoCommit = now
+
+	dirty, err := i.gitWorktree.IsDirty()
+	if err != nil {
+		return false, err
+	}
+	if !dirty {
+		return false, nil
+	}
+	if err := i.gitWorktree.CommitChanges(autoCommitMessage); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // UpdateDiffStats updates the git diff statistics for this instance
 func (i *Instance) UpdateDiffStats() error {
 	if !i.started {
@@ -521,29 +1243,137 @@ func (i *Instance) UpdateDiffStats() error {
 	}
 
 	if i.Status == Paused {
-		// Keep the previous diff stats if the instance is paused
+		// Keep the diff of the branch taken when the instance was paused
 		return nil
 	}
 
 	stats := i.gitWorktree.Diff()
 	if stats.Error != nil {
-		if strings.Contains(stats.Error.Error(), "base commit SHA not set") {
-			// Worktree is not fully set up yet, not an error
+		if errors.Is(stats.Error, git.ErrBaseCommitNotSet) {
+			// Worktree is not fully set up yet, not an error. If it stays that way, SetupIncomplete reports it.
+			if i.setupIncompleteSince.IsZero() {
+				i.setupIncompleteSince = time.Now()
+			}
 			i.diffStats = nil
 			return nil
 		}
 		return fmt.Errorf("failed to get diff stats: %w", stats.Error)
 	}
 
+	i.setupIncompleteSince = time.Time{}
+	changed := i.diffStats == nil || i.diffStats.Content != stats.Content
 	i.diffStats = stats
+
+	// The divergence takes a few git commands, so it's only computed again once divergenceInterval has passed, or
+	// right away when the diff changed.
+	if !changed && time.Since(i.divergenceAt) < divergenceInterval {
+		return nil
+	}
+	i.divergenceAt = time.Now()
+	// Divergence is informational only, so failing to compute it (e.g. an unborn HEAD in the repo) is not an error.
+	// This runs on every metadata tick, so don't log the failure either.
+	if divergence, err := i.gitWorktree.Divergence("HEAD"); err != nil {
+		i.divergence = nil
+	} else {
+		i.divergence = &divergence
+	}
 	return nil
 }
 
+// divergenceInterval is how often UpdateDiffStats computes the divergence again while the diff stays the same
+const divergenceInterval = 5 * time.Second
+
+// setupIncompleteGrace is how long a started instance may go without a base commit before its setup is considered
+// incomplete rather than still in progress
+const setupIncompleteGrace = 10 * time.Second
+
+// SetupIncomplete returns true if the instance is running but its worktree setup never completed, so that no diff can
+// be shown for it. CompleteSetup and Recreate recover from it.
+func (i *Instance) SetupIncomplete() bool {
+	return i.started && i.Status != Paused && !i.setupIncompleteSince.IsZero() &&
+		time.Since(i.setupIncompleteSince) >= setupIncompleteGrace
+}
+
+// CompleteSetup runs the worktree setup of an instance whose setup never completed again. The worktree and its changes
+// are kept if they exist. If the worktree had to be set up from scratch, the tmux session is recreated in it, since the
+// program was running in a directory that no longer exists.
+func (i *Instance) CompleteSetup() error {
+	if !i.started || i.Status == Paused {
+		return fmt.Errorf("cannot complete setup of instance that has not been started or is paused")
+	}
+	_, missingErr := os.Stat(i.gitWorktree.GetWorktreePath())
+	if err := i.gitWorktree.CompleteSetup(); err != nil {
+		return fmt.Errorf("failed to complete worktree setup for '%s': %w", i.Title, err)
+	}
+	i.setupIncompleteSince = time.Time{}
+	if missingErr == nil {
+		return nil
+	}
+	if err := i.tmuxSession.Close(); err != nil {
+		log.WarningLog.Printf("failed to close tmux session of '%s' before recreating it: %v", i.Title, err)
+	}
+	return i.RecreateSession()
+}
+
+// Recreate throws away the tmux session and worktree of an instance whose setup never completed and sets both up again
+// from HEAD. Uncommitted changes and the commits of the branch are lost, unless the branch was imported, in which case
+// it's kept and checked out again.
+func (i *Instance) Recreate() error {
+	if !i.started || i.Status == Paused {
+		return fmt.Errorf("cannot recreate instance that has not been started or is paused")
+	}
+	if err := i.tmuxSession.Close(); err != nil {
+		log.WarningLog.Printf("failed to close tmux session of '%s' before recreating it: %v", i.Title, err)
+	}
+	if err := i.gitWorktree.Cleanup(); err != nil {
+		return fmt.Errorf("failed to clean up worktree of '%s': %w", i.Title, err)
+	}
+	if err := i.gitWorktree.CompleteSetup(); err != nil {
+		return fmt.Errorf("failed to set up worktree for '%s': %w", i.Title, err)
+	}
+	i.setupIncompleteSince = time.Time{}
+	i.diffStats = nil
+	i.divergence = nil
+	i.divergenceAt = time.Time{}
+	return i.RecreateSession()
+}
+
+// checkedOutInterval is how often UpdateCheckedOut asks git which branch is checked out
+const checkedOutInterval = 5 * time.Second
+
+// UpdateCheckedOut refreshes whether the instance's branch is checked out in the main repository. It's called on
+// every metadata tick, so git is only asked again once checkedOutInterval has passed.
+func (i *Instance) UpdateCheckedOut() {
+	if !i.started || i.gitWorktree == nil || time.Since(i.checkedOutAt) < checkedOutInterval {
+		return
+	}
+	i.checkedOutAt = time.Now()
+	checkedOut, err := i.gitWorktree.IsBranchCheckedOut()
+	if err != nil {
+		// Informational only, like the divergence
+		i.checkedOut = false
+		return
+	}
+	i.checkedOut = checkedOut
+}
+
+// IsCheckedOut returns true if the instance's branch was checked out in the main repository when last checked.
+// Such an instance can't be killed or resumed until another branch is checked out.
+func (i *Instance) IsCheckedOut() bool {
+	return i.checkedOut
+}
+
 // GetDiffStats returns the current git diff statistics
 func (i *Instance) GetDiffStats() *git.DiffStats {
 	return i.diffStats
 }
 
+// GetDivergence returns how the instance branch relates to the branch checked out in the main repository.
+// Returns nil if it hasn't been computed yet.
+func (i *Instance) GetDivergence() *git.DivergenceInfo {
+	return i.divergence
+}
+
 // SendPrompt sends a prompt to the tmux session
 func (i *Instance) SendPrompt(prompt string) error {
 	if !i.started {
@@ -552,7 +1382,13 @@ func (i *Instance) SendPrompt(prompt string) error {
 	if i.tmuxSession == nil {
 		return fmt.Errorf("tmux session not initialized")
 	}
-	if err := i.tmuxSession.SendKeys(prompt); err != nil {
+	if strings.ContainsFunc(prompt, unicode.IsControl) {
+		// Typing a newline or another control character would act as a key press, e.g. submit the first line on
+		// its own. Paste the prompt instead so that it arrives intact.
+		if err := i.tmuxSession.PasteText(prompt); err != nil {
+			return fmt.Errorf("error pasting prompt into tmux session: %w", err)
+		}
+	} else if err := i.tmuxSession.SendKeys(prompt); err != nil {
 		return fmt.Errorf("error sending keys to tmux session: %w", err)
 	}
 
@@ -565,18 +1401,56 @@ func (i *Instance) SendPrompt(prompt string) error {
 	return nil
 }
 
+// SendRawKeys sends keys in tmux notation, separated by whitespace, e.g. "C-c" or "Escape Up Enter". Unlike SendPrompt,
+// nothing else is sent, not even enter.
+func (i *Instance) SendRawKeys(keys string) error {
+	if !i.started {
+		return fmt.Errorf("instance not started")
+	}
+	if i.Paused() {
+		return fmt.Errorf("cannot send keys to a paused instance")
+	}
+	if i.tmuxSession == nil {
+		return fmt.Errorf("tmux session not initialized")
+	}
+	fields := strings.Fields(keys)
+	if len(fields) == 0 {
+		return fmt.Errorf("no keys to send")
+	}
+	if err := i.tmuxSession.SendTmuxKeys(fields); err != nil {
+		return err
+	}
+	i.ResetStallCount()
+	return nil
+}
+
 // Watchdog functionality
 
-// DetectStall checks if the session appears to be stalled based on content and timing
-func (i *Instance) DetectStall(stallTimeoutSeconds, continuousModeTimeoutSeconds int) bool {
-	if !i.started || i.Status == Paused || !i.WatchdogEnabled {
+// DetectStall checks if the session appears to be stalled based on content, its captured pane, and timing
+func (i *Instance) DetectStall(content string, stallTimeoutSeconds, continuousModeTimeoutSeconds int) bool {
+	if !i.started || i.Status == Paused || !i.WatchdogEnabled || SafeMode() {
 		return false
 	}
 
-	// Get current content
-	content, err := i.tmuxSession.CapturePaneContent()
-	if err != nil {
-		log.WarningLog.Printf("failed to capture pane content for stall detection: %v", err)
+	// Compaction shows no new output for a while, but it isn't a stall. Sending continue in the middle of it corrupts
+	// the session, so hold off until it's done and then start counting from there.
+	if isCompacting(content) {
+		if !i.compacting {
+			log.InfoLog.Printf("instance '%s' is compacting its conversation, pausing the watchdog", i.Title)
+		}
+		i.compacting = true
+		i.LastActivityTime = time.Now()
+		i.lastContentHash = ""
+		return false
+	}
+	if i.compacting {
+		log.InfoLog.Printf("instance '%s' finished compacting its conversation, resuming the watchdog", i.Title)
+		i.compacting = false
+	}
+
+	// The rest of the pane may not change during a long thinking phase, but the busy indicator keeps ticking
+	if i.busyIndicatorChanged(content) {
+		i.markActivity()
 		return false
 	}
 
@@ -675,16 +1549,17 @@ func (i *Instance) DetectStall(stallTimeoutSeconds, continuousModeTimeoutSeconds
 			// Update hash if it changed
 			if i.lastContentHash != normalizedHash {
 				i.lastContentHash = normalizedHash
-				i.LastActivityTime = time.Now()
+				i.markActivity()
 			}
 			
 			return false
 		}
 	}
 
-	// Regular mode detection (unchanged logic for backward compatibility)
-	// Calculate content hash to detect if content has changed
-	currentHash := i.hashContent(content)
+	// Regular mode detection
+	// Calculate content hash to detect if content has changed. Spinners and timers redraw constantly, so only
+	// changes to the normalized text count as activity.
+	currentHash := i.hashContent(i.normalizeContent(content))
 	contentUnchanged := i.lastContentHash == currentHash
 	
 	// Update hash for next check
@@ -692,7 +1567,7 @@ func (i *Instance) DetectStall(stallTimeoutSeconds, continuousModeTimeoutSeconds
 
 	// If content changed, update last activity time
 	if !contentUnchanged {
-		i.LastActivityTime = time.Now()
+		i.markActivity()
 		return false
 	}
 
@@ -718,25 +1593,145 @@ func (i *Instance) DetectStall(stallTimeoutSeconds, continuousModeTimeoutSeconds
 	return false
 }
 
-// normalizeContent strips out dynamic elements like timestamps and cursor positions
+// continueEchoGrace is how long after a continue command changes to the pane are taken as the echo of the command
+// rather than the program working again
+const continueEchoGrace = 10 * time.Second
+
+// markActivity records that the pane changed. The stall count is kept when the program works again after a continue,
+// so that a program that keeps stalling runs out of continues; only input from the user starts it over, see
+// ResetStallCount.
+func (i *Instance) markActivity() {
+	i.LastActivityTime = time.Now()
+}
+
+// ResetStallCount starts the watchdog's count of continues over. It's called when the user gives the program input,
+// e.g. a prompt, since the user has taken over from the watchdog.
+func (i *Instance) ResetStallCount() {
+	i.StallCount = 0
+}
+
+// workedSinceContinue returns true if the pane changed after the last continue of the watchdog, not counting the echo
+// of the continue itself
+func (i *Instance) workedSinceContinue() bool {
+	return !i.continueSentAt.IsZero() && i.LastActivityTime.After(i.continueSentAt.Add(continueEchoGrace))
+}
+
+// compactingLineRegex matches the status line Claude Code shows while it compacts the conversation, e.g.
+// "✻ Compacting conversation… (12s · esc to interrupt)". It's different from the "Context left until auto-compact:"
+// notice, which is shown while idle.
+var compactingLineRegex = regexp.MustCompile(`(?i)^\s*[\x{2800}-\x{28FF}◐◓◑◒◴◷◶◵·✢✳✶✻✽∗*]\s+compacting( conversation)?(…|\.\.\.)`)
+
+// compactingStatusLines is how many of the last non-empty lines of the pane are searched for the compacting status
+// line. It's shown right above the input box, so output further up that mentions compacting doesn't count.
+const compactingStatusLines = 8
+
+// isCompacting returns true if the pane content shows that Claude Code is compacting the conversation
+func isCompacting(content string) bool {
+	lines := strings.Split(StripANSI(content), "\n")
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < compactingStatusLines; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		checked++
+		if compactingLineRegex.MatchString(lines[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPromptWaiting records whether the program shows a prompt that nobody answers, e.g. a permission prompt in an
+// instance without AutoYes. It's called on every metadata tick.
+func (i *Instance) SetPromptWaiting(waiting bool) {
+	if !waiting {
+		i.promptSince = time.Time{}
+	} else if i.promptSince.IsZero() {
+		i.promptSince = time.Now()
+	}
+}
+
+// NeedsAttention returns true if the instance waits for the user: it shows an unanswered prompt, it's ready for the
+// next task, or the watchdog gave up on it after maxContinueAttempts. since is when it started waiting.
+func (i *Instance) NeedsAttention(maxContinueAttempts int) (since time.Time, ok bool) {
+	if !i.started || i.Status == Paused {
+		return time.Time{}, false
+	}
+	switch {
+	case !i.promptSince.IsZero():
+		return i.promptSince, true
+	case i.Status == Ready:
+		return i.UpdatedAt, true
+	case i.WatchdogEnabled && i.StallCount >= maxContinueAttempts && maxContinueAttempts > 0:
+		return i.LastActivityTime, true
+	}
+	return time.Time{}, false
+}
+
+// IsCompacting returns true if Claude Code was compacting the conversation at the last watchdog check
+func (i *Instance) IsCompacting() bool {
+	return i.compacting
+}
+
+var (
+	// ansiRegex matches ANSI escape codes (colors, cursor movements, etc) and OSC sequences (e.g. window titles)
+	ansiRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+	// timeRegex matches timestamps, e.g. 13:54:48 or 2024-01-15
+	timeRegex = regexp.MustCompile(`\d{1,2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}`)
+	// percentRegex matches percentages that might change, e.g. "28%"
+	percentRegex = regexp.MustCompile(`\d+%`)
+	// spinnerRegex matches spinner frames: braille dots, circle quarters and the glyphs Claude Code cycles through
+	spinnerRegex = regexp.MustCompile(`[\x{2800}-\x{28FF}◐◓◑◒◴◷◶◵·✢✳✶✻✽∗]`)
+	// progressLineRegex matches status lines that only show that the program is busy, e.g.
+	// "✻ Thinking… (12s · ↑ 1.2k tokens · esc to interrupt)". Their timers tick without any real output.
+	progressLineRegex = regexp.MustCompile(`(?i)esc to interrupt|ctrl\+c to (interrupt|cancel)`)
+	// elapsedRegex matches elapsed time and token counters, e.g. "12s", "1m 5s" or "1.2k tokens"
+	elapsedRegex = regexp.MustCompile(`\b\d+(\.\d+)?[hms]\b|\b\d+(\.\d+)?k? tokens\b`)
+)
+
+// StripANSI removes the ANSI escape codes and OSC sequences from captured pane content
+func StripANSI(content string) string {
+	return ansiRegex.ReplaceAllString(content, "")
+}
+
+// normalizeContent reduces the pane content to its text so that cosmetic redraws (spinners, timers, cursor
+// movements) don't look like progress. Lines that only show that the program is busy are dropped entirely;
+// busyIndicatorChanged tells whether they're still ticking.
 func (i *Instance) normalizeContent(content string) string {
-	// Remove ANSI escape codes (colors, cursor movements, etc)
-	ansiRegex := regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
-	normalized := ansiRegex.ReplaceAllString(content, "")
-	
-	// Remove timestamp patterns (common formats)
-	// Example: 13:54:48, 2024-01-15, etc.
-	timeRegex := regexp.MustCompile(`\d{1,2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}`)
+	normalized := StripANSI(content)
 	normalized = timeRegex.ReplaceAllString(normalized, "")
-	
-	// Remove percentage patterns that might change (like "28%")
-	percentRegex := regexp.MustCompile(`\d+%`)
 	normalized = percentRegex.ReplaceAllString(normalized, "")
-	
-	// Normalize whitespace
-	normalized = strings.TrimSpace(normalized)
-	
-	return normalized
+	normalized = spinnerRegex.ReplaceAllString(normalized, "")
+
+	var lines []string
+	for _, line := range strings.Split(normalized, "\n") {
+		if progressLineRegex.MatchString(line) {
+			continue
+		}
+		line = elapsedRegex.ReplaceAllString(line, "")
+		// Normalize whitespace
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// busyIndicatorChanged returns true if the pane shows a busy indicator that changed since the last check, e.g. the
+// timer of "✻ Thinking… (12s · esc to interrupt)" ticking. normalizeContent drops these lines, so this is how they
+// count as activity. A hung program shows the indicator too, but it doesn't tick.
+func (i *Instance) busyIndicatorChanged(content string) bool {
+	var busy []string
+	for _, line := range strings.Split(StripANSI(content), "\n") {
+		if progressLineRegex.MatchString(line) {
+			busy = append(busy, strings.TrimSpace(line))
+		}
+	}
+	indicator := strings.Join(busy, "\n")
+	changed := indicator != "" && indicator != i.lastBusyIndicator
+	i.lastBusyIndicator = indicator
+	return changed
 }
 
 // hashContent creates a hash of the content
@@ -751,6 +1746,9 @@ func (i *Instance) InjectContinue(continueCommands []string) error {
 	if !i.started || i.Status == Paused {
 		return fmt.Errorf("cannot inject continue: instance not running")
 	}
+	if SafeMode() {
+		return fmt.Errorf("cannot inject continue: safe mode is on")
+	}
 
 	// Default continue commands if none provided
 	if len(continueCommands) == 0 {
@@ -765,6 +1763,7 @@ func (i *Instance) InjectContinue(continueCommands []string) error {
 	}
 
 	log.WarningLog.Printf("attempting to unstall instance '%s' (attempt %d)", i.Title, i.StallCount+1)
+	i.publishEvent(EventStalled, fmt.Sprintf("unstall attempt %d", i.StallCount+1))
 
 	// Get current content to make intelligent decision
 	content, err := i.tmuxSession.CapturePaneContent()
@@ -826,6 +1825,7 @@ func (i *Instance) InjectContinue(continueCommands []string) error {
 		// Increment stall count and update activity time
 		i.StallCount++
 		i.LastActivityTime = time.Now()
+		i.continueSentAt = i.LastActivityTime
 		
 		log.WarningLog.Printf("sent continue command '%s' to instance '%s'", cmd, i.Title)
 		return nil
@@ -863,6 +1863,7 @@ func (i *Instance) ToggleContinuousMode() bool {
 		// Clear the start time when disabling
 		i.ContinuousModeStartTime = time.Time{}
 	}
+	i.cachedDurationString = ""
 	if log.WarningLog != nil {
 		log.WarningLog.Printf("continuous mode %s for instance '%s'", 
 			map[bool]string{true: "enabled", false: "disabled"}[i.ContinuousMode], i.Title)
@@ -870,16 +1871,55 @@ func (i *Instance) ToggleContinuousMode() bool {
 	return i.ContinuousMode
 }
 
-// SetContinuousModeDuration sets the duration for continuous mode
+// SetContinuousModeDuration sets the total duration for continuous mode (0 = indefinite). The duration counts from
+// when continuous mode was enabled, so changing it while it runs keeps the time already elapsed: with 1h of 2h
+// elapsed, setting 3h leaves 2h. Use ExtendContinuousMode to add time or ResetContinuousMode to start the clock over.
 func (i *Instance) SetContinuousModeDuration(duration time.Duration) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
-	
+
+	i.ContinuousModeDuration = duration
+	i.cachedDurationString = ""
+}
+
+// ExtendContinuousMode adds extra to the time remaining in continuous mode. If the time is already up but the watchdog
+// hasn't turned continuous mode off yet, extra counts from now. Indefinite continuous mode stays indefinite.
+func (i *Instance) ExtendContinuousMode(extra time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.ContinuousMode || i.ContinuousModeDuration == 0 {
+		return
+	}
+	if elapsed := time.Since(i.ContinuousModeStartTime); elapsed > i.ContinuousModeDuration {
+		i.ContinuousModeDuration = elapsed
+	}
+	i.ContinuousModeDuration += extra
+	i.cachedDurationString = ""
+}
+
+// ResetContinuousMode starts the continuous mode clock over with a new total duration (0 = indefinite), as if it had
+// just been enabled.
+func (i *Instance) ResetContinuousMode(duration time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	i.ContinuousModeDuration = duration
 	if i.ContinuousMode {
-		// Reset start time when duration changes
 		i.ContinuousModeStartTime = time.Now()
 	}
+	i.cachedDurationString = ""
+}
+
+// GetContinuousModeRuntime returns how long continuous mode has been running, or 0 if it's disabled
+func (i *Instance) GetContinuousModeRuntime() time.Duration {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if !i.ContinuousMode {
+		return 0
+	}
+	return time.Since(i.ContinuousModeStartTime)
 }
 
 // IsContinuousMode returns whether continuous mode is enabled
@@ -897,6 +1937,7 @@ func (i *Instance) DisableContinuousMode() {
 	if i.ContinuousMode {
 		i.ContinuousMode = false
 		i.ContinuousModeStartTime = time.Time{}
+		i.cachedDurationString = ""
 		if log.InfoLog != nil {
 			log.InfoLog.Printf("continuous mode disabled for instance '%s'", i.Title)
 		}
@@ -908,7 +1949,11 @@ func (i *Instance) DisableContinuousMode() {
 func (i *Instance) GetContinuousModeTimeRemaining() time.Duration {
 	i.mu.RLock()
 	defer i.mu.RUnlock()
-	
+	return i.continuousModeTimeRemaining()
+}
+
+// continuousModeTimeRemaining is GetContinuousModeTimeRemaining for callers that hold mu
+func (i *Instance) continuousModeTimeRemaining() time.Duration {
 	if !i.ContinuousMode || i.ContinuousModeDuration == 0 {
 		return 0
 	}
@@ -923,10 +1968,10 @@ func (i *Instance) GetContinuousModeTimeRemaining() time.Duration {
 }
 
 // GetContinuousModeTimeRemainingFormatted returns a formatted string of remaining time
-// Uses caching to avoid repeated formatting
+// Uses caching to avoid repeated formatting. It updates the cache, so it takes the write lock.
 func (i *Instance) GetContinuousModeTimeRemainingFormatted() string {
-	i.mu.RLock()
-	defer i.mu.RUnlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	
 	if !i.ContinuousMode {
 		return ""
@@ -937,10 +1982,7 @@ func (i *Instance) GetContinuousModeTimeRemainingFormatted() string {
 		return i.cachedDurationString
 	}
 	
-	// Need to temporarily unlock for GetContinuousModeTimeRemaining call
-	i.mu.RUnlock()
-	remaining := i.GetContinuousModeTimeRemaining()
-	i.mu.RLock()
+	remaining := i.continuousModeTimeRemaining()
 	
 	if remaining == 0 {
 		i.cachedDurationString = ""
@@ -948,19 +1990,7 @@ func (i *Instance) GetContinuousModeTimeRemainingFormatted() string {
 		return ""
 	}
 	
-	// Format remaining time
-	hours := int(remaining.Hours())
-	minutes := int(remaining.Minutes()) % 60
-	seconds := int(remaining.Seconds()) % 60
-	
-	var timeStr string
-	if hours > 0 {
-		timeStr = fmt.Sprintf("%dh%dm", hours, minutes)
-	} else if minutes > 0 {
-		timeStr = fmt.Sprintf("%dm%ds", minutes, seconds)
-	} else {
-		timeStr = fmt.Sprintf("%ds", seconds)
-	}
+	timeStr := formatDuration(remaining)
 	
 	// Cache the result
 	i.cachedDurationString = timeStr
@@ -969,33 +1999,132 @@ func (i *Instance) GetContinuousModeTimeRemainingFormatted() string {
 	return timeStr
 }
 
-// ManualRestart allows user to manually restart Claude Code with session restore
-func (i *Instance) ManualRestart() error {
-	// Acquire mutex to prevent concurrent restarts
+// formatDuration formats a duration compactly, e.g. "2h5m", "5m30s" or "42s"
+func formatDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	} else if minutes > 0 {
+		return fmt.Sprintf("%dm%ds", minutes, seconds)
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
+
+// GetAgeFormatted returns how long ago the instance was created
+func (i *Instance) GetAgeFormatted() string {
+	return formatDuration(time.Since(i.CreatedAt))
+}
+
+// GetTimeSinceActivityFormatted returns how long ago the watchdog last saw activity in the instance, e.g. "2m ago".
+// Returns an empty string if no activity has been recorded.
+func (i *Instance) GetTimeSinceActivityFormatted() string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.LastActivityTime.IsZero() {
+		return ""
+	}
+	return humanize.RelativeTime(i.LastActivityTime)
+}
+
+// GetLastRestartFormatted returns how long ago Claude Code was last restarted in the instance, e.g. "3h ago".
+// Returns an empty string if it was never restarted.
+func (i *Instance) GetLastRestartFormatted() string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.LastRestartTime.IsZero() {
+		return ""
+	}
+	return humanize.RelativeTime(i.LastRestartTime)
+}
+
+// RestartsLeft returns how many restarts are left before the instance gives up restarting, out of maxAttempts. Once
+// it has given up, retryIn is how long until the cooldown has passed and restarts are allowed again.
+func (i *Instance) RestartsLeft(maxAttempts int, cooldown time.Duration) (left int, retryIn time.Duration) {
+	if i.RestartAttempts < maxAttempts {
+		return maxAttempts - i.RestartAttempts, 0
+	}
+	if retryIn = cooldown - time.Since(i.LastRestartTime); retryIn > 0 {
+		return 0, retryIn
+	}
+	// The cooldown has passed, the attempts start over with the next restart
+	return maxAttempts, 0
+}
+
+// NeedsRestart returns true if the program of a started Claude Code instance should be restarted: it's stalled now,
+// it didn't react to a ping or the last ApplyPaneCheck found its tmux session gone. Stalled means the watchdog gave up
+// after maxContinueAttempts, or it sent continue and nothing happened for stallTimeout since.
+func (i *Instance) NeedsRestart(maxContinueAttempts int, stallTimeout time.Duration) bool {
+	if !i.started || i.Status == Paused || !i.isClaude() {
+		return false
+	}
+	gaveUp := maxContinueAttempts > 0 && i.StallCount >= maxContinueAttempts
+	stalled := i.StallCount > 0 && !i.workedSinceContinue() && time.Since(i.LastActivityTime) > stallTimeout
+	return gaveUp || stalled || i.Unresponsive() || i.sessionGone
+}
+
+// restartLimitError is returned when an instance ran out of restarts until the cooldown has passed
+type restartLimitError struct {
+	title    string
+	attempts int
+	retryIn  time.Duration
+}
+
+func (e *restartLimitError) Error() string {
+	return fmt.Sprintf("'%s' was restarted %d times, please wait %s before restarting again",
+		e.title, e.attempts, formatDuration(e.retryIn))
+}
+
+// ManualRestart allows user to manually restart Claude Code with session restore. Manual restarts count towards
+// maxAttempts, after which restarting waits for the cooldown like automatic restarts do. The restart waits for Claude
+// Code to come back up, which can take tens of seconds, so callers in the UI should run it in the background.
+func (i *Instance) ManualRestart(maxAttempts int, cooldown time.Duration) error {
+	// Acquire mutex to prevent concurrent restarts. Don't hold it for the whole restart: the UI reads the continuous
+	// mode fields while rendering.
 	i.mu.Lock()
-	defer i.mu.Unlock()
 	
 	// Validate state
 	if !i.started {
+		i.mu.Unlock()
 		return fmt.Errorf("cannot restart: instance not started")
 	}
 	if i.Status == Paused {
+		i.mu.Unlock()
 		return fmt.Errorf("cannot restart: instance is paused")
 	}
-	if !strings.Contains(strings.ToLower(i.Program), "claude") {
+	if !i.isClaude() {
+		i.mu.Unlock()
 		return fmt.Errorf("restart only supported for Claude Code sessions")
 	}
 
 	// Check if we're already restarting
-	const restartCooldown = 10 * time.Second
-	if time.Since(i.LastRestartTime) < restartCooldown {
-		return fmt.Errorf("please wait %v before restarting again", 
-			restartCooldown - time.Since(i.LastRestartTime))
+	if i.restarting {
+		i.mu.Unlock()
+		return fmt.Errorf("instance is already restarting")
+	}
+	left, retryIn := i.RestartsLeft(maxAttempts, cooldown)
+	if left == 0 {
+		i.mu.Unlock()
+		return &restartLimitError{title: i.Title, attempts: maxAttempts, retryIn: retryIn}
+	}
+	if left == maxAttempts {
+		i.RestartAttempts = 0
 	}
 
 	// Save current state
 	i.LastRestartTime = time.Now()
 	i.RestartAttempts++
+	i.restarting = true
+	i.mu.Unlock()
+	defer func() {
+		i.mu.Lock()
+		i.restarting = false
+		i.mu.Unlock()
+	}()
 
 	// Log the restart
 	log.InfoLog.Printf("user initiated restart for instance '%s'", i.Title)
@@ -1008,53 +2137,52 @@ func (i *Instance) ManualRestart() error {
 	return nil
 }
 
-// DetectCrashAndRestart detects if Claude Code crashed and restarts it with --resume
-func (i *Instance) DetectCrashAndRestart() bool {
-	if !i.started || i.Status == Paused {
+// DetectCrashAndRestart detects if Claude Code crashed and restarts it with --resume. After maxAttempts restarts, it
+// gives up until the cooldown has passed.
+func (i *Instance) DetectCrashAndRestart(maxAttempts int, cooldown time.Duration) bool {
+	if !i.started || i.Status == Paused || SafeMode() {
 		return false
 	}
 
 	// Only handle Claude Code crashes
-	if !strings.Contains(strings.ToLower(i.Program), "claude") {
+	if !i.isClaude() {
 		return false
 	}
 
 	// Check if we've tried too many restarts recently
-	const maxRestartAttempts = 3
-	const restartCooldown = 5 * time.Minute
-	
-	if i.RestartAttempts >= maxRestartAttempts {
-		timeSinceLastRestart := time.Since(i.LastRestartTime)
-		if timeSinceLastRestart < restartCooldown {
-			// Too many restart attempts, give up for now
-			return false
-		}
+	left, _ := i.RestartsLeft(maxAttempts, cooldown)
+	if left == 0 {
+		// Too many restart attempts, give up for now
+		return false
+	}
+	if left == maxAttempts {
 		// Reset counter after cooldown
 		i.RestartAttempts = 0
 	}
 
-	// Try to capture pane content - if this fails, the session likely crashed
-	_, err := i.tmuxSession.CapturePaneContent()
-	if err != nil {
-		// Check if it's an exit status 1 error (session crashed)
-		if strings.Contains(err.Error(), "exit status 1") || 
-		   strings.Contains(err.Error(), "no session found") ||
-		   strings.Contains(err.Error(), "can't find session") {
-			
-			log.WarningLog.Printf("detected crashed Claude Code session '%s' (attempt %d/%d)", 
-				i.Title, i.RestartAttempts+1, maxRestartAttempts)
-			
-			i.RestartAttempts++
-			i.LastRestartTime = time.Now()
-			
-			if err := i.restartClaudeWithResume(); err != nil {
-				log.ErrorLog.Printf("failed to restart Claude Code session '%s': %v", i.Title, err)
-				return false
-			}
-			return true
-		}
+	// A crash is what the last ApplyPaneCheck found: the tmux session is gone. A program that didn't react to
+	// hungPings pings in a row is hung: the session is still there, but it's as good as crashed. After a single failed
+	// ping it's only marked unresponsive, that can also be a program that's briefly busy.
+	switch {
+	case i.sessionGone:
+		log.WarningLog.Printf("detected crashed Claude Code session '%s' (attempt %d/%d)",
+			i.Title, i.RestartAttempts+1, maxAttempts)
+	case i.hung():
+		log.WarningLog.Printf("detected hung Claude Code session '%s' (attempt %d/%d)",
+			i.Title, i.RestartAttempts+1, maxAttempts)
+	default:
+		return false
 	}
-	return false
+
+	i.recordPing(true)
+	i.RestartAttempts++
+	i.LastRestartTime = time.Now()
+
+	if err := i.restartClaudeWithResume(); err != nil {
+		log.ErrorLog.Printf("failed to restart Claude Code session '%s': %v", i.Title, err)
+		return false
+	}
+	return true
 }
 
 // restartClaudeWithResume restarts Claude Code with --resume and the session ID
@@ -1081,14 +2209,13 @@ func (i *Instance) restartClaudeWithResume() error {
 		}
 	}
 
-	// Create resume command with session number
-	baseProgram := strings.Split(i.Program, " ")[0] // Get just "claude" without args
-	resumeProgram := fmt.Sprintf("%s -r %s", baseProgram, sessionNumber)
+	// Create resume command with session number, keeping the args the program was started with
+	resumeProgram := i.wrapCommandLine(i.resumeLine(sessionNumber))
 
 	log.WarningLog.Printf("restarting with command: %s", resumeProgram)
 
 	// Create new tmux session with resume command
-	tmuxSession := tmux.NewTmuxSession(i.Title, resumeProgram)
+	tmuxSession := i.newTmuxSession(resumeProgram)
 	i.tmuxSession = tmuxSession
 
 	// Start the new session in the existing worktree
@@ -1097,6 +2224,7 @@ func (i *Instance) restartClaudeWithResume() error {
 	}
 
 	log.WarningLog.Printf("successfully restarted Claude Code session '%s' with session %s", i.Title, sessionNumber)
+	i.publishEvent(EventRestarted, fmt.Sprintf("resumed Claude Code session %s", sessionNumber))
 	
 	// Wait for Claude to be ready with exponential backoff
 	maxRetries := 5
@@ -1128,6 +2256,8 @@ func (i *Instance) restartClaudeWithResume() error {
 	// Reset activity tracking for fresh monitoring
 	i.LastActivityTime = time.Now()
 	i.lastContentHash = ""
+	i.StallCount = 0
+	i.sessionGone = false
 	
 	// Restore continuous mode state if it was enabled
 	if wasInContinuousMode {
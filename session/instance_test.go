@@ -0,0 +1,540 @@
+package session
+
+import (
+	"github.com/smtg-ai/claude-squad/config"
+	"github.com/smtg-ai/claude-squad/session/git"
+	"github.com/smtg-ai/claude-squad/session/tmux"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckNotInWorktree(t *testing.T) {
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "repo")
+	worktreePath := filepath.Join(root, "worktrees", "feature_123")
+	siblingPath := filepath.Join(root, "worktrees", "feature_1234")
+	for _, dir := range []string{repoPath, filepath.Join(worktreePath, "src"), siblingPath} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+
+	instances := []*Instance{
+		{Title: "not-started"},
+		{
+			Title:       "feature",
+			gitWorktree: git.NewGitWorktreeFromStorage(repoPath, worktreePath, "feature", "user/feature", "", false),
+		},
+	}
+
+	// The worktree itself and any directory inside it are refused
+	err := CheckNotInWorktree(worktreePath, instances)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "feature")
+	assert.Contains(t, err.Error(), repoPath)
+	assert.Error(t, CheckNotInWorktree(filepath.Join(worktreePath, "src"), instances))
+
+	// A relative path is resolved before comparing
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(filepath.Join(worktreePath, "src")))
+	defer os.Chdir(wd)
+	assert.Error(t, CheckNotInWorktree(".", instances))
+
+	// The repository root and a worktree that merely shares the prefix are fine
+	assert.NoError(t, CheckNotInWorktree(repoPath, instances))
+	assert.NoError(t, CheckNotInWorktree(siblingPath, instances))
+	assert.NoError(t, CheckNotInWorktree(repoPath, nil))
+}
+
+func TestSetTitleMaxLength(t *testing.T) {
+	instance, err := NewInstance(InstanceOptions{Title: "", Path: t.TempDir(), Program: "claude", MaxTitleLength: 5})
+	require.NoError(t, err)
+
+	require.NoError(t, instance.SetTitle("héllo"))
+	err = instance.SetTitle("héllo!")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "5 characters")
+	assert.Equal(t, "héllo", instance.Title)
+
+	// No limit
+	assert.NoError(t, ValidateTitle(strings.Repeat("a", 100), 0))
+}
+
+func TestIsCompacting(t *testing.T) {
+	assert.True(t, isCompacting("✻ Compacting conversation… (12s · esc to interrupt)"))
+	assert.True(t, isCompacting("\x1b[38;5;174m✶\x1b[39m \x1b[1mCompacting…\x1b[0m"))
+	// The auto-compact notice is shown while idle and is not compaction in progress
+	assert.False(t, isCompacting("> \n  Context left until auto-compact: 12%"))
+	assert.False(t, isCompacting("⎿ Conversation compacted"))
+	// Output that mentions compacting isn't the status line
+	assert.False(t, isCompacting("⏺ I'll fix the compacting... logic in storage.go"))
+	assert.False(t, isCompacting("log: compacting conversation history"))
+	// Only the bottom of the pane has the status line
+	assert.False(t, isCompacting("✻ Compacting conversation…\n"+strings.Repeat("output\n", compactingStatusLines)))
+	assert.True(t, isCompacting("✻ Compacting conversation…\n\n╭───╮\n│ > │\n╰───╯\n  ? for shortcuts\n\n"))
+}
+
+func TestContinuousModeDurationChanges(t *testing.T) {
+	// 1h of 2h elapsed
+	running := func() *Instance {
+		return &Instance{
+			ContinuousMode:          true,
+			ContinuousModeStartTime: time.Now().Add(-time.Hour),
+			ContinuousModeDuration:  2 * time.Hour,
+		}
+	}
+	assertRemaining := func(t *testing.T, instance *Instance, want time.Duration) {
+		t.Helper()
+		assert.InDelta(t, want.Seconds(), instance.GetContinuousModeTimeRemaining().Seconds(), 5)
+	}
+
+	t.Run("set keeps the elapsed time", func(t *testing.T) {
+		instance := running()
+		instance.SetContinuousModeDuration(3 * time.Hour)
+		assertRemaining(t, instance, 2*time.Hour)
+	})
+
+	t.Run("extend adds to the time left", func(t *testing.T) {
+		instance := running()
+		instance.ExtendContinuousMode(30 * time.Minute)
+		assertRemaining(t, instance, 90*time.Minute)
+		assert.Equal(t, 150*time.Minute, instance.ContinuousModeDuration)
+	})
+
+	t.Run("extend after the time is up counts from now", func(t *testing.T) {
+		instance := running()
+		instance.ContinuousModeStartTime = time.Now().Add(-3 * time.Hour)
+		instance.ExtendContinuousMode(30 * time.Minute)
+		assertRemaining(t, instance, 30*time.Minute)
+	})
+
+	t.Run("extend leaves indefinite alone", func(t *testing.T) {
+		instance := running()
+		instance.ContinuousModeDuration = 0
+		instance.ExtendContinuousMode(30 * time.Minute)
+		assert.Equal(t, time.Duration(0), instance.ContinuousModeDuration)
+	})
+
+	t.Run("reset starts the clock over", func(t *testing.T) {
+		instance := running()
+		instance.ResetContinuousMode(3 * time.Hour)
+		assertRemaining(t, instance, 3*time.Hour)
+		assert.Less(t, instance.GetContinuousModeRuntime(), time.Minute)
+	})
+}
+
+func TestContinuousModeTimeRemainingFormatted(t *testing.T) {
+	instance := &Instance{Title: "continuous", ContinuousModeDuration: 2 * time.Hour}
+	assert.Empty(t, instance.GetContinuousModeTimeRemainingFormatted())
+	instance.ToggleContinuousMode()
+	assert.Equal(t, "1h59m", instance.GetContinuousModeTimeRemainingFormatted())
+	// Disabling drops the cached string right away
+	instance.ToggleContinuousMode()
+	assert.Empty(t, instance.GetContinuousModeTimeRemainingFormatted())
+
+	// Meant for go test -race: the UI reads the string while the watchdog toggles the mode
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 1000; k++ {
+				instance.ToggleContinuousMode()
+				instance.SetContinuousModeDuration(time.Duration(k+1) * time.Hour)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 1000; k++ {
+				instance.GetContinuousModeTimeRemainingFormatted()
+				instance.GetContinuousModeTimeRemaining()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNeedsAttention(t *testing.T) {
+	readySince := time.Now().Add(-time.Minute)
+	ready := &Instance{started: true, Status: Ready, UpdatedAt: readySince}
+	since, ok := ready.NeedsAttention(3)
+	assert.True(t, ok)
+	assert.Equal(t, readySince, since)
+
+	running := &Instance{started: true, Status: Running}
+	_, ok = running.NeedsAttention(3)
+	assert.False(t, ok)
+
+	// A prompt nobody answers
+	running.SetPromptWaiting(true)
+	since, ok = running.NeedsAttention(3)
+	assert.True(t, ok)
+	running.SetPromptWaiting(true)
+	again, _ := running.NeedsAttention(3)
+	assert.Equal(t, since, again, "waiting since the prompt was first seen")
+	running.SetPromptWaiting(false)
+	_, ok = running.NeedsAttention(3)
+	assert.False(t, ok)
+
+	// The watchdog gave up
+	stalled := &Instance{started: true, Status: Running, WatchdogEnabled: true, StallCount: 3}
+	_, ok = stalled.NeedsAttention(3)
+	assert.True(t, ok)
+
+	paused := &Instance{started: true, Status: Paused}
+	_, ok = paused.NeedsAttention(3)
+	assert.False(t, ok)
+}
+
+func TestDiffChangedSinceViewed(t *testing.T) {
+	instance := &Instance{Title: "diff", Status: Running, started: true}
+	assert.False(t, instance.DiffChangedSinceViewed(), "no diff yet")
+
+	instance.diffStats = &git.DiffStats{Added: 1, Content: "+one"}
+	assert.True(t, instance.DiffChangedSinceViewed())
+	instance.MarkDiffViewed()
+	assert.False(t, instance.DiffChangedSinceViewed())
+
+	// The same diff again isn't a change
+	instance.diffStats = &git.DiffStats{Added: 1, Content: "+one"}
+	assert.False(t, instance.DiffChangedSinceViewed())
+
+	instance.diffStats = &git.DiffStats{Added: 2, Content: "+one\n+two"}
+	assert.True(t, instance.DiffChangedSinceViewed())
+
+	// Viewing survives saving and loading the instance
+	instance.MarkDiffViewed()
+	assert.Equal(t, instance.viewedDiffHash, instance.ToInstanceData().ViewedDiffHash)
+}
+
+func TestAutoCommit(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "file.txt"), []byte("one\n"), 0644))
+	require.NoError(t, git.InitRepo(repo))
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@localhost")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@localhost")
+	lastMessage := func() string {
+		out, err := exec.Command("git", "-C", repo, "log", "-1", "--format=%s").CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	initial := lastMessage()
+
+	instance := &Instance{
+		Title:       "autosave",
+		Status:      Running,
+		started:     true,
+		gitWorktree: git.NewGitWorktreeFromStorage(repo, repo, "autosave", "main", "", false),
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "file.txt"), []byte("two\n"), 0644))
+
+	committed, err := instance.AutoCommit(0)
+	require.NoError(t, err)
+	assert.False(t, committed, "turned off")
+	committed, err = instance.AutoCommit(time.Minute)
+	require.NoError(t, err)
+	assert.False(t, committed, "the first commit waits for the interval")
+	assert.Equal(t, initial, lastMessage())
+
+	instance.lastAutoCommit = time.Now().Add(-2 * time.Minute)
+	committed, err = instance.AutoCommit(time.Minute)
+	require.NoError(t, err)
+	assert.True(t, committed)
+	assert.Equal(t, autoCommitMessage, lastMessage())
+
+	// Nothing changed since
+	instance.lastAutoCommit = time.Now().Add(-2 * time.Minute)
+	committed, err = instance.AutoCommit(time.Minute)
+	require.NoError(t, err)
+	assert.False(t, committed)
+
+	instance.Status = Paused
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "file.txt"), []byte("three\n"), 0644))
+	instance.lastAutoCommit = time.Now().Add(-2 * time.Minute)
+	committed, err = instance.AutoCommit(time.Minute)
+	require.NoError(t, err)
+	assert.False(t, committed, "paused")
+}
+
+func TestReadyPattern(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ReadyPatterns = map[string]string{
+		"aider --model sonnet": `sonnet> $`,
+		"aider":                `(?m)^> $`,
+		"codex":                `(`,
+	}
+	SetConfig(cfg)
+	defer SetConfig(nil)
+
+	pattern := func(program string) string {
+		instance := &Instance{Title: "ready", Program: program}
+		if re := instance.readyPattern(); re != nil {
+			return re.String()
+		}
+		return ""
+	}
+	assert.Equal(t, `sonnet> $`, pattern("aider --model sonnet"), "the program as configured comes first")
+	assert.Equal(t, `(?m)^> $`, pattern("/usr/local/bin/aider --model opus"), "then the name of the command")
+	assert.Empty(t, pattern("codex"), "invalid patterns are ignored")
+	assert.Empty(t, pattern("claude"))
+}
+
+func TestApplyPaneCheck(t *testing.T) {
+	paused := &Instance{Title: "paused", Program: "claude", Status: Paused, started: true}
+	assert.Nil(t, paused.PaneChecker(), "paused instances aren't captured")
+
+	instance := &Instance{Title: "crashed", Program: "claude", Status: Running, started: true,
+		LastActivityTime: time.Now()}
+	assert.False(t, instance.NeedsRestart(3, time.Minute))
+	updated, prompt := instance.ApplyPaneCheck(PaneCheck{Err: errors.New("can't find session"), SessionGone: true})
+	assert.False(t, updated)
+	assert.False(t, prompt)
+	assert.True(t, instance.NeedsRestart(3, time.Minute), "the background check found the session gone")
+}
+
+func TestBusyIndicatorChanged(t *testing.T) {
+	instance := &Instance{Title: "thinking"}
+	thinking := func(elapsed string) string {
+		return "● Reading the code\n\n✻ Thinking… (" + elapsed + " · ↑ 1.2k tokens · esc to interrupt)\n> "
+	}
+
+	assert.True(t, instance.busyIndicatorChanged(thinking("12s")))
+	assert.True(t, instance.busyIndicatorChanged(thinking("13s")), "the timer ticks while generating")
+	assert.False(t, instance.busyIndicatorChanged(thinking("13s")), "a frozen timer isn't activity")
+	assert.False(t, instance.busyIndicatorChanged("● Done\n> "), "no indicator")
+	assert.True(t, instance.busyIndicatorChanged(thinking("1s")))
+}
+
+func TestNeedsRestart(t *testing.T) {
+	const maxContinueAttempts, stallTimeout = 3, 5 * time.Minute
+	longAgo := time.Now().Add(-time.Hour)
+	stalled := &Instance{Title: "stalled", Program: "claude", Status: Running, started: true, StallCount: 1,
+		LastActivityTime: longAgo}
+	assert.True(t, stalled.NeedsRestart(maxContinueAttempts, stallTimeout), "nothing happened since the continue")
+
+	// A continue long ago doesn't make a working session stalled
+	working := &Instance{Title: "working", Program: "claude", Status: Running, started: true, StallCount: 1,
+		LastActivityTime: time.Now()}
+	assert.False(t, working.NeedsRestart(maxContinueAttempts, stallTimeout))
+	working.StallCount = maxContinueAttempts
+	assert.True(t, working.NeedsRestart(maxContinueAttempts, stallTimeout), "the watchdog gave up")
+
+	healthy := &Instance{Title: "healthy", Program: "claude", Status: Running, started: true}
+	assert.False(t, healthy.NeedsRestart(maxContinueAttempts, stallTimeout))
+	healthy.recordPing(false)
+	assert.True(t, healthy.NeedsRestart(maxContinueAttempts, stallTimeout), "a program that didn't react to a ping")
+
+	// Only Claude Code can be restarted with its conversation
+	aider := &Instance{Title: "aider", Program: "aider", Status: Running, started: true, StallCount: 1,
+		LastActivityTime: longAgo}
+	assert.False(t, aider.NeedsRestart(maxContinueAttempts, stallTimeout))
+
+	paused := &Instance{Title: "paused", Program: "claude", Status: Paused, started: true, StallCount: 1,
+		LastActivityTime: longAgo}
+	assert.False(t, paused.NeedsRestart(maxContinueAttempts, stallTimeout))
+}
+
+func TestStallCountKeptWhenWorkResumes(t *testing.T) {
+	const maxContinueAttempts, stallTimeout = 3, 5 * time.Minute
+	instance := &Instance{Title: "resumed", Program: "claude", Status: Running, started: true, StallCount: 2}
+
+	// The program worked again after the continue, so the continue did its job, but it still counts
+	instance.continueSentAt = time.Now().Add(-time.Hour)
+	instance.markActivity()
+	assert.Equal(t, 2, instance.StallCount)
+	// Idle long after the work isn't a stall that needs a restart
+	instance.LastActivityTime = time.Now().Add(-30 * time.Minute)
+	assert.False(t, instance.NeedsRestart(maxContinueAttempts, stallTimeout))
+
+	// Only the echo of the continue since: nothing happened
+	instance.LastActivityTime = instance.continueSentAt.Add(time.Second)
+	assert.True(t, instance.NeedsRestart(maxContinueAttempts, stallTimeout))
+
+	// Input from the user starts the count over
+	instance.ResetStallCount()
+	assert.Equal(t, 0, instance.StallCount)
+	assert.False(t, instance.NeedsRestart(maxContinueAttempts, stallTimeout))
+}
+
+func TestRestartsLeft(t *testing.T) {
+	instance := &Instance{Title: "restarts", Program: "claude", Status: Running, started: true}
+	left, retryIn := instance.RestartsLeft(3, 5*time.Minute)
+	assert.Equal(t, 3, left)
+	assert.Zero(t, retryIn)
+
+	instance.RestartAttempts = 2
+	instance.LastRestartTime = time.Now()
+	left, _ = instance.RestartsLeft(3, 5*time.Minute)
+	assert.Equal(t, 1, left)
+
+	// Out of attempts until the cooldown has passed
+	instance.RestartAttempts = 3
+	left, retryIn = instance.RestartsLeft(3, 5*time.Minute)
+	assert.Equal(t, 0, left)
+	assert.InDelta(t, float64(5*time.Minute), float64(retryIn), float64(time.Second))
+	err := instance.ManualRestart(3, 5*time.Minute)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "please wait")
+
+	instance.LastRestartTime = time.Now().Add(-6 * time.Minute)
+	left, retryIn = instance.RestartsLeft(3, 5*time.Minute)
+	assert.Equal(t, 3, left)
+	assert.Zero(t, retryIn)
+}
+
+func TestRestartDeadSessionKeepsRestartLimit(t *testing.T) {
+	instance := &Instance{
+		Title:           "dead",
+		Program:         "claude",
+		Status:          Running,
+		started:         true,
+		RestartAttempts: 3,
+		LastRestartTime: time.Now(),
+		tmuxSession:     tmux.NewTmuxSession("restart-limit-test-missing", "claude"),
+	}
+
+	// Out of restarts, the session isn't started fresh instead
+	err := instance.RestartDeadSession(3, 5*time.Minute)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "please wait")
+}
+
+func TestNewInstanceRequiresProgram(t *testing.T) {
+	for _, program := range []string{"", "   "} {
+		_, err := NewInstance(InstanceOptions{Title: "no-program", Path: t.TempDir(), Program: program})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "default_program")
+	}
+}
+
+func TestQueueInitialPrompts(t *testing.T) {
+	instance, err := NewInstance(InstanceOptions{
+		Title:         "startup",
+		Path:          t.TempDir(),
+		Program:       "claude",
+		StartupPrompt: "Read CLAUDE.md before starting",
+	})
+	require.NoError(t, err)
+	instance.EnqueuePrompt("fix the tests")
+
+	// The startup prompt goes first, since it primes the program for everything after it
+	instance.queueInitialPrompts()
+	assert.Equal(t, []string{"Read CLAUDE.md before starting", "fix the tests"}, instance.QueuedPrompts())
+
+	// The initial prompt follows the startup prompt
+	primed, err := NewInstance(InstanceOptions{
+		Title:         "primed",
+		Path:          t.TempDir(),
+		Program:       "claude",
+		StartupPrompt: "Read CLAUDE.md before starting",
+		Prompt:        "add a changelog",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "add a changelog", primed.Prompt)
+	primed.EnqueuePrompt("then run the tests")
+	primed.queueInitialPrompts()
+	assert.Equal(t, []string{"Read CLAUDE.md before starting", "add a changelog", "then run the tests"},
+		primed.QueuedPrompts())
+}
+
+func TestInitialPromptIsSent(t *testing.T) {
+	for _, bin := range []string{"git", "tmux"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	// Keep the config and worktrees out of the real home directory.
+	t.Setenv("HOME", t.TempDir())
+
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	instance, err := NewInstance(InstanceOptions{
+		Title:   "initial-prompt-test",
+		Path:    repoDir,
+		Program: "cat",
+		Prompt:  "hello from the initial prompt",
+	})
+	require.NoError(t, err)
+	require.NoError(t, instance.Start(true))
+	defer instance.Kill()
+	assert.Equal(t, []string{"hello from the initial prompt"}, instance.QueuedPrompts())
+
+	sent, err := instance.SendQueuedPrompt()
+	require.NoError(t, err)
+	assert.True(t, sent)
+	assert.Empty(t, instance.QueuedPrompts())
+	assert.Equal(t, Running, instance.Status)
+	assert.Eventually(t, func() bool {
+		content, err := instance.Preview()
+		return err == nil && strings.Contains(content, "hello from the initial prompt")
+	}, 5*time.Second, 100*time.Millisecond, "the prompt should be typed into the program")
+
+	// Nothing left to send
+	sent, err = instance.SendQueuedPrompt()
+	require.NoError(t, err)
+	assert.False(t, sent)
+}
+
+func TestCloseUnstartedInstance(t *testing.T) {
+	instance, err := NewInstance(InstanceOptions{Title: "never-started", Path: t.TempDir(), Program: "claude"})
+	require.NoError(t, err)
+
+	// Closing and killing agree: there's nothing to clean up for an instance that never started
+	require.NoError(t, instance.Close())
+	require.NoError(t, instance.Kill())
+	assert.False(t, instance.Started())
+}
+
+func TestFailedPingsMakeTheProgramHung(t *testing.T) {
+	instance := &Instance{Title: "pinged", Program: "claude", Status: Running, started: true}
+	assert.False(t, instance.Unresponsive())
+
+	// A single failed ping only marks it unresponsive
+	instance.recordPing(false)
+	assert.True(t, instance.Unresponsive())
+	assert.False(t, instance.hung())
+
+	instance.recordPing(false)
+	assert.True(t, instance.hung(), "another failed ping without output in between")
+
+	// Output or a ping it reacts to start the count over
+	instance.recordPing(true)
+	assert.False(t, instance.Unresponsive())
+	assert.False(t, instance.hung())
+}
+
+func TestSafeModeStopsAutomaticActions(t *testing.T) {
+	SetSafeMode(true)
+	defer SetSafeMode(false)
+
+	// There's no tmux session, so any of these touching the session would panic
+	instance := &Instance{Title: "safe", Program: "claude", Status: Running, started: true, AutoYes: true,
+		WatchdogEnabled: true, failedPings: hungPings}
+	instance.TapEnter()
+	assert.False(t, instance.DetectStall("> ", 1, 1))
+	assert.False(t, instance.DetectCrashAndRestart(3, 5*time.Minute))
+	instance.lastAutoCommit = time.Now().Add(-time.Hour)
+	committed, err := instance.AutoCommit(time.Minute)
+	require.NoError(t, err)
+	assert.False(t, committed)
+	err = instance.InjectContinue(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "safe mode")
+}
@@ -0,0 +1,152 @@
+package session
+
+import (
+	"github.com/smtg-ai/claude-squad/config"
+	"github.com/smtg-ai/claude-squad/log"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	outputLogsDirName = "output-logs"
+	outputLogExt      = ".log"
+)
+
+// OutputLogDir returns the directory output logs are written to: output_log_dir from the config, with a leading "~/"
+// expanded, or the output-logs directory inside the config directory
+func OutputLogDir() (string, error) {
+	if dir := currentConfig().OutputLogDir; dir != "" {
+		if dir == "~" || strings.HasPrefix(dir, "~/") {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("failed to expand directory %s: %w", dir, err)
+			}
+			dir = filepath.Join(homeDir, strings.TrimPrefix(dir, "~"))
+		}
+		return dir, nil
+	}
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, outputLogsDirName), nil
+}
+
+// OutputLogPath returns the path of the output log of the session with title
+func OutputLogPath(title string) (string, error) {
+	dir, err := OutputLogDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fileNameForTitle(title)+outputLogExt), nil
+}
+
+// outputLog appends the output of an instance to a file as it changes. Once the file would grow beyond maxSize, it's
+// moved aside to a .1 file, replacing the previous one, and a new file is started.
+type outputLog struct {
+	path    string
+	maxSize int64
+	// prevLines are the lines of the last capture, to tell new output from output that was already written
+	prevLines []string
+}
+
+// write appends the lines of content, a capture of the pane, that weren't on the screen at the last capture
+func (l *outputLog) write(content string) error {
+	// The blank bottom of the pane isn't output
+	cur := strings.Split(strings.TrimRight(content, " \t\n"), "\n")
+	for idx, line := range cur {
+		cur[idx] = strings.TrimRight(line, " \t")
+	}
+	lines := newOutputLines(l.prevLines, cur)
+	l.prevLines = cur
+	if len(lines) == 0 {
+		return nil
+	}
+
+	data := strings.Join(lines, "\n") + "\n"
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create output log directory: %w", err)
+	}
+	if err := l.rotate(int64(len(data))); err != nil {
+		return fmt.Errorf("failed to rotate output log: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open output log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(data); err != nil {
+		return fmt.Errorf("failed to write output log: %w", err)
+	}
+	return nil
+}
+
+// rotate moves the log aside if writing incoming more bytes would make it larger than maxSize
+func (l *outputLog) rotate(incoming int64) error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Size() == 0 || info.Size()+incoming <= l.maxSize {
+		return nil
+	}
+	return os.Rename(l.path, l.path+".1")
+}
+
+// newOutputLines returns the lines of cur that weren't on the screen at the last capture, prev, by how far the
+// output scrolled: the longest run of lines at the top of cur that was on the screen before was already written, and
+// everything below it is new. Lines below that run in prev, e.g. a prompt the program redraws at the bottom, aren't
+// written again if cur still ends with them.
+func newOutputLines(prev []string, cur []string) []string {
+	overlap, end := 0, len(prev)
+	for start := range prev {
+		k := 0
+		for start+k < len(prev) && k < len(cur) && prev[start+k] == cur[k] {
+			k++
+		}
+		if k > 0 && k >= overlap {
+			overlap, end = k, start+k
+		}
+	}
+
+	lines := cur[overlap:]
+	if footer := prev[end:]; overlap > 0 && len(footer) > 0 && len(footer) <= len(lines) &&
+		slices.Equal(lines[len(lines)-len(footer):], footer) {
+		lines = lines[:len(lines)-len(footer)]
+	}
+	return lines
+}
+
+// SetTailOutput turns appending the output of the instance to its output log on or off. It returns the path of the
+// log.
+func (i *Instance) SetTailOutput(enabled bool) (string, error) {
+	path, err := OutputLogPath(i.Title)
+	if err != nil {
+		return "", err
+	}
+	i.TailOutput = enabled
+	i.outputLog = nil
+	return path, nil
+}
+
+// tailOutput appends the new output in content, the pane of the instance as plain text, to its output log. Failing to
+// write it is only logged.
+func (i *Instance) tailOutput(content string) {
+	if i.outputLog == nil {
+		path, err := OutputLogPath(i.Title)
+		if err != nil {
+			log.WarningLog.Printf("could not log the output of '%s': %v", i.Title, err)
+			return
+		}
+		i.outputLog = &outputLog{path: path, maxSize: currentConfig().GetOutputLogMaxSize()}
+	}
+	if err := i.outputLog.write(content); err != nil {
+		log.WarningLog.Printf("could not log the output of '%s': %v", i.Title, err)
+	}
+}
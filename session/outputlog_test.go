@@ -0,0 +1,88 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "session.log")
+	outputLog := &outputLog{path: path, maxSize: 1024}
+
+	require.NoError(t, outputLog.write("$ make\nbuilding\n> "))
+	// The output scrolls up and the prompt is redrawn below it
+	require.NoError(t, outputLog.write("building\nok   \n}\n}\n> "))
+	// Nothing changed
+	require.NoError(t, outputLog.write("building\nok\n}\n}\n> "))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "$ make\nbuilding\n>\nok\n}\n}\n", string(content))
+
+	// A log that would grow beyond the max size is moved aside
+	outputLog.maxSize = int64(len(content)) + 2
+	require.NoError(t, outputLog.write("done"))
+	rotated, err := os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(rotated))
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "done\n", string(content))
+}
+
+func TestOutputLogPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path, err := OutputLogPath("fix the bug: now!")
+	require.NoError(t, err)
+	assert.Regexp(t, `^fix-the-bug-now-[0-9a-f]{8}\.log$`, filepath.Base(path))
+	assert.Equal(t, outputLogsDirName, filepath.Base(filepath.Dir(path)))
+
+	// Titles that end up with the same name get different files
+	other, err := OutputLogPath("fix the bug now")
+	require.NoError(t, err)
+	assert.NotEqual(t, path, other)
+	plain, err := OutputLogPath("fix-the-bug-now")
+	require.NoError(t, err)
+	assert.Equal(t, "fix-the-bug-now.log", filepath.Base(plain))
+}
+
+func TestNewOutputLines(t *testing.T) {
+	tests := []struct {
+		name string
+		prev []string
+		cur  []string
+		want []string
+	}{
+		{name: "first capture", cur: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "unchanged", prev: []string{"a", "b"}, cur: []string{"a", "b"}, want: []string{}},
+		{name: "scrolled", prev: []string{"a", "b", "c"}, cur: []string{"b", "c", "d", "e"}, want: []string{"d", "e"}},
+		{
+			name: "repeated lines are new output too",
+			prev: []string{"ok", "}", ""},
+			cur:  []string{"ok", "}", "", "ok", "}", ""},
+			want: []string{"ok", "}", ""},
+		},
+		{
+			name: "prompt redrawn at the bottom",
+			prev: []string{"$ make", "building", "> "},
+			cur:  []string{"building", "ok", "> "},
+			want: []string{"ok"},
+		},
+		{name: "cleared screen", prev: []string{"a", "b"}, cur: []string{"c"}, want: []string{"c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newOutputLines(tt.prev, tt.cur)
+			if len(tt.want) == 0 {
+				assert.Empty(t, got)
+			} else {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
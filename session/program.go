@@ -0,0 +1,189 @@
+package session
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// ProgramCommand is a program split into the command and its arguments, the way a shell would split it.
+type ProgramCommand struct {
+	// Command is the executable, e.g. "claude" or "/opt/my tools/claude"
+	Command string `json:"command"`
+	// Args are the arguments, with quotes removed
+	Args []string `json:"args,omitempty"`
+}
+
+// resumeFlags are Claude Code flags that pick the conversation to start with. WithResume replaces them.
+var resumeFlags = map[string]bool{"-r": true, "--resume": true, "-c": true, "--continue": true}
+
+// WithResume returns the command with all of its args and "-r <session>" appended, so that a restarted program
+// keeps the user's flags. Any resume or continue flag from the original command is dropped in favour of the new one.
+func (p ProgramCommand) WithResume(session string) ProgramCommand {
+	args := make([]string, 0, len(p.Args)+2)
+	for idx := 0; idx < len(p.Args); idx++ {
+		arg := p.Args[idx]
+		if strings.HasPrefix(arg, "--resume=") {
+			continue
+		}
+		if resumeFlags[arg] {
+			// -r and --resume may be followed by a session ID
+			if (arg == "-r" || arg == "--resume") && idx+1 < len(p.Args) && !strings.HasPrefix(p.Args[idx+1], "-") {
+				idx++
+			}
+			continue
+		}
+		args = append(args, arg)
+	}
+	return ProgramCommand{Command: p.Command, Args: append(args, "-r", session)}
+}
+
+// hasResumeFlag returns true if the args pick the conversation to start with, see resumeFlags
+func (p ProgramCommand) hasResumeFlag() bool {
+	for _, arg := range p.Args {
+		if resumeFlags[arg] || strings.HasPrefix(arg, "--resume=") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProgram splits program into words like a POSIX shell: words are separated by whitespace, single quotes keep
+// everything literally, and double quotes and backslashes can be used to include spaces and quotes in a word.
+// Variables and other expansions are left as they are.
+func ParseProgram(program string) (ProgramCommand, error) {
+	var words []string
+	var word strings.Builder
+	// inWord is true once the current word has started. A quoted empty string ("") is still a word.
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range program {
+		switch {
+		case escaped:
+			// Within double quotes, a backslash only escapes the characters that are special there
+			if quote == '"' && !strings.ContainsRune("$`\"\\\n", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' {
+				escaped = true
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return ProgramCommand{}, fmt.Errorf("unterminated %c quote in program %q", quote, program)
+	}
+	if escaped {
+		return ProgramCommand{}, fmt.Errorf("program %q ends with a backslash", program)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	if len(words) == 0 {
+		return ProgramCommand{}, fmt.Errorf("program cannot be empty")
+	}
+	return ProgramCommand{Command: words[0], Args: words[1:]}, nil
+}
+
+// String joins the command and arguments back into a command line for the shell. Words with whitespace, quotes or
+// backslashes are single-quoted; everything else is left as is, so that e.g. ~ and $HOME are still expanded.
+func (p ProgramCommand) String() string {
+	words := make([]string, 0, len(p.Args)+1)
+	for _, word := range append([]string{p.Command}, p.Args...) {
+		words = append(words, shellQuote(word))
+	}
+	return strings.Join(words, " ")
+}
+
+// CheckProgram returns an error if the executable of program can't be found on PATH, so that a typo fails right away
+// instead of in a tmux session that dies with "command not found". Shell builtins and aliases aren't found either;
+// skip_program_check in the config turns the check off for those.
+func CheckProgram(program string) error {
+	command, err := ParseProgram(program)
+	if err != nil {
+		return err
+	}
+	if _, err := exec.LookPath(command.Command); err != nil {
+		return fmt.Errorf("program %q not found on PATH. Set skip_program_check in the config if it is a shell alias or builtin", command.Command)
+	}
+	return nil
+}
+
+// WrapCommandLine prefixes commandLine with wrapper, e.g. "firejail --private=." to run the program in a sandbox.
+// The program runs in the worktree, so relative paths in the wrapper are relative to it; {worktree} is replaced by
+// the quoted worktree path for wrappers that need it spelled out, like "docker exec -w {worktree} agents".
+func WrapCommandLine(wrapper string, commandLine string, worktreePath string) string {
+	wrapper = strings.TrimSpace(wrapper)
+	if wrapper == "" {
+		return commandLine
+	}
+	return strings.ReplaceAll(wrapper, "{worktree}", shellQuote(worktreePath)) + " " + commandLine
+}
+
+// CheckProgramWrapper returns an error if the executable of wrapper can't be found on PATH. Unlike the program, the
+// wrapper is always checked: running the program without the sandbox it's meant to be in isn't an option.
+func CheckProgramWrapper(wrapper string) error {
+	command, err := ParseProgram(wrapper)
+	if err != nil {
+		return fmt.Errorf("invalid program_wrapper: %w", err)
+	}
+	if _, err := exec.LookPath(command.Command); err != nil {
+		return fmt.Errorf("program_wrapper %q not found on PATH", command.Command)
+	}
+	return nil
+}
+
+// Name returns the base name of the command, e.g. "claude" for "/usr/local/bin/claude"
+func (p ProgramCommand) Name() string {
+	return filepath.Base(p.Command)
+}
+
+// isClaudeArg returns true if arg, following prev, names Claude Code, e.g. in "npx @anthropic-ai/claude-code@latest"
+// or "env FOO=1 claude". A "claude" that's the value of an option, like in "aider --model claude", doesn't count.
+func isClaudeArg(prev, arg string) bool {
+	name, _, _ := strings.Cut(strings.ToLower(filepath.Base(arg)), "@")
+	if name == "claude-code" {
+		return true
+	}
+	return name == "claude" && !strings.HasPrefix(prev, "-")
+}
+
+// shellQuote quotes word for the shell if it needs it
+func shellQuote(word string) string {
+	if word != "" && !strings.ContainsAny(word, " \t\n'\"\\") {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
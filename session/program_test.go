@@ -0,0 +1,173 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProgram(t *testing.T) {
+	tests := []struct {
+		name    string
+		program string
+		command string
+		args    []string
+	}{
+		{
+			name:    "command only",
+			program: "claude",
+			command: "claude",
+			args:    []string{},
+		},
+		{
+			name:    "double quoted arg",
+			program: `claude --model "claude-3.5" --flag`,
+			command: "claude",
+			args:    []string{"--model", "claude-3.5", "--flag"},
+		},
+		{
+			name:    "path with spaces",
+			program: `"/opt/my tools/claude" --verbose`,
+			command: "/opt/my tools/claude",
+			args:    []string{"--verbose"},
+		},
+		{
+			name:    "single quotes keep everything",
+			program: `aider --message 'say "hi" \n'`,
+			command: "aider",
+			args:    []string{"--message", `say "hi" \n`},
+		},
+		{
+			name:    "escaped space and quote",
+			program: `my\ agent --name "a \"quoted\" name" it\'s`,
+			command: "my agent",
+			args:    []string{"--name", `a "quoted" name`, "it's"},
+		},
+		{
+			name:    "empty quoted arg",
+			program: `codex --prompt ""`,
+			command: "codex",
+			args:    []string{"--prompt", ""},
+		},
+		{
+			name:    "extra whitespace",
+			program: "  aider   --model\tollama_chat/gemma3:1b ",
+			command: "aider",
+			args:    []string{"--model", "ollama_chat/gemma3:1b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProgram(tt.program)
+			require.NoError(t, err)
+			assert.Equal(t, tt.command, got.Command)
+			assert.Equal(t, tt.args, got.Args)
+
+			// The command line for the shell parses back to the same words
+			again, err := ParseProgram(got.String())
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
+
+func TestParseProgramErrors(t *testing.T) {
+	for _, program := range []string{"", "   ", `claude --model "claude-3.5`, `claude 'oops`, `claude \`} {
+		_, err := ParseProgram(program)
+		assert.Error(t, err, program)
+	}
+}
+
+func TestProgramCommandString(t *testing.T) {
+	command := ProgramCommand{Command: "/opt/my tools/claude", Args: []string{"--model", "claude-3.5", "it's", "~/x"}}
+	assert.Equal(t, `'/opt/my tools/claude' --model claude-3.5 'it'\''s' ~/x`, command.String())
+	assert.Equal(t, "claude", command.Name())
+}
+
+func TestProgramCommandWithResume(t *testing.T) {
+	tests := []struct {
+		program string
+		want    string
+	}{
+		{program: "claude", want: "claude -r 3"},
+		{program: `claude --model "claude-3.5" --dangerously-skip-permissions`, want: "claude --model claude-3.5 --dangerously-skip-permissions -r 3"},
+		{program: "claude --continue --verbose", want: "claude --verbose -r 3"},
+		{program: "claude -r 1 --verbose", want: "claude --verbose -r 3"},
+		{program: "claude --resume --verbose", want: "claude --verbose -r 3"},
+		{program: "claude --resume=abc --verbose", want: "claude --verbose -r 3"},
+	}
+
+	for _, tt := range tests {
+		command, err := ParseProgram(tt.program)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, command.WithResume("3").String(), tt.program)
+	}
+}
+
+func TestProgramCommandIsSaved(t *testing.T) {
+	command, err := ParseProgram(`claude --model "claude-3.5"`)
+	require.NoError(t, err)
+	instance := &Instance{Program: `claude --model "claude-3.5"`, command: &command}
+
+	data := instance.ToInstanceData()
+	require.NotNil(t, data.ProgramCommand)
+	assert.Equal(t, command, *data.ProgramCommand)
+
+	// Older saved instances don't have the structured command, so it's parsed from the program
+	restored := &Instance{Program: data.Program}
+	assert.Equal(t, command, restored.programCommand())
+}
+
+func TestCheckProgram(t *testing.T) {
+	assert.NoError(t, CheckProgram("sh -c 'echo hi'"))
+	err := CheckProgram("claide-does-not-exist --verbose")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "claide-does-not-exist")
+}
+
+func TestIsClaude(t *testing.T) {
+	assert.True(t, (&Instance{Program: "claude"}).isClaude())
+	assert.True(t, (&Instance{Program: `"/opt/my tools/claude" --model "claude-3.5"`}).isClaude())
+	assert.False(t, (&Instance{Program: "aider --model claude-3.5"}).isClaude())
+	assert.True(t, (&Instance{Program: "npx @anthropic-ai/claude-code"}).isClaude())
+	assert.True(t, (&Instance{Program: "npx -y @anthropic-ai/claude-code@latest --verbose"}).isClaude())
+	assert.True(t, (&Instance{Program: "env DISABLE_TELEMETRY=1 claude"}).isClaude())
+	assert.False(t, (&Instance{Program: "aider --model claude"}).isClaude())
+}
+
+func TestResumeLine(t *testing.T) {
+	tests := []struct {
+		program string
+		want    string
+	}{
+		{program: `claude --model "claude 3.5" ~/x`, want: `claude --model 'claude 3.5' ~/x -r 3`},
+		{program: "npx @anthropic-ai/claude-code", want: "npx @anthropic-ai/claude-code -r 3"},
+		// A conversation picked by the program is replaced
+		{program: "claude --continue --verbose", want: "claude --verbose -r 3"},
+	}
+
+	for _, tt := range tests {
+		command, err := ParseProgram(tt.program)
+		require.NoError(t, err)
+		instance := &Instance{Title: "resume", Program: tt.program, command: &command}
+		// The command line is the one the program was started with
+		if !command.hasResumeFlag() {
+			assert.Equal(t, instance.programLine()+" -r 3", instance.resumeLine("3"), tt.program)
+		}
+		assert.Equal(t, tt.want, instance.resumeLine("3"), tt.program)
+	}
+}
+
+func TestWrapCommandLine(t *testing.T) {
+	assert.Equal(t, "claude -r 3", WrapCommandLine("", "claude -r 3", "/tmp/wt"))
+	assert.Equal(t, "firejail --private=. claude -r 3", WrapCommandLine(" firejail --private=. ", "claude -r 3", "/tmp/wt"))
+	assert.Equal(t, "docker exec -w '/tmp/my wt' box claude", WrapCommandLine("docker exec -w {worktree} box", "claude", "/tmp/my wt"))
+}
+
+func TestCheckProgramWrapper(t *testing.T) {
+	assert.NoError(t, CheckProgramWrapper("env FOO=1"))
+	assert.Error(t, CheckProgramWrapper("firejail-does-not-exist --private=."))
+	assert.Error(t, CheckProgramWrapper(`firejail "--private=.`))
+}
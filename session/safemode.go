@@ -0,0 +1,20 @@
+package session
+
+import (
+	"sync/atomic"
+)
+
+// safeMode is true while claude-squad must not act on sessions by itself, see SetSafeMode
+var safeMode atomic.Bool
+
+// SetSafeMode turns safe mode on or off for all instances. In safe mode nothing is done to a session without being
+// asked: AutoYes doesn't answer prompts, the watchdog doesn't detect stalls or send continue, crashed programs aren't
+// restarted and queued prompts wait.
+func SetSafeMode(on bool) {
+	safeMode.Store(on)
+}
+
+// SafeMode returns true if safe mode is on
+func SafeMode() bool {
+	return safeMode.Load()
+}
@@ -0,0 +1,179 @@
+package session
+
+import (
+	"github.com/smtg-ai/claude-squad/config"
+	"github.com/smtg-ai/claude-squad/log"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const statsFileName = "stats.jsonl"
+
+// StatEvent is a step in the lifecycle of a session that is recorded in the stats file
+type StatEvent string
+
+const (
+	StatCreated StatEvent = "created"
+	StatPaused  StatEvent = "paused"
+	StatKilled  StatEvent = "killed"
+)
+
+// StatRecord is a line of the stats file. The stats file never leaves this machine.
+type StatRecord struct {
+	Event     StatEvent `json:"event"`
+	Time      time.Time `json:"time"`
+	Title     string    `json:"title"`
+	Program   string    `json:"program,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Added and Removed are the lines changed by the session so far
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// StatsPath returns the path of the stats file
+func StatsPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, statsFileName), nil
+}
+
+// recordStat appends a record of event to the stats file, unless disable_local_stats is set. Failing to record it is
+// only logged.
+func (i *Instance) recordStat(event StatEvent) {
+	if currentConfig().DisableLocalStats {
+		return
+	}
+	path, err := StatsPath()
+	if err != nil {
+		log.WarningLog.Printf("could not record stats: %v", err)
+		return
+	}
+	record := StatRecord{Event: event, Time: time.Now(), Title: i.Title, Program: i.Program, CreatedAt: i.CreatedAt}
+	if i.diffStats != nil {
+		record.Added = i.diffStats.Added
+		record.Removed = i.diffStats.Removed
+	}
+	if err := appendStat(path, record); err != nil {
+		log.WarningLog.Printf("could not record stats: %v", err)
+	}
+}
+
+// appendStat appends record to the stats file at path as a line of JSON
+func appendStat(path string, record StatRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Start on a new line if the last write was cut off, so that only the broken record is lost
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// ReadStats reads the records of the stats file at path. Lines that don't parse, e.g. one cut off by a crash, are
+// skipped. A missing file has no records.
+func ReadStats(path string) ([]StatRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open stats file: %w", err)
+	}
+	defer file.Close()
+
+	var records []StatRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record StatRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err == nil {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stats file: %w", err)
+	}
+	return records, nil
+}
+
+// DayStats is the number of sessions created on a day
+type DayStats struct {
+	// Day is the local date, e.g. "2025-01-02"
+	Day      string
+	Sessions int
+}
+
+// StatsSummary summarizes the stats file
+type StatsSummary struct {
+	// Days are the days sessions were created on, oldest first
+	Days    []DayStats
+	Created int
+	Killed  int
+	// AverageLifetime is the average time from creating to killing a session, over the killed sessions
+	AverageLifetime time.Duration
+	// Added and Removed are the lines changed, taking the last recorded diff of each session
+	Added   int
+	Removed int
+}
+
+// SummarizeStats summarizes stats records
+func SummarizeStats(records []StatRecord) StatsSummary {
+	type sessionKey struct {
+		title     string
+		createdAt int64
+	}
+	var summary StatsSummary
+	perDay := make(map[string]int)
+	lastDiff := make(map[sessionKey]StatRecord)
+	var lifetime time.Duration
+
+	for _, record := range records {
+		switch record.Event {
+		case StatCreated:
+			summary.Created++
+			perDay[record.Time.Local().Format("2006-01-02")]++
+		case StatKilled:
+			summary.Killed++
+			lifetime += record.Time.Sub(record.CreatedAt)
+			fallthrough
+		case StatPaused:
+			lastDiff[sessionKey{record.Title, record.CreatedAt.UnixNano()}] = record
+		}
+	}
+
+	for day, sessions := range perDay {
+		summary.Days = append(summary.Days, DayStats{Day: day, Sessions: sessions})
+	}
+	sort.Slice(summary.Days, func(a, b int) bool {
+		return summary.Days[a].Day < summary.Days[b].Day
+	})
+	if summary.Killed > 0 {
+		summary.AverageLifetime = lifetime / time.Duration(summary.Killed)
+	}
+	for _, record := range lastDiff {
+		summary.Added += record.Added
+		summary.Removed += record.Removed
+	}
+	return summary
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.jsonl")
+	records, err := ReadStats(path)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	created := time.Date(2025, 1, 2, 10, 0, 0, 0, time.Local)
+	require.NoError(t, appendStat(path, StatRecord{Event: StatCreated, Time: created, Title: "a", CreatedAt: created}))
+	// A line cut off by a crash is skipped
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"event":"kil`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	require.NoError(t, appendStat(path, StatRecord{Event: StatKilled, Time: created.Add(time.Hour), Title: "a", CreatedAt: created}))
+
+	records, err = ReadStats(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, StatKilled, records[1].Event)
+}
+
+func TestSummarizeStats(t *testing.T) {
+	day1 := time.Date(2025, 1, 2, 10, 0, 0, 0, time.Local)
+	day2 := day1.Add(24 * time.Hour)
+	records := []StatRecord{
+		{Event: StatCreated, Time: day1, Title: "a", CreatedAt: day1},
+		{Event: StatCreated, Time: day1.Add(time.Hour), Title: "b", CreatedAt: day1.Add(time.Hour)},
+		{Event: StatPaused, Time: day1.Add(2 * time.Hour), Title: "a", CreatedAt: day1, Added: 5, Removed: 1},
+		{Event: StatKilled, Time: day1.Add(4 * time.Hour), Title: "a", CreatedAt: day1, Added: 10, Removed: 2},
+		{Event: StatCreated, Time: day2, Title: "a", CreatedAt: day2},
+		{Event: StatKilled, Time: day2.Add(2 * time.Hour), Title: "a", CreatedAt: day2, Added: 3},
+		{Event: StatPaused, Time: day2.Add(3 * time.Hour), Title: "b", CreatedAt: day1.Add(time.Hour), Removed: 7},
+	}
+
+	summary := SummarizeStats(records)
+	assert.Equal(t, []DayStats{{Day: "2025-01-02", Sessions: 2}, {Day: "2025-01-03", Sessions: 1}}, summary.Days)
+	assert.Equal(t, 3, summary.Created)
+	assert.Equal(t, 2, summary.Killed)
+	assert.Equal(t, 3*time.Hour, summary.AverageLifetime)
+	// Only the last diff of each session counts
+	assert.Equal(t, 13, summary.Added)
+	assert.Equal(t, 9, summary.Removed)
+}
@@ -2,8 +2,12 @@ package session
 
 import (
 	"github.com/smtg-ai/claude-squad/config"
+	"github.com/smtg-ai/claude-squad/log"
+	"github.com/smtg-ai/claude-squad/session/git"
 	"encoding/json"
 	"fmt"
+	"os"
+	"sync"
 	"time"
 )
 
@@ -18,8 +22,18 @@ type InstanceData struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	AutoYes   bool      `json:"auto_yes"`
+	Tag       string    `json:"tag,omitempty"`
+	// Remote is the git remote the branch is pushed to. Empty means origin.
+	Remote string `json:"remote,omitempty"`
+	// TailOutput is true if the output is appended to the output log of the instance
+	TailOutput bool `json:"tail_output,omitempty"`
+	// ViewedDiffHash is the hash of the diff when the user last viewed the instance
+	ViewedDiffHash string `json:"viewed_diff_hash,omitempty"`
 
 	Program   string          `json:"program"`
+	// ProgramCommand is Program split into the command and its args. It's used when restarting, so that the args
+	// survive. Older saved instances don't have it.
+	ProgramCommand *ProgramCommand `json:"program_command,omitempty"`
 	Worktree  GitWorktreeData `json:"worktree"`
 	DiffStats DiffStatsData   `json:"diff_stats"`
 	
@@ -32,6 +46,9 @@ type InstanceData struct {
 	StallCount              int           `json:"stall_count"`
 	RestartAttempts         int           `json:"restart_attempts"`
 	LastRestartTime         time.Time     `json:"last_restart_time"`
+
+	// PromptQueue holds the prompts waiting to be sent to the instance
+	PromptQueue []string `json:"prompt_queue,omitempty"`
 }
 
 // GitWorktreeData represents the serializable data of a GitWorktree
@@ -41,18 +58,24 @@ type GitWorktreeData struct {
 	SessionName   string `json:"session_name"`
 	BranchName    string `json:"branch_name"`
 	BaseCommitSHA string `json:"base_commit_sha"`
+	// ExistingBranch is true if the branch was imported rather than created by claude-squad
+	ExistingBranch bool `json:"existing_branch,omitempty"`
 }
 
 // DiffStatsData represents the serializable data of a DiffStats
 type DiffStatsData struct {
 	Added   int    `json:"added"`
 	Removed int    `json:"removed"`
-	Content string `json:"content"`
+	Content string `json:"content,omitempty"`
 }
 
 // Storage handles saving and loading instances using the state interface
 type Storage struct {
 	state config.InstanceStorage
+	// compact drops the diff content when saving
+	compact bool
+	// mu serializes saves and load-modify-save sequences so concurrent callers can't interleave their writes
+	mu sync.Mutex
 }
 
 // NewStorage creates a new storage instance
@@ -62,13 +85,31 @@ func NewStorage(state config.InstanceStorage) (*Storage, error) {
 	}, nil
 }
 
+// SetCompact sets whether the diff content is left out when saving. Diffs can be large, so dropping them keeps the
+// state file small and fast to load. The line counts are always saved, and so is the diff of paused instances, which
+// can't be recomputed without their worktree.
+func (s *Storage) SetCompact(compact bool) {
+	s.compact = compact
+}
+
 // SaveInstances saves the list of instances to disk
 func (s *Storage) SaveInstances(instances []*Instance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveInstances(instances)
+}
+
+// saveInstances saves the list of instances to disk. The caller must hold s.mu.
+func (s *Storage) saveInstances(instances []*Instance) error {
 	// Convert instances to InstanceData
 	data := make([]InstanceData, 0)
 	for _, instance := range instances {
 		if instance.Started() {
-			data = append(data, instance.ToInstanceData())
+			instanceData := instance.ToInstanceData()
+			if s.compact && !instance.Paused() {
+				instanceData.DiffStats.Content = ""
+			}
+			data = append(data, instanceData)
 		}
 	}
 
@@ -83,6 +124,13 @@ func (s *Storage) SaveInstances(instances []*Instance) error {
 
 // LoadInstances loads the list of instances from disk
 func (s *Storage) LoadInstances() ([]*Instance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadInstances()
+}
+
+// loadInstances loads the list of instances from disk. The caller must hold s.mu.
+func (s *Storage) loadInstances() ([]*Instance, error) {
 	jsonData := s.state.GetInstances()
 
 	var instancesData []InstanceData
@@ -102,9 +150,46 @@ func (s *Storage) LoadInstances() ([]*Instance, error) {
 	return instances, nil
 }
 
+// Titles returns the titles of the stored instances. Unlike LoadInstances, it doesn't start anything.
+func (s *Storage) Titles() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var instancesData []InstanceData
+	if err := json.Unmarshal(s.state.GetInstances(), &instancesData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal instances: %w", err)
+	}
+	titles := make([]string, len(instancesData))
+	for i, data := range instancesData {
+		titles[i] = data.Title
+	}
+	return titles, nil
+}
+
+// FindInstanceData returns the stored data of the instance with the given title. Unlike LoadInstances, it doesn't
+// start anything, so it's safe to use outside the TUI.
+func (s *Storage) FindInstanceData(title string) (InstanceData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var instancesData []InstanceData
+	if err := json.Unmarshal(s.state.GetInstances(), &instancesData); err != nil {
+		return InstanceData{}, fmt.Errorf("failed to unmarshal instances: %w", err)
+	}
+	for _, data := range instancesData {
+		if data.Title == title {
+			return data, nil
+		}
+	}
+	return InstanceData{}, fmt.Errorf("instance not found: %s", title)
+}
+
 // DeleteInstance removes an instance from storage
 func (s *Storage) DeleteInstance(title string) error {
-	instances, err := s.LoadInstances()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instances, err := s.loadInstances()
 	if err != nil {
 		return fmt.Errorf("failed to load instances: %w", err)
 	}
@@ -124,12 +209,15 @@ func (s *Storage) DeleteInstance(title string) error {
 		return fmt.Errorf("instance not found: %s", title)
 	}
 
-	return s.SaveInstances(newInstances)
+	return s.saveInstances(newInstances)
 }
 
 // UpdateInstance updates an existing instance in storage
 func (s *Storage) UpdateInstance(instance *Instance) error {
-	instances, err := s.LoadInstances()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instances, err := s.loadInstances()
 	if err != nil {
 		return fmt.Errorf("failed to load instances: %w", err)
 	}
@@ -149,10 +237,155 @@ func (s *Storage) UpdateInstance(instance *Instance) error {
 		return fmt.Errorf("instance not found: %s", data.Title)
 	}
 
-	return s.SaveInstances(instances)
+	return s.saveInstances(instances)
 }
 
 // DeleteAllInstances removes all stored instances
 func (s *Storage) DeleteAllInstances() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.state.DeleteAllInstances()
 }
+
+// exportVersion is the version of the file format written by ExportState
+const exportVersion = 1
+
+// ExportedState is the file written by ExportState. It holds everything needed to recreate the instances on another
+// machine: titles, programs, branch names and base commits.
+type ExportedState struct {
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Instances  []InstanceData `json:"instances"`
+}
+
+// ExportState writes all stored instances to path so that they can be recreated on another machine with ImportState.
+// Only committed work travels with the branches, so instances should be paused (which commits their changes) and
+// their branches pushed before exporting.
+func (s *Storage) ExportState(path string) (*ExportedState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var instancesData []InstanceData
+	if err := json.Unmarshal(s.state.GetInstances(), &instancesData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal instances: %w", err)
+	}
+	for i := range instancesData {
+		// The diff is recomputed on the other machine
+		instancesData[i].DiffStats.Content = ""
+	}
+
+	exported := &ExportedState{
+		Version:    exportVersion,
+		ExportedAt: time.Now(),
+		Instances:  instancesData,
+	}
+	data, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal exported state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return exported, nil
+}
+
+// ImportState recreates the instances exported to path as paused instances of the repository at repoPath. Their
+// branches must exist in that repository, locally or on origin. Worktrees are set up fresh when the instances are
+// resumed. Instances whose title is already in use are skipped. It returns the titles of the imported instances.
+func (s *Storage) ImportState(path string, repoPath string) ([]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var exported ExportedState
+	if err := json.Unmarshal(raw, &exported); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if exported.Version != exportVersion {
+		return nil, fmt.Errorf("unsupported export version %d", exported.Version)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var instancesData []InstanceData
+	if err := json.Unmarshal(s.state.GetInstances(), &instancesData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal instances: %w", err)
+	}
+	titles := make(map[string]bool, len(instancesData))
+	for _, data := range instancesData {
+		titles[data.Title] = true
+	}
+
+	// Resolve every instance before changing anything, so that a missing branch doesn't leave a partial import.
+	imported := make([]InstanceData, 0, len(exported.Instances))
+	trees := make([]*git.GitWorktree, 0, len(exported.Instances))
+	for _, data := range exported.Instances {
+		if titles[data.Title] {
+			continue
+		}
+		tree, err := git.NewGitWorktreeForImport(repoPath, data.Title, data.Worktree.BranchName,
+			data.Worktree.BaseCommitSHA, data.Worktree.ExistingBranch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import instance %s: %w", data.Title, err)
+		}
+
+		data.Path = tree.GetRepoPath()
+		data.Status = Paused
+		data.Worktree = GitWorktreeData{
+			RepoPath:       tree.GetRepoPath(),
+			WorktreePath:   tree.GetWorktreePath(),
+			SessionName:    data.Title,
+			BranchName:     tree.GetBranchName(),
+			BaseCommitSHA:  tree.GetBaseCommitSHA(),
+			ExistingBranch: tree.IsExistingBranch(),
+		}
+		// Runtime state from the other machine doesn't apply here
+		data.ContinuousMode = false
+		data.ContinuousModeStartTime = time.Time{}
+		data.ContinuousModeDuration = 0
+		data.StallCount = 0
+		data.RestartAttempts = 0
+		data.LastRestartTime = time.Time{}
+
+		titles[data.Title] = true
+		imported = append(imported, data)
+		trees = append(trees, tree)
+	}
+
+	// Then create the branches that are only on origin, and delete them again if the import fails after all
+	var created []*git.GitWorktree
+	rollback := func() {
+		for _, tree := range created {
+			if err := tree.DeleteImportBranch(); err != nil {
+				log.ErrorLog.Printf("failed to roll back import: %v", err)
+			}
+		}
+	}
+	for i, tree := range trees {
+		ok, err := tree.CreateImportBranch()
+		if err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed to import instance %s: %w", imported[i].Title, err)
+		}
+		if ok {
+			created = append(created, tree)
+		}
+	}
+
+	jsonData, err := json.Marshal(append(instancesData, imported...))
+	if err != nil {
+		rollback()
+		return nil, fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	if err := s.state.SaveInstances(jsonData); err != nil {
+		rollback()
+		return nil, err
+	}
+
+	importedTitles := make([]string, len(imported))
+	for i, data := range imported {
+		importedTitles[i] = data.Title
+	}
+	return importedTitles, nil
+}
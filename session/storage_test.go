@@ -0,0 +1,196 @@
+package session
+
+import (
+	"github.com/smtg-ai/claude-squad/config"
+	"github.com/smtg-ai/claude-squad/log"
+	"github.com/smtg-ai/claude-squad/session/git"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	log.Initialize(false)
+	defer log.Close()
+	os.Exit(m.Run())
+}
+
+// TestStorageConcurrentSaves hammers the storage with concurrent saves and updates. Run it with -race.
+func TestStorageConcurrentSaves(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	storage, err := NewStorage(config.DefaultState())
+	require.NoError(t, err)
+
+	// Paused instances can be loaded back without starting tmux sessions.
+	instances := make([]*Instance, 5)
+	for i := range instances {
+		instances[i] = &Instance{
+			Title:   fmt.Sprintf("instance-%d", i),
+			Program: "claude",
+			Status:  Paused,
+			started: true,
+		}
+	}
+	require.NoError(t, storage.SaveInstances(instances))
+
+	const workers = 20
+	const iterations = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*iterations)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				if w%2 == 0 {
+					errs <- storage.SaveInstances(instances)
+				} else {
+					errs <- storage.UpdateInstance(instances[w%len(instances)])
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// The state file on disk must be complete, valid JSON containing every instance.
+	configDir, err := config.GetConfigDir()
+	require.NoError(t, err)
+	raw, err := os.ReadFile(filepath.Join(configDir, config.StateFileName))
+	require.NoError(t, err)
+
+	var state config.State
+	require.NoError(t, json.Unmarshal(raw, &state))
+	var data []InstanceData
+	require.NoError(t, json.Unmarshal(state.InstancesData, &data))
+	assert.Len(t, data, len(instances))
+
+	// No temporary files are left behind.
+	leftovers, err := filepath.Glob(filepath.Join(configDir, config.StateFileName+".tmp-*"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+
+	loaded, err := storage.LoadInstances()
+	require.NoError(t, err)
+	assert.Len(t, loaded, len(instances))
+}
+
+func TestCompactStorageKeepsPausedDiffs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	storage, err := NewStorage(config.DefaultState())
+	require.NoError(t, err)
+	storage.SetCompact(true)
+
+	running := &Instance{Title: "running", Program: "claude", Status: Running, started: true,
+		diffStats: &git.DiffStats{Added: 1, Content: "+running"}}
+	paused := &Instance{Title: "paused", Program: "claude", Status: Paused, started: true,
+		diffStats: &git.DiffStats{Added: 1, Content: "+paused"}}
+	require.NoError(t, storage.SaveInstances([]*Instance{running, paused}))
+
+	data, err := storage.FindInstanceData("running")
+	require.NoError(t, err)
+	assert.Empty(t, data.DiffStats.Content)
+	assert.Equal(t, 1, data.DiffStats.Added)
+
+	data, err = storage.FindInstanceData("paused")
+	require.NoError(t, err)
+	assert.Equal(t, "+paused", data.DiffStats.Content)
+}
+
+func TestImportStateChecksAllBranchesFirst(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	run := func(dir string, args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	originDir := t.TempDir()
+	run(originDir, "init")
+	run(originDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial")
+	run(originDir, "branch", "on-origin")
+	repoDir := filepath.Join(t.TempDir(), "clone")
+	run(originDir, "clone", originDir, repoDir)
+
+	// The first branch is only on origin, the second one doesn't exist at all
+	exported := ExportedState{Version: exportVersion, Instances: []InstanceData{
+		{Title: "first", Program: "claude", Worktree: GitWorktreeData{BranchName: "on-origin"}},
+		{Title: "second", Program: "claude", Worktree: GitWorktreeData{BranchName: "missing"}},
+	}}
+	raw, err := json.Marshal(exported)
+	require.NoError(t, err)
+	exportPath := filepath.Join(t.TempDir(), "export.json")
+	require.NoError(t, os.WriteFile(exportPath, raw, 0644))
+
+	storage, err := NewStorage(config.DefaultState())
+	require.NoError(t, err)
+	_, err = storage.ImportState(exportPath, repoDir)
+	require.ErrorContains(t, err, "missing")
+
+	out, err := exec.Command("git", "-C", repoDir, "branch", "--list", "on-origin").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Empty(t, strings.TrimSpace(string(out)), "no branch should be created by a failed import")
+
+	// Without the missing branch, the branch on origin is created locally
+	exported.Instances = exported.Instances[:1]
+	raw, err = json.Marshal(exported)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(exportPath, raw, 0644))
+	titles, err := storage.ImportState(exportPath, repoDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, titles)
+	out, err = exec.Command("git", "-C", repoDir, "branch", "--list", "on-origin").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "on-origin")
+}
+
+func TestFindInstanceData(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	storage, err := NewStorage(config.DefaultState())
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveInstances([]*Instance{
+		{Title: "first", Program: "claude", Status: Paused, started: true},
+		{Title: "second", Program: "aider", Status: Paused, started: true},
+	}))
+
+	data, err := storage.FindInstanceData("second")
+	require.NoError(t, err)
+	assert.Equal(t, "aider", data.Program)
+
+	_, err = storage.FindInstanceData("third")
+	assert.ErrorContains(t, err, "instance not found")
+}
+
+func TestStorageTitles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	storage, err := NewStorage(config.DefaultState())
+	require.NoError(t, err)
+	titles, err := storage.Titles()
+	require.NoError(t, err)
+	assert.Empty(t, titles)
+
+	require.NoError(t, storage.SaveInstances([]*Instance{
+		{Title: "first", Program: "claude", Status: Paused, started: true},
+		{Title: "second", Program: "aider", Status: Paused, started: true},
+	}))
+	titles, err = storage.Titles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, titles)
+}
@@ -35,6 +35,11 @@ type TmuxSession struct {
 	// cmdExec is used to execute commands in the tmux session.
 	cmdExec cmd.Executor
 
+	// programName is the program as configured, if the command line tmux runs differs from it. Set by SetProgramName.
+	programName string
+	// isReady reports whether the pane content shows the program is idle. Set by SetReadyCheck.
+	isReady func(content string) bool
+
 	// Initialized by Start or Restore
 	//
 	// ptmx is a PTY is running the tmux attach command. This can be resized to change the
@@ -60,12 +65,35 @@ const TmuxPrefix = "claudesquad_"
 
 var whiteSpaceRegex = regexp.MustCompile(`\s+`)
 
+// maxTmuxNameLength is the maximum number of characters of the title used in the tmux session name
+const maxTmuxNameLength = 64
+
+// truncatedNameHashLength is how many hex digits of the hash of the full title are appended to a truncated name
+const truncatedNameHashLength = 8
+
+// toClaudeSquadTmuxName returns the tmux session name for a title. Long titles are truncated and end in a short hash
+// of the full title instead, so that titles with the same beginning still get different sessions.
 func toClaudeSquadTmuxName(str string) string {
 	str = whiteSpaceRegex.ReplaceAllString(str, "")
 	str = strings.ReplaceAll(str, ".", "_") // tmux replaces all . with _
+	if runes := []rune(str); len(runes) > maxTmuxNameLength {
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(str)))[:truncatedNameHashLength]
+		str = string(runes[:maxTmuxNameLength-truncatedNameHashLength-1]) + "-" + hash
+	}
 	return fmt.Sprintf("%s%s", TmuxPrefix, str)
 }
 
+// sessionTarget returns the -t argument for commands that target the session. Without the leading "=", tmux falls
+// back to prefix matching, so a command for "claudesquad_foo" could hit the user's "claudesquad_foobar" session.
+func (t *TmuxSession) sessionTarget() string {
+	return "=" + t.sanitizedName
+}
+
+// paneTarget returns the -t argument for commands that target the active pane of the session
+func (t *TmuxSession) paneTarget() string {
+	return t.sessionTarget() + ":"
+}
+
 // NewTmuxSession creates a new TmuxSession with the given name and program.
 func NewTmuxSession(name string, program string) *TmuxSession {
 	return newTmuxSession(name, program, MakePtyFactory(), cmd.MakeExecutor())
@@ -95,7 +123,7 @@ func (t *TmuxSession) Start(workDir string) error {
 	if err != nil {
 		// Cleanup any partially created session if any exists.
 		if t.DoesSessionExist() {
-			cleanupCmd := exec.Command("tmux", "kill-session", "-t", t.sanitizedName)
+			cleanupCmd := exec.Command("tmux", "kill-session", "-t", t.sessionTarget())
 			if cleanupErr := t.cmdExec.Run(cleanupCmd); cleanupErr != nil {
 				err = fmt.Errorf("%v (cleanup error: %v)", err, cleanupErr)
 			}
@@ -128,11 +156,11 @@ func (t *TmuxSession) Start(workDir string) error {
 		return fmt.Errorf("error restoring tmux session: %w", err)
 	}
 
-	if t.program == ProgramClaude || strings.HasPrefix(t.program, ProgramAider) {
+	if program := t.getProgramName(); program == ProgramClaude || strings.HasPrefix(program, ProgramAider) {
 		searchString := "Do you trust the files in this folder?"
 		tapFunc := t.TapEnter
 		iterations := 5
-		if t.program != ProgramClaude {
+		if program != ProgramClaude {
 			searchString = "Open documentation url for more info"
 			tapFunc = t.TapDAndEnter
 			iterations = 10 // Aider takes longer to start :/
@@ -157,7 +185,7 @@ func (t *TmuxSession) Start(workDir string) error {
 
 // Restore attaches to an existing session and restores the window size
 func (t *TmuxSession) Restore() error {
-	ptmx, err := t.ptyFactory.Start(exec.Command("tmux", "attach-session", "-t", t.sanitizedName))
+	ptmx, err := t.ptyFactory.Start(exec.Command("tmux", "attach-session", "-t", t.sessionTarget()))
 	if err != nil {
 		return fmt.Errorf("error opening PTY: %w", err)
 	}
@@ -185,6 +213,7 @@ func (m *statusMonitor) hash(s string) []byte {
 
 // TapEnter sends an enter keystroke to the tmux pane.
 func (t *TmuxSession) TapEnter() error {
+	t.leaveCopyMode()
 	_, err := t.ptmx.Write([]byte{0x0D})
 	if err != nil {
 		return fmt.Errorf("error sending enter keystroke to PTY: %w", err)
@@ -201,11 +230,143 @@ func (t *TmuxSession) TapDAndEnter() error {
 	return nil
 }
 
+// TapCtrlC sends a ctrl+c keystroke to the tmux pane.
+func (t *TmuxSession) TapCtrlC() error {
+	_, err := t.ptmx.Write([]byte{0x03})
+	if err != nil {
+		return fmt.Errorf("error sending ctrl+c keystroke to PTY: %w", err)
+	}
+	return nil
+}
+
+// TapEsc sends an escape keystroke to the tmux pane.
+func (t *TmuxSession) TapEsc() error {
+	_, err := t.ptmx.Write([]byte{0x1B})
+	if err != nil {
+		return fmt.Errorf("error sending escape keystroke to PTY: %w", err)
+	}
+	return nil
+}
+
 func (t *TmuxSession) SendKeys(keys string) error {
+	t.leaveCopyMode()
 	_, err := t.ptmx.Write([]byte(keys))
 	return err
 }
 
+// SendTmuxKeys sends keys to the pane with tmux send-keys, so each of them is a key in tmux notation, e.g. "C-c",
+// "Escape" or "Up". Anything that isn't a key name is typed as it is.
+func (t *TmuxSession) SendTmuxKeys(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	t.leaveCopyMode()
+	args := append([]string{"send-keys", "-t", t.paneTarget()}, keys...)
+	if err := t.cmdExec.Run(exec.Command("tmux", args...)); err != nil {
+		return fmt.Errorf("error sending keys to tmux session: %w", err)
+	}
+	return nil
+}
+
+// pingPollInterval is how often Ping checks whether the pane changed
+const pingPollInterval = 100 * time.Millisecond
+
+// Ping checks that the program in the pane is listening. It types a space and waits up to timeout for the pane to
+// change, then erases the space with a backspace. A program that's busy redrawing the pane counts as responsive too.
+// A hung program doesn't redraw; it gets the space and the backspace together once it recovers, so nothing is left
+// behind. A program showing a permission prompt is waiting for the user, and menus don't redraw on a space or might
+// act on it, so it counts as responsive without typing anything.
+func (t *TmuxSession) Ping(timeout time.Duration) (bool, error) {
+	before, err := t.CapturePaneContent()
+	if err != nil {
+		return false, err
+	}
+	if t.hasPrompt(before) {
+		return true, nil
+	}
+	if err := t.SendTmuxKeys([]string{"Space"}); err != nil {
+		return false, err
+	}
+	// The result doesn't depend on erasing the space, so a failure here isn't reported
+	defer func() { _ = t.SendTmuxKeys([]string{"BSpace"}) }()
+
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		time.Sleep(pingPollInterval)
+		after, err := t.CapturePaneContent()
+		if err != nil {
+			return false, err
+		}
+		if after != before {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// leaveCopyMode takes the pane out of copy mode, e.g. after scrolling back through it while attached. In copy mode,
+// tmux handles the keys itself, so prompts and watchdog continues would never reach the program. Failures are only
+// logged: the keys are sent either way.
+func (t *TmuxSession) leaveCopyMode() {
+	output, err := t.cmdExec.Output(exec.Command("tmux", "display-message", "-p", "-t", t.paneTarget(), "#{pane_in_mode}"))
+	if err != nil || strings.TrimSpace(string(output)) != "1" {
+		return
+	}
+	if err := t.cmdExec.Run(exec.Command("tmux", "send-keys", "-X", "-t", t.paneTarget(), "cancel")); err != nil {
+		log.WarningLog.Printf("could not leave copy mode in %s: %v", t.sanitizedName, err)
+	}
+}
+
+// PasteText pastes text into the pane through a tmux buffer instead of typing it. Typed newlines would submit the
+// prompt line by line, so multi-line text has to be pasted. tmux wraps the paste in bracketed paste sequences if the
+// program asked for them, so the program sees a single paste.
+func (t *TmuxSession) PasteText(text string) error {
+	t.leaveCopyMode()
+	bufferName := t.sanitizedName + "_paste"
+
+	loadCmd := exec.Command("tmux", "load-buffer", "-b", bufferName, "-")
+	loadCmd.Stdin = strings.NewReader(text)
+	if err := t.cmdExec.Run(loadCmd); err != nil {
+		return fmt.Errorf("error loading text into tmux buffer: %w", err)
+	}
+
+	// -d deletes the buffer once it's pasted
+	pasteCmd := exec.Command("tmux", "paste-buffer", "-p", "-d", "-b", bufferName, "-t", t.paneTarget())
+	if err := t.cmdExec.Run(pasteCmd); err != nil {
+		return fmt.Errorf("error pasting tmux buffer: %w", err)
+	}
+	return nil
+}
+
+// SetProgramName sets the program as configured, for when the command line the session runs is different, e.g. inside
+// a program wrapper or with resume flags. It's what claude and aider are recognized by.
+func (t *TmuxSession) SetProgramName(program string) {
+	t.programName = program
+}
+
+// getProgramName returns the program as configured, which defaults to the command line the session runs
+func (t *TmuxSession) getProgramName() string {
+	if t.programName != "" {
+		return t.programName
+	}
+	return t.program
+}
+
+// hasPrompt returns true if content shows a permission prompt of the program. Only claude and aider prompts are known.
+func (t *TmuxSession) hasPrompt(content string) bool {
+	if program := t.getProgramName(); program == ProgramClaude {
+		return strings.Contains(content, "No, and tell Claude what to do differently")
+	} else if strings.HasPrefix(program, ProgramAider) {
+		return strings.Contains(content, "(Y)es/(N)o/(D)on't ask again")
+	}
+	return false
+}
+
+// SetReadyCheck makes HasUpdated report no update whenever isReady returns true for the pane content, whether it
+// changed or not. It's for programs with a distinctive idle prompt that keep redrawing the pane while idle.
+func (t *TmuxSession) SetReadyCheck(isReady func(content string) bool) {
+	t.isReady = isReady
+}
+
 // HasUpdated checks if the tmux pane content has changed since the last tick. It also returns true if
 // the tmux pane has a prompt for aider or claude code.
 func (t *TmuxSession) HasUpdated() (updated bool, hasPrompt bool) {
@@ -214,19 +375,21 @@ func (t *TmuxSession) HasUpdated() (updated bool, hasPrompt bool) {
 		log.ErrorLog.Printf("error capturing pane content in status monitor: %v", err)
 		return false, false
 	}
+	return t.CheckContent(content)
+}
 
-	// Only set hasPrompt for claude and aider. Use these strings to check for a prompt.
-	if t.program == ProgramClaude {
-		hasPrompt = strings.Contains(content, "No, and tell Claude what to do differently")
-	} else if strings.HasPrefix(t.program, ProgramAider) {
-		hasPrompt = strings.Contains(content, "(Y)es/(N)o/(D)on't ask again")
-	}
+// CheckContent is HasUpdated for pane content that was captured already, e.g. in the background
+func (t *TmuxSession) CheckContent(content string) (updated bool, hasPrompt bool) {
+	hasPrompt = t.hasPrompt(content)
 
-	if !bytes.Equal(t.monitor.hash(content), t.monitor.prevOutputHash) {
-		t.monitor.prevOutputHash = t.monitor.hash(content)
-		return true, hasPrompt
+	if hash := t.monitor.hash(content); !bytes.Equal(hash, t.monitor.prevOutputHash) {
+		t.monitor.prevOutputHash = hash
+		updated = true
 	}
-	return false, hasPrompt
+	if t.isReady != nil && t.isReady(content) {
+		return false, hasPrompt
+	}
+	return updated, hasPrompt
 }
 
 func (t *TmuxSession) Attach() (chan struct{}, error) {
@@ -306,6 +469,58 @@ func (t *TmuxSession) Attach() (chan struct{}, error) {
 	return t.attachCh, nil
 }
 
+// InsideTmux returns true if claude-squad itself is running inside a tmux session
+func InsideTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// AttachSplit opens the session in a new pane split next to the pane running claude-squad instead of taking over the
+// whole terminal. It only works when claude-squad is running inside tmux. The session is attached as a nested client,
+// so closing the pane or detaching from it returns to claude-squad.
+func (t *TmuxSession) AttachSplit() error {
+	tmuxEnv := os.Getenv("TMUX")
+	if tmuxEnv == "" {
+		return fmt.Errorf("cannot attach in a split pane: not running inside tmux")
+	}
+
+	// $TMUX is "socket_path,pid,session_index". The nested client has to talk to the same server, but tmux refuses to
+	// attach while $TMUX is set, so pass the socket explicitly and unset the variable.
+	socketPath := strings.Split(tmuxEnv, ",")[0]
+	args := []string{"split-window", "-h"}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		args = append(args, "-t", pane)
+	}
+	args = append(args, "env", "-u", "TMUX", "tmux", "-S", socketPath, "attach-session", "-t", t.sessionTarget())
+
+	if err := t.cmdExec.Run(exec.Command("tmux", args...)); err != nil {
+		return fmt.Errorf("error opening split pane for session %s: %w", t.sanitizedName, err)
+	}
+	return nil
+}
+
+// AttachTerminal attaches the terminal to the tmux session of the instance with the given title, outside of
+// claude-squad. Inside tmux, the current client switches to the session instead, since tmux doesn't nest clients.
+func AttachTerminal(title string) error {
+	t := NewTmuxSession(title, "")
+	if !t.DoesSessionExist() {
+		return fmt.Errorf("tmux session for '%s' is not running", title)
+	}
+
+	var cmd *exec.Cmd
+	if os.Getenv("TMUX") != "" {
+		cmd = exec.Command("tmux", "switch-client", "-t", t.sessionTarget())
+	} else {
+		cmd = exec.Command("tmux", "attach-session", "-t", t.sessionTarget())
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error attaching to tmux session %s: %w", t.sanitizedName, err)
+	}
+	return nil
+}
+
 // Detach disconnects from the current tmux session. It panics if detaching fails. At the moment, there's no
 // way to recover from a failed detach.
 func (t *TmuxSession) Detach() {
@@ -353,7 +568,7 @@ func (t *TmuxSession) Close() error {
 		t.ptmx = nil
 	}
 
-	cmd := exec.Command("tmux", "kill-session", "-t", t.sanitizedName)
+	cmd := exec.Command("tmux", "kill-session", "-t", t.sessionTarget())
 	if err := t.cmdExec.Run(cmd); err != nil {
 		errs = append(errs, fmt.Errorf("error killing tmux session: %w", err))
 	}
@@ -389,15 +604,26 @@ func (t *TmuxSession) updateWindowSize(cols, rows int) error {
 }
 
 func (t *TmuxSession) DoesSessionExist() bool {
+	return t.DoesSessionExistContext(context.Background())
+}
+
+// DoesSessionExistContext is DoesSessionExist, but tmux is killed once ctx is done
+func (t *TmuxSession) DoesSessionExistContext(ctx context.Context) bool {
 	// Using "-t name" does a prefix match, which is wrong. `-t=` does an exact match.
-	existsCmd := exec.Command("tmux", "has-session", fmt.Sprintf("-t=%s", t.sanitizedName))
+	existsCmd := exec.CommandContext(ctx, "tmux", "has-session", "-t", t.sessionTarget())
 	return t.cmdExec.Run(existsCmd) == nil
 }
 
 // CapturePaneContent captures the content of the tmux pane
 func (t *TmuxSession) CapturePaneContent() (string, error) {
+	return t.CapturePaneContentContext(context.Background())
+}
+
+// CapturePaneContentContext is CapturePaneContent, but tmux is killed once ctx is done, so that a hung tmux server
+// doesn't block the caller for good
+func (t *TmuxSession) CapturePaneContentContext(ctx context.Context) (string, error) {
 	// Add -e flag to preserve escape sequences (ANSI color codes)
-	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", t.sanitizedName)
+	cmd := exec.CommandContext(ctx, "tmux", "capture-pane", "-p", "-e", "-J", "-t", t.paneTarget())
 	output, err := t.cmdExec.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("error capturing pane content: %v", err)
@@ -409,7 +635,7 @@ func (t *TmuxSession) CapturePaneContent() (string, error) {
 // start and end specify the starting and ending line numbers (use "-" for the start/end of history)
 func (t *TmuxSession) CapturePaneContentWithOptions(start, end string) (string, error) {
 	// Add -e flag to preserve escape sequences (ANSI color codes)
-	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-S", start, "-E", end, "-t", t.sanitizedName)
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-S", start, "-E", end, "-t", t.paneTarget())
 	output, err := t.cmdExec.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("failed to capture tmux pane content with options: %v", err)
@@ -417,32 +643,94 @@ func (t *TmuxSession) CapturePaneContentWithOptions(start, end string) (string,
 	return string(output), nil
 }
 
-// CleanupSessions kills all tmux sessions that start with "session-"
+// CaptureHistory captures the whole scrollback of the pane as plain text, without escape sequences
+func (t *TmuxSession) CaptureHistory() (string, error) {
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-J", "-S", "-", "-E", "-", "-t", t.paneTarget())
+	output, err := t.cmdExec.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to capture tmux pane history: %v", err)
+	}
+	return string(output), nil
+}
+
+// IsServerRunning returns false if the tmux server is gone, e.g. after `tmux kill-server`. In that case every
+// session is dead, not just individual ones. tmux is killed once ctx is done.
+func IsServerRunning(ctx context.Context, cmdExec cmd.Executor) bool {
+	output, err := cmdExec.Output(exec.CommandContext(ctx, "tmux", "list-sessions"))
+	if err == nil {
+		return true
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		output = append(output, exitErr.Stderr...)
+	}
+	msg := string(output)
+	return !strings.Contains(msg, "no server running") && !strings.Contains(msg, "error connecting to") &&
+		!strings.Contains(msg, "server exited unexpectedly")
+}
+
+// CleanupSessions kills all tmux sessions whose name starts with TmuxPrefix
 func CleanupSessions(cmdExec cmd.Executor) error {
-	// First try to list sessions
-	cmd := exec.Command("tmux", "ls")
-	output, err := cmdExec.Output(cmd)
+	sessions, err := listSessions(cmdExec)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range sessions {
+		log.InfoLog.Printf("cleaning up session: %s", name)
+		if err := cmdExec.Run(exec.Command("tmux", "kill-session", "-t", "="+name)); err != nil {
+			return fmt.Errorf("failed to kill tmux session %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// CleanupZombieSessions kills the tmux sessions starting with TmuxPrefix that don't belong to any of the given
+// instance titles, e.g. sessions left behind by a restart whose close failed. It returns the names of the killed
+// sessions.
+func CleanupZombieSessions(cmdExec cmd.Executor, titles []string) ([]string, error) {
+	sessions, err := listSessions(cmdExec)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]bool, len(titles))
+	for _, title := range titles {
+		live[toClaudeSquadTmuxName(title)] = true
+	}
+
+	var killed []string
+	var errs []error
+	for _, name := range sessions {
+		if live[name] {
+			continue
+		}
+		if err := cmdExec.Run(exec.Command("tmux", "kill-session", "-t", "="+name)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to kill tmux session %s: %v", name, err))
+			continue
+		}
+		killed = append(killed, name)
+	}
+	return killed, errors.Join(errs...)
+}
+
+// listSessions returns the names of the tmux sessions starting with TmuxPrefix
+func listSessions(cmdExec cmd.Executor) ([]string, error) {
+	output, err := cmdExec.Output(exec.Command("tmux", "ls"))
 
 	// If there's an error and it's because no server is running, that's fine
 	// Exit code 1 typically means no sessions exist
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			return nil // No sessions to clean up
+			return nil, nil // No sessions to clean up
 		}
-		return fmt.Errorf("failed to list tmux sessions: %v", err)
+		return nil, fmt.Errorf("failed to list tmux sessions: %v", err)
 	}
 
-	re := regexp.MustCompile(fmt.Sprintf(`%s.*:`, TmuxPrefix))
+	// Only match the prefix at the start of a session name so that user sessions are left alone.
+	re := regexp.MustCompile(fmt.Sprintf(`(?m)^%s[^:]*:`, TmuxPrefix))
 	matches := re.FindAllString(string(output), -1)
 	for i, match := range matches {
 		matches[i] = match[:strings.Index(match, ":")]
 	}
-
-	for _, match := range matches {
-		log.InfoLog.Printf("cleaning up session: %s", match)
-		if err := cmdExec.Run(exec.Command("tmux", "kill-session", "-t", match)); err != nil {
-			return fmt.Errorf("failed to kill tmux session %s: %v", match, err)
-		}
-	}
-	return nil
+	return matches, nil
 }
@@ -2,13 +2,16 @@ package tmux
 
 import (
 	cmd2 "github.com/smtg-ai/claude-squad/cmd"
+	"context"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/smtg-ai/claude-squad/cmd/cmd_test"
 
@@ -41,12 +44,34 @@ func NewMockPtyFactory(t *testing.T) *MockPtyFactory {
 	}
 }
 
+func TestCapturePaneContentContextKillsHungTmux(t *testing.T) {
+	// A tmux that never answers
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "tmux"), []byte("#!/bin/sh\nexec sleep 10\n"), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	session := newTmuxSession("hung", "program", NewMockPtyFactory(t), cmd2.MakeExecutor())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := session.CapturePaneContentContext(ctx)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
 func TestSanitizeName(t *testing.T) {
 	session := NewTmuxSession("asdf", "program")
 	require.Equal(t, TmuxPrefix+"asdf", session.sanitizedName)
 
 	session = NewTmuxSession("a sd f . . asdf", "program")
 	require.Equal(t, TmuxPrefix+"asdf__asdf", session.sanitizedName)
+
+	// Long titles are truncated, with a hash of the full title so that they stay apart
+	session = NewTmuxSession(strings.Repeat("a", 100), "program")
+	require.Len(t, session.sanitizedName, len(TmuxPrefix)+maxTmuxNameLength)
+	require.True(t, strings.HasPrefix(session.sanitizedName, TmuxPrefix+strings.Repeat("a", 55)+"-"))
+	other := NewTmuxSession(strings.Repeat("a", 100)+"b", "program")
+	require.NotEqual(t, session.sanitizedName, other.sanitizedName)
 }
 
 func TestStartTmuxSession(t *testing.T) {
@@ -74,7 +99,7 @@ func TestStartTmuxSession(t *testing.T) {
 	require.Equal(t, 2, len(ptyFactory.cmds))
 	require.Equal(t, fmt.Sprintf("tmux new-session -d -s claudesquad_test-session -c %s claude", workdir),
 		cmd2.ToString(ptyFactory.cmds[0]))
-	require.Equal(t, "tmux attach-session -t claudesquad_test-session",
+	require.Equal(t, "tmux attach-session -t =claudesquad_test-session",
 		cmd2.ToString(ptyFactory.cmds[1]))
 
 	require.Equal(t, 2, len(ptyFactory.files))
@@ -86,3 +111,219 @@ func TestStartTmuxSession(t *testing.T) {
 	_, err = ptyFactory.files[1].Stat()
 	require.NoError(t, err)
 }
+
+func TestPasteTextMultiLine(t *testing.T) {
+	prompt := "Fix the \"parser\"; it's broken:\n```go\nfmt.Println(`raw $HOME`)\n```\nThanks!"
+
+	var ran []string
+	var loaded string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			if cmd.Stdin != nil {
+				data, err := io.ReadAll(cmd.Stdin)
+				require.NoError(t, err)
+				loaded = string(data)
+			}
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return nil, nil
+		},
+	}
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+
+	require.NoError(t, session.PasteText(prompt))
+	require.Equal(t, []string{
+		"tmux load-buffer -b claudesquad_test-session_paste -",
+		"tmux paste-buffer -p -d -b claudesquad_test-session_paste -t =claudesquad_test-session:",
+	}, ran)
+	// The prompt goes through stdin untouched, so nothing needs escaping
+	require.Equal(t, prompt, loaded)
+}
+
+func TestPasteTextLeavesCopyMode(t *testing.T) {
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			if strings.Contains(cmd.String(), "#{pane_in_mode}") {
+				return []byte("1\n"), nil
+			}
+			return nil, nil
+		},
+	}
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+
+	require.NoError(t, session.PasteText("hello"))
+	require.Equal(t, []string{
+		"tmux send-keys -X -t =claudesquad_test-session: cancel",
+		"tmux load-buffer -b claudesquad_test-session_paste -",
+		"tmux paste-buffer -p -d -b claudesquad_test-session_paste -t =claudesquad_test-session:",
+	}, ran)
+}
+
+func TestPasteTextMultiLineInTmux(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+
+	prompt := "line one with 'single' and \"double\" quotes\n`backticks` $(not a subshell); echo nope\n\tindented"
+	outPath := filepath.Join(t.TempDir(), "out")
+
+	session := newTmuxSession(fmt.Sprintf("paste-test-%d", rand.Int31()), "cat", NewMockPtyFactory(t), cmd2.MakeExecutor())
+	// Run cat with the terminal in raw mode so that the pasted text reaches it byte for byte
+	require.NoError(t, exec.Command("tmux", "new-session", "-d", "-s", session.sanitizedName,
+		fmt.Sprintf("stty raw -echo; head -c %d > %s", len(prompt), outPath)).Run())
+	defer exec.Command("tmux", "kill-session", "-t", session.sessionTarget()).Run()
+
+	require.NoError(t, session.PasteText(prompt))
+
+	var got []byte
+	for i := 0; i < 50; i++ {
+		got, _ = os.ReadFile(outPath)
+		if len(got) >= len(prompt) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	// tmux turns line feeds into carriage returns when pasting, like a terminal does for typed text
+	require.Equal(t, strings.ReplaceAll(prompt, "\n", "\r"), string(got))
+}
+
+func TestAttachSplit(t *testing.T) {
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return nil, nil
+		},
+	}
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+
+	t.Setenv("TMUX", "")
+	require.Error(t, session.AttachSplit())
+	require.Empty(t, ran)
+
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+	t.Setenv("TMUX_PANE", "%3")
+	require.NoError(t, session.AttachSplit())
+	require.Equal(t, []string{"tmux split-window -h -t %3 env -u TMUX tmux -S /tmp/tmux-1000/default " +
+		"attach-session -t =claudesquad_test-session"}, ran)
+}
+
+func TestCleanupZombieSessions(t *testing.T) {
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte("claudesquad_live: 1 windows\n" +
+				"claudesquad_zombie: 1 windows\n" +
+				"mywork: 2 windows\n"), nil
+		},
+	}
+
+	killed, err := CleanupZombieSessions(cmdExec, []string{"live"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"claudesquad_zombie"}, killed)
+	require.Equal(t, []string{"tmux kill-session -t =claudesquad_zombie"}, ran)
+}
+
+func TestSendTmuxKeys(t *testing.T) {
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte("0\n"), nil
+		},
+	}
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+
+	require.NoError(t, session.SendTmuxKeys([]string{"C-c", "Escape", "Up"}))
+	require.Equal(t, []string{"tmux send-keys -t =claudesquad_test-session: C-c Escape Up"}, ran)
+}
+
+func TestHasPromptInsideProgramWrapper(t *testing.T) {
+	const prompt = "1. Yes\n2. No, and tell Claude what to do differently"
+	session := newTmuxSession("test-session", "firejail --private=. claude", NewMockPtyFactory(t), cmd_test.MockCmdExec{})
+	require.False(t, session.hasPrompt(prompt))
+
+	session.SetProgramName("claude")
+	require.True(t, session.hasPrompt(prompt))
+}
+
+func TestHasUpdatedReadyCheck(t *testing.T) {
+	captures := []string{"working 1", "working 2", "idle> ", "idle> 3"}
+	captured := 0
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error { return nil },
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			content := captures[captured%len(captures)]
+			captured++
+			return []byte(content), nil
+		},
+	}
+	session := newTmuxSession("test-session", "aider", NewMockPtyFactory(t), cmdExec)
+	session.monitor = newStatusMonitor()
+	session.SetReadyCheck(func(content string) bool { return strings.HasPrefix(content, "idle> ") })
+
+	var updates []bool
+	for range captures {
+		updated, _ := session.HasUpdated()
+		updates = append(updates, updated)
+	}
+	// The idle prompt is ready even though the pane changed
+	require.Equal(t, []bool{true, true, false, false}, updates)
+}
+
+func TestPing(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		captures []string
+		want     bool
+		wantSent []string
+	}{
+		{name: "redraws", captures: []string{"> ", "> ", ">  "}, want: true, wantSent: []string{"Space", "BSpace"}},
+		{name: "hung", captures: []string{"> "}, want: false, wantSent: []string{"Space", "BSpace"}},
+		// A space could pick an option of the prompt
+		{name: "prompt", captures: []string{"1. Yes\n2. No, and tell Claude what to do differently"}, want: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var sent []string
+			captured := 0
+			cmdExec := cmd_test.MockCmdExec{
+				RunFunc: func(cmd *exec.Cmd) error {
+					sent = append(sent, cmd.Args[len(cmd.Args)-1])
+					return nil
+				},
+				OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+					if strings.Contains(cmd.String(), "#{pane_in_mode}") {
+						return []byte("0\n"), nil
+					}
+					content := tc.captures[min(captured, len(tc.captures)-1)]
+					captured++
+					return []byte(content), nil
+				},
+			}
+			session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+
+			responsive, err := session.Ping(time.Second)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, responsive)
+			// The space is always erased again
+			require.Equal(t, tc.wantSent, sent)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"github.com/smtg-ai/claude-squad/config"
+	"github.com/smtg-ai/claude-squad/log"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	transcriptsDirName = "transcripts"
+	transcriptExt      = ".txt"
+	// transcriptTimeFormat sorts the same way as the time, so that transcripts of a session are listed in order
+	transcriptTimeFormat = "20060102-150405"
+)
+
+// unsafeFileNameChars matches the characters that are replaced in the file names of transcripts
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Transcript is a saved scrollback of a session
+type Transcript struct {
+	// Name is the file name without the extension, e.g. "my-session-20250102-150405"
+	Name string
+	// Path is the path of the transcript file
+	Path string
+	// SavedAt is when the transcript was saved
+	SavedAt time.Time
+}
+
+// TranscriptsDir returns the directory transcripts are saved in
+func TranscriptsDir() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, transcriptsDirName), nil
+}
+
+// ListTranscripts returns the saved transcripts, newest first
+func ListTranscripts() ([]Transcript, error) {
+	dir, err := TranscriptsDir()
+	if err != nil {
+		return nil, err
+	}
+	return listTranscripts(dir)
+}
+
+func listTranscripts(dir string) ([]Transcript, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read transcripts directory: %w", err)
+	}
+
+	var transcripts []Transcript
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != transcriptExt {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		transcripts = append(transcripts, Transcript{
+			Name:    strings.TrimSuffix(entry.Name(), transcriptExt),
+			Path:    filepath.Join(dir, entry.Name()),
+			SavedAt: info.ModTime(),
+		})
+	}
+	sort.SliceStable(transcripts, func(a, b int) bool {
+		return transcripts[a].SavedAt.After(transcripts[b].SavedAt)
+	})
+	return transcripts, nil
+}
+
+// fileNameForTitle turns the title of a session into a file name without an extension. Titles that had to be changed
+// end in a short hash of the title, so that e.g. "fix: bug" and "fix bug" don't share files.
+func fileNameForTitle(title string) string {
+	name := strings.Trim(unsafeFileNameChars.ReplaceAllString(title, "-"), "-")
+	if name != "" && name == title {
+		return name
+	}
+	if name == "" {
+		name = "session"
+	}
+	return fmt.Sprintf("%s-%x", name, sha256.Sum256([]byte(title)))[:len(name)+9]
+}
+
+// writeTranscript writes content to a new transcript file in dir, named after the title and the time
+func writeTranscript(dir string, title string, content string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create transcripts directory: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s%s", fileNameForTitle(title), now.Format(transcriptTimeFormat), transcriptExt))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return path, nil
+}
+
+// saveTranscriptOnClose saves the full scrollback of the tmux session if save_transcript_on_close is set. It's called
+// right before the tmux session is closed. Failing to save the transcript doesn't stop the session from closing.
+func (i *Instance) saveTranscriptOnClose() {
+	if i.tmuxSession == nil || !currentConfig().SaveTranscriptOnClose || !i.tmuxSession.DoesSessionExist() {
+		return
+	}
+	content, err := i.tmuxSession.CaptureHistory()
+	if err != nil {
+		log.WarningLog.Printf("could not capture transcript of '%s': %v", i.Title, err)
+		return
+	}
+	dir, err := TranscriptsDir()
+	if err != nil {
+		log.WarningLog.Printf("could not save transcript of '%s': %v", i.Title, err)
+		return
+	}
+	path, err := writeTranscript(dir, i.Title, content, time.Now())
+	if err != nil {
+		log.WarningLog.Printf("could not save transcript of '%s': %v", i.Title, err)
+		return
+	}
+	log.InfoLog.Printf("saved transcript of '%s' to %s", i.Title, path)
+}
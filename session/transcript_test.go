@@ -0,0 +1,44 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscripts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "transcripts")
+
+	// No transcripts saved yet
+	transcripts, err := listTranscripts(dir)
+	require.NoError(t, err)
+	assert.Empty(t, transcripts)
+
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	older, err := writeTranscript(dir, "fix the bug/now", "old output", now)
+	require.NoError(t, err)
+	assert.Regexp(t, `^fix-the-bug-now-[0-9a-f]{8}-20250102-150405\.txt$`, filepath.Base(older))
+	require.NoError(t, os.Chtimes(older, now, now))
+
+	newer, err := writeTranscript(dir, "?!", "new output", now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Regexp(t, `^session-[0-9a-f]{8}-20250102-150505\.txt$`, filepath.Base(newer))
+
+	// Other files are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), nil, 0644))
+
+	transcripts, err = listTranscripts(dir)
+	require.NoError(t, err)
+	require.Len(t, transcripts, 2)
+	assert.Equal(t, strings.TrimSuffix(filepath.Base(newer), transcriptExt), transcripts[0].Name)
+	assert.Equal(t, older, transcripts[1].Path)
+
+	content, err := os.ReadFile(older)
+	require.NoError(t, err)
+	assert.Equal(t, "old output", string(content))
+}
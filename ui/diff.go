@@ -56,13 +56,17 @@ func (d *DiffPane) SetDiff(instance *session.Instance) {
 
 	stats := instance.GetDiffStats()
 	if stats == nil {
-		// Show loading message if worktree is not ready
+		// Show loading message if worktree is not ready, or how to get out of it if it never will be
+		message := "Setting up worktree..."
+		if instance.SetupIncomplete() {
+			message = "Worktree setup never completed.\nPress f to set it up again or re-create the session."
+		}
 		centeredMessage := lipgloss.Place(
 			d.width,
 			d.height,
 			lipgloss.Center,
 			lipgloss.Center,
-			"Setting up worktree...",
+			message,
 		)
 		d.viewport.SetContent(centeredMessage)
 		return
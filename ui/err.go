@@ -8,6 +8,8 @@ import (
 type ErrBox struct {
 	height, width int
 	err           error
+	// status describes an operation in progress. It's shown when there's no error to show.
+	status string
 }
 
 var errStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{
@@ -15,6 +17,11 @@ var errStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{
 	Dark:  "#FF0000",
 })
 
+var statusStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{
+	Light: "#1a1a1a",
+	Dark:  "#dddddd",
+})
+
 func NewErrBox() *ErrBox {
 	return &ErrBox{}
 }
@@ -27,6 +34,15 @@ func (e *ErrBox) Clear() {
 	e.err = nil
 }
 
+// SetStatus shows a status message, e.g. the progress of a long operation, until ClearStatus is called.
+func (e *ErrBox) SetStatus(status string) {
+	e.status = status
+}
+
+func (e *ErrBox) ClearStatus() {
+	e.status = ""
+}
+
 func (e *ErrBox) SetSize(width, height int) {
 	e.width = width
 	e.height = height
@@ -41,6 +57,8 @@ func (e *ErrBox) String() string {
 		if len(err) > e.width-3 && e.width-3 >= 0 {
 			err = err[:e.width-3] + "..."
 		}
+	} else if e.status != "" {
+		return lipgloss.Place(e.width, e.height, lipgloss.Center, lipgloss.Center, statusStyle.Render(e.status))
 	}
 	return lipgloss.Place(e.width, e.height, lipgloss.Center, lipgloss.Center, errStyle.Render(err))
 }
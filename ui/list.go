@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"github.com/smtg-ai/claude-squad/humanize"
 	"github.com/smtg-ai/claude-squad/log"
 	"github.com/smtg-ai/claude-squad/session"
 	"errors"
@@ -9,11 +10,19 @@ import (
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 )
 
 const readyIcon = "● "
 const pausedIcon = "⏸ "
 const continuousIcon = "[C]"
+const autoYesIcon = "[Y]"
+const queueIconFormat = "[Q:%d]"
+const compactingIcon = "[compacting]"
+
+// FocusedListHeight is the height of the list in focus mode, where only the selected item is shown: the title with
+// its padding and the branch line with its padding.
+const FocusedListHeight = 4
 
 var readyStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#51bd73", Dark: "#51bd73"})
@@ -30,6 +39,18 @@ var pausedStyle = lipgloss.NewStyle().
 var continuousStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#ff9500", Dark: "#ff9500"})
 
+// tagColors maps session.Tags to the color of the tag marker
+var tagColors = map[string]lipgloss.Color{
+	"red":    lipgloss.Color("#de613e"),
+	"orange": lipgloss.Color("#ff9500"),
+	"yellow": lipgloss.Color("#FFD700"),
+	"green":  lipgloss.Color("#51bd73"),
+	"blue":   lipgloss.Color("#4a90e2"),
+	"purple": lipgloss.Color("#7D56F4"),
+}
+
+const tagIcon = "■ "
+
 var titleStyle = lipgloss.NewStyle().
 	Padding(1, 1, 0, 1).
 	Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
@@ -52,6 +73,11 @@ var mainTitle = lipgloss.NewStyle().
 	Background(lipgloss.Color("62")).
 	Foreground(lipgloss.Color("230"))
 
+var safeModeStyle = lipgloss.NewStyle().
+	Bold(true).
+	Background(lipgloss.Color("#de613e")).
+	Foreground(lipgloss.Color("#ffffff"))
+
 var autoYesStyle = lipgloss.NewStyle().
 	Background(lipgloss.Color("#dde4f0")).
 	Foreground(lipgloss.Color("#1a1a1a"))
@@ -62,6 +88,10 @@ type List struct {
 	height, width int
 	renderer      *InstanceRenderer
 	autoyes       bool
+	// focused is true if only the selected item is shown, see SetFocused
+	focused bool
+	// filter holds the items shown, in order, while the list is filtered. nil shows all items.
+	filter []*session.Instance
 
 	// map of repo name to number of instances using it. Used to display the repo name only if there are
 	// multiple repos in play.
@@ -71,7 +101,7 @@ type List struct {
 func NewList(spinner *spinner.Model, autoYes bool) *List {
 	return &List{
 		items:    []*session.Instance{},
-		renderer: &InstanceRenderer{spinner: spinner},
+		renderer: &InstanceRenderer{spinner: spinner, autoYes: autoYes},
 		repos:    make(map[string]int),
 		autoyes:  autoYes,
 	}
@@ -100,6 +130,83 @@ func (l *List) SetSessionPreviewSize(width, height int) (err error) {
 	return
 }
 
+// SetFocused sets whether the list is collapsed to just the selected item, without the title.
+func (l *List) SetFocused(focused bool) {
+	l.focused = focused
+}
+
+// SetFilter shows only the given instances, in the given order. nil shows all instances again. If the selected
+// instance isn't one of them, the first one is selected.
+func (l *List) SetFilter(instances []*session.Instance) {
+	if instances == nil {
+		l.filter = nil
+		return
+	}
+	l.filter = instances
+	selected := l.GetSelectedInstance()
+	for _, instance := range instances {
+		if instance == selected {
+			return
+		}
+	}
+	if len(instances) > 0 {
+		l.selectedIdx = l.indexOf(instances[0])
+	}
+}
+
+// IsFiltered returns true if only some of the instances are shown, see SetFilter
+func (l *List) IsFiltered() bool {
+	return l.filter != nil
+}
+
+// visibleItems returns the items shown in the list. While filtering, the selected item is always shown, e.g. a new
+// instance that's being named.
+func (l *List) visibleItems() []*session.Instance {
+	if l.filter == nil {
+		return l.items
+	}
+	selected := l.GetSelectedInstance()
+	visible := make([]*session.Instance, 0, len(l.filter)+1)
+	hasSelected := false
+	for _, instance := range l.filter {
+		// Skip instances that were killed since the filter was set
+		if l.indexOf(instance) < 0 {
+			continue
+		}
+		visible = append(visible, instance)
+		hasSelected = hasSelected || instance == selected
+	}
+	if !hasSelected && selected != nil {
+		visible = append(visible, selected)
+	}
+	return visible
+}
+
+// indexOf returns the index of the instance in the list, or -1 if it isn't in the list
+func (l *List) indexOf(instance *session.Instance) int {
+	for idx, item := range l.items {
+		if item == instance {
+			return idx
+		}
+	}
+	return -1
+}
+
+// moveInFilter moves the selection by offset within the filtered items
+func (l *List) moveInFilter(offset int) {
+	visible := l.visibleItems()
+	selected := l.GetSelectedInstance()
+	for pos, instance := range visible {
+		if instance != selected {
+			continue
+		}
+		if next := pos + offset; next >= 0 && next < len(visible) {
+			l.selectedIdx = l.indexOf(visible[next])
+		}
+		return
+	}
+}
+
 func (l *List) NumInstances() int {
 	return len(l.items)
 }
@@ -108,6 +215,8 @@ func (l *List) NumInstances() int {
 type InstanceRenderer struct {
 	spinner *spinner.Model
 	width   int
+	// autoYes is true if AutoYes is on for all instances. Otherwise, instances with AutoYes get an indicator.
+	autoYes bool
 }
 
 func (r *InstanceRenderer) setWidth(width int) {
@@ -151,18 +260,58 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 		timeStr := i.GetContinuousModeTimeRemainingFormatted()
 		if timeStr != "" {
 			continuousIndicator = continuousStyle.Render(fmt.Sprintf("[C:%s]", timeStr))
-			continuousIndicatorWidth = len(fmt.Sprintf("[C:%s]", timeStr)) + 1
+			continuousIndicatorWidth = runewidth.StringWidth(fmt.Sprintf("[C:%s]", timeStr)) + 1
 		} else {
 			continuousIndicator = continuousStyle.Render(continuousIcon)
-			continuousIndicatorWidth = len(continuousIcon) + 1 // Account for space
+			continuousIndicatorWidth = runewidth.StringWidth(continuousIcon) + 1 // Account for space
 		}
 	}
 	
-	widthAvail := r.width - 3 - len(prefix) - 1 - continuousIndicatorWidth
-	if widthAvail > 0 && widthAvail < len(titleText) && len(titleText) >= widthAvail-3 {
-		titleText = titleText[:widthAvail-3] + "..."
+	// Flag instances that opted into AutoYes on their own
+	if i.AutoYes && !r.autoYes {
+		if continuousIndicator == "" {
+			continuousIndicator = autoYesStyle.Render(autoYesIcon)
+		} else {
+			continuousIndicator = autoYesStyle.Render(autoYesIcon) + " " + continuousIndicator
+		}
+		continuousIndicatorWidth += runewidth.StringWidth(autoYesIcon) + 1
 	}
 	
+	// The watchdog holds off while Claude Code compacts the conversation
+	if i.IsCompacting() {
+		if continuousIndicator == "" {
+			continuousIndicator = pausedStyle.Render(compactingIcon)
+		} else {
+			continuousIndicator = pausedStyle.Render(compactingIcon) + " " + continuousIndicator
+		}
+		continuousIndicatorWidth += runewidth.StringWidth(compactingIcon) + 1
+	}
+
+	// Show how many prompts are waiting to be sent
+	if queued := i.QueueLength(); queued > 0 {
+		queueIcon := fmt.Sprintf(queueIconFormat, queued)
+		if continuousIndicator == "" {
+			continuousIndicator = autoYesStyle.Render(queueIcon)
+		} else {
+			continuousIndicator = autoYesStyle.Render(queueIcon) + " " + continuousIndicator
+		}
+		continuousIndicatorWidth += runewidth.StringWidth(queueIcon) + 1
+	}
+	
+	tagMarker := ""
+	tagMarkerWidth := 0
+	if color, ok := tagColors[i.Tag]; ok {
+		tagMarker = lipgloss.NewStyle().Foreground(color).Background(titleS.GetBackground()).Render(tagIcon)
+		tagMarkerWidth = 2
+	}
+
+	widthAvail := r.width - 3 - len(prefix) - 1 - continuousIndicatorWidth - tagMarkerWidth
+	// Measure in cells rather than bytes: titles may contain wide or multibyte characters
+	if widthAvail > 0 && runewidth.StringWidth(titleText) > widthAvail {
+		titleText = runewidth.Truncate(titleText, widthAvail, "...")
+	}
+	titleText = tagMarker + titleText
+	
 	titleWithIndicator := fmt.Sprintf("%s %s", prefix, titleText)
 	if continuousIndicator != "" {
 		titleWithIndicator = fmt.Sprintf("%s %s %s", prefix, titleText, continuousIndicator)
@@ -197,9 +346,10 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 
 	remainingWidth := r.width
 	remainingWidth -= len(prefix)
-	remainingWidth -= len(branchIcon)
+	// The icon has a space before it and a dash after it
+	remainingWidth -= runewidth.StringWidth(branchIcon) + 2
 
-	diffWidth := len(addedDiff) + len(removedDiff)
+	diffWidth := runewidth.StringWidth(addedDiff) + runewidth.StringWidth(removedDiff)
 	if diffWidth > 0 {
 		diffWidth += 1
 	}
@@ -208,6 +358,30 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 	remainingWidth -= diffWidth
 
 	branch := i.Branch
+	// Explain upfront why kill and resume are refused
+	if i.IsCheckedOut() {
+		branch += " [checked out]"
+	}
+	// The agent made progress since the user last looked at it
+	if i.DiffChangedSinceViewed() {
+		branch += " [changed]"
+	}
+	// The program didn't react to a ping
+	if i.Unresponsive() {
+		branch += " [unresponsive]"
+	}
+	// No diff is ever shown until the setup is completed
+	if i.SetupIncomplete() {
+		branch += " [setup incomplete]"
+	}
+	// Flag branches that need a rebase, otherwise their diffs look confusing.
+	if divergence := i.GetDivergence(); divergence != nil {
+		if divergence.IsDiverged() {
+			branch += fmt.Sprintf(" [diverged +%d/-%d]", divergence.Ahead, divergence.Behind)
+		} else if divergence.IsBehind() {
+			branch += fmt.Sprintf(" [behind %d]", divergence.Behind)
+		}
+	}
 	if i.Started() && hasMultipleRepos {
 		repoName, err := i.RepoName()
 		if err != nil {
@@ -216,18 +390,23 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 			branch += fmt.Sprintf(" (%s)", repoName)
 		}
 	}
+	// Show when the status last changed, e.g. how long an instance has been waiting for input, if there's room
+	if i.Started() {
+		if updated := " · " + humanize.RelativeTime(i.UpdatedAt); remainingWidth >= runewidth.StringWidth(branch+updated) {
+			branch += updated
+		}
+	}
 	// Don't show branch if there's no space for it. Or show ellipsis if it's too long.
 	if remainingWidth < 0 {
 		branch = ""
-	} else if remainingWidth < len(branch) {
+	} else if remainingWidth < runewidth.StringWidth(branch) {
 		if remainingWidth < 3 {
 			branch = ""
 		} else {
-			// We know the remainingWidth is at least 4 and branch is longer than that, so this is safe.
-			branch = branch[:remainingWidth-3] + "..."
+			branch = runewidth.Truncate(branch, remainingWidth, "...")
 		}
 	}
-	remainingWidth -= len(branch)
+	remainingWidth -= runewidth.StringWidth(branch)
 
 	// Add spaces to fill the remaining width.
 	spaces := ""
@@ -248,8 +427,20 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 }
 
 func (l *List) String() string {
-	const titleText = " Instances "
+	titleText := " Instances "
+	if l.filter != nil {
+		titleText = " Needs attention "
+	}
 	const autoYesText = " auto-yes "
+	const safeModeText = " SAFE MODE "
+
+	if l.focused {
+		if len(l.items) == 0 {
+			return lipgloss.Place(l.width, l.height, lipgloss.Left, lipgloss.Top, "")
+		}
+		item := l.renderer.Render(l.items[l.selectedIdx], l.selectedIdx+1, true, len(l.repos) > 1)
+		return lipgloss.Place(l.width, l.height, lipgloss.Left, lipgloss.Top, item)
+	}
 
 	// Write the title.
 	var b strings.Builder
@@ -259,25 +450,37 @@ func (l *List) String() string {
 	// Write title line
 	// add padding of 2 because the border on list items adds some extra characters
 	titleWidth := AdjustPreviewWidth(l.width) + 2
-	if !l.autoyes {
+	// Safe mode overrides auto-yes, so it takes the place of its badge
+	badge := ""
+	if session.SafeMode() {
+		badge = safeModeStyle.Render(safeModeText)
+	} else if l.autoyes {
+		badge = autoYesStyle.Render(autoYesText)
+	}
+	if badge == "" {
 		b.WriteString(lipgloss.Place(
 			titleWidth, 1, lipgloss.Left, lipgloss.Bottom, mainTitle.Render(titleText)))
 	} else {
 		title := lipgloss.Place(
 			titleWidth/2, 1, lipgloss.Left, lipgloss.Bottom, mainTitle.Render(titleText))
-		autoYes := lipgloss.Place(
-			titleWidth-(titleWidth/2), 1, lipgloss.Right, lipgloss.Bottom, autoYesStyle.Render(autoYesText))
+		badgePlaced := lipgloss.Place(
+			titleWidth-(titleWidth/2), 1, lipgloss.Right, lipgloss.Bottom, badge)
 		b.WriteString(lipgloss.JoinHorizontal(
-			lipgloss.Top, title, autoYes))
+			lipgloss.Top, title, badgePlaced))
 	}
 
 	b.WriteString("\n")
 	b.WriteString("\n")
 
 	// Render the list.
-	for i, item := range l.items {
+	visible := l.visibleItems()
+	if l.filter != nil && len(visible) == 0 {
+		b.WriteString(listDescStyle.Render("Nothing needs your attention"))
+	}
+	for pos, item := range visible {
+		i := l.indexOf(item)
 		b.WriteString(l.renderer.Render(item, i+1, i == l.selectedIdx, len(l.repos) > 1))
-		if i != len(l.items)-1 {
+		if pos != len(visible)-1 {
 			b.WriteString("\n\n")
 		}
 	}
@@ -289,28 +492,55 @@ func (l *List) Down() {
 	if len(l.items) == 0 {
 		return
 	}
+	if l.filter != nil {
+		l.moveInFilter(1)
+		return
+	}
 	if l.selectedIdx < len(l.items)-1 {
 		l.selectedIdx++
 	}
 }
 
-// Kill selects the next item in the list.
+// NextWithStatus selects the next instance with the given status, wrapping around to the top of the list. It returns
+// false and leaves the selection alone if no instance has that status.
+func (l *List) NextWithStatus(status session.Status) bool {
+	for offset := 1; offset <= len(l.items); offset++ {
+		idx := (l.selectedIdx + offset) % len(l.items)
+		if l.items[idx].Status == status {
+			l.selectedIdx = idx
+			return true
+		}
+	}
+	return false
+}
+
+// Kill kills the selected item and selects the next item in the list.
 func (l *List) Kill() {
 	if len(l.items) == 0 {
 		return
 	}
-	targetInstance := l.items[l.selectedIdx]
+	l.KillInstance(l.items[l.selectedIdx])
+}
+
+// KillInstance kills targetInstance and removes it from the list, keeping the selection on the item that was selected
+// unless it's the one killed. Background operations use it, since the selection may have moved since they started.
+func (l *List) KillInstance(targetInstance *session.Instance) {
+	idx := -1
+	for i, instance := range l.items {
+		if instance == targetInstance {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return
+	}
 
 	// Kill the tmux session
 	if err := targetInstance.Kill(); err != nil {
 		log.ErrorLog.Printf("could not kill instance: %v", err)
 	}
 
-	// If you delete the last one in the list, select the previous one.
-	if l.selectedIdx == len(l.items)-1 {
-		defer l.Up()
-	}
-
 	// Unregister the reponame.
 	repoName, err := targetInstance.RepoName()
 	if err != nil {
@@ -319,8 +549,16 @@ func (l *List) Kill() {
 		l.rmRepo(repoName)
 	}
 
-	// Since there's items after this, the selectedIdx can stay the same.
-	l.items = append(l.items[:l.selectedIdx], l.items[l.selectedIdx+1:]...)
+	// Since there's items after this, the selectedIdx can stay the same unless an item before it was removed.
+	l.items = append(l.items[:idx], l.items[idx+1:]...)
+	if idx < l.selectedIdx {
+		l.selectedIdx--
+	}
+
+	// If you delete the last one in the list, select the previous one.
+	if l.selectedIdx == len(l.items) && l.selectedIdx > 0 {
+		l.selectedIdx--
+	}
 }
 
 func (l *List) Attach() (chan struct{}, error) {
@@ -328,11 +566,21 @@ func (l *List) Attach() (chan struct{}, error) {
 	return targetInstance.Attach()
 }
 
+// AttachSplit opens the selected instance in a tmux pane next to claude-squad.
+func (l *List) AttachSplit() error {
+	targetInstance := l.items[l.selectedIdx]
+	return targetInstance.AttachSplit()
+}
+
 // Up selects the prev item in the list.
 func (l *List) Up() {
 	if len(l.items) == 0 {
 		return
 	}
+	if l.filter != nil {
+		l.moveInFilter(-1)
+		return
+	}
 	if l.selectedIdx > 0 {
 		l.selectedIdx--
 	}
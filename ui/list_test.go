@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"github.com/smtg-ai/claude-squad/session"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderedTitle renders an instance with a title too long for the list and returns the truncated title
+func renderedTitle(t *testing.T, title string) string {
+	s := spinner.New()
+	r := &InstanceRenderer{spinner: &s}
+	r.setWidth(120)
+	for _, line := range strings.Split(r.Render(&session.Instance{Title: title}, 1, false, false), "\n") {
+		_, after, found := strings.Cut(line, " 1. ")
+		if !found {
+			continue
+		}
+		end := strings.LastIndex(after, "...")
+		require.NotEqual(t, -1, end, "title wasn't truncated: %q", line)
+		return strings.TrimSpace(after[:end+len("...")])
+	}
+	require.FailNow(t, "title line not found")
+	return ""
+}
+
+func TestRenderTruncatesTitleByDisplayWidth(t *testing.T) {
+	ascii := renderedTitle(t, strings.Repeat("a", 200))
+
+	tests := []struct {
+		name  string
+		title string
+	}{
+		{name: "multibyte", title: strings.Repeat("é·", 100)},
+		{name: "wide", title: strings.Repeat("漢", 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderedTitle(t, tt.title)
+			assert.True(t, strings.HasPrefix(tt.title, strings.TrimSuffix(got, "...")))
+			// A wide character that doesn't fit entirely is dropped, so the title can be one cell narrower
+			assert.InDelta(t, lipgloss.Width(ascii), lipgloss.Width(got), 1)
+		})
+	}
+}
+
+// newTestList returns a list with an instance for each title, and the instances
+func newTestList(titles ...string) (*List, []*session.Instance) {
+	s := spinner.New()
+	l := NewList(&s, false)
+	instances := make([]*session.Instance, len(titles))
+	for i, title := range titles {
+		instances[i] = &session.Instance{Title: title}
+		l.AddInstance(instances[i])
+	}
+	return l, instances
+}
+
+func TestListSetFilter(t *testing.T) {
+	t.Run("keeps the selection if it's shown", func(t *testing.T) {
+		l, instances := newTestList("a", "b", "c")
+		l.SetSelectedInstance(2)
+		l.SetFilter([]*session.Instance{instances[0], instances[2]})
+		assert.True(t, l.IsFiltered())
+		assert.Equal(t, instances[2], l.GetSelectedInstance())
+	})
+
+	t.Run("selects the first shown instance if the selection isn't shown", func(t *testing.T) {
+		l, instances := newTestList("a", "b", "c")
+		l.SetSelectedInstance(0)
+		l.SetFilter([]*session.Instance{instances[2], instances[1]})
+		assert.Equal(t, instances[2], l.GetSelectedInstance())
+	})
+
+	t.Run("an empty filter keeps the selection", func(t *testing.T) {
+		l, instances := newTestList("a", "b")
+		l.SetSelectedInstance(1)
+		l.SetFilter([]*session.Instance{})
+		assert.True(t, l.IsFiltered())
+		assert.Equal(t, instances[1], l.GetSelectedInstance())
+	})
+
+	t.Run("nil shows all instances again", func(t *testing.T) {
+		l, instances := newTestList("a", "b", "c")
+		l.SetFilter([]*session.Instance{instances[1]})
+		l.SetFilter(nil)
+		assert.False(t, l.IsFiltered())
+		assert.Equal(t, instances, l.visibleItems())
+		assert.Equal(t, instances[1], l.GetSelectedInstance())
+	})
+}
+
+func TestListVisibleItems(t *testing.T) {
+	t.Run("shows the filtered instances in the filter's order", func(t *testing.T) {
+		l, instances := newTestList("a", "b", "c")
+		l.SetFilter([]*session.Instance{instances[2], instances[0]})
+		assert.Equal(t, []*session.Instance{instances[2], instances[0]}, l.visibleItems())
+	})
+
+	t.Run("always shows the selected instance", func(t *testing.T) {
+		l, instances := newTestList("a", "b", "c")
+		l.SetFilter([]*session.Instance{instances[0]})
+		// e.g. a new instance that's being named
+		l.SetSelectedInstance(1)
+		assert.Equal(t, []*session.Instance{instances[0], instances[1]}, l.visibleItems())
+	})
+
+	t.Run("leaves out instances killed since the filter was set", func(t *testing.T) {
+		l, instances := newTestList("a", "b", "c")
+		l.SetFilter([]*session.Instance{instances[0], instances[2]})
+		l.items = []*session.Instance{instances[0], instances[1]}
+		assert.Equal(t, []*session.Instance{instances[0]}, l.visibleItems())
+	})
+}
+
+func TestListMoveInFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string
+		want  string
+	}{
+		{name: "down skips instances that aren't shown", moves: []string{"down"}, want: "c"},
+		{name: "down stops at the last shown instance", moves: []string{"down", "down", "down"}, want: "e"},
+		{name: "up stops at the first shown instance", moves: []string{"up"}, want: "a"},
+		{name: "up goes back", moves: []string{"down", "down", "up"}, want: "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, instances := newTestList("a", "b", "c", "d", "e")
+			l.SetFilter([]*session.Instance{instances[0], instances[2], instances[4]})
+			for _, move := range tt.moves {
+				if move == "down" {
+					l.Down()
+				} else {
+					l.Up()
+				}
+			}
+			assert.Equal(t, tt.want, l.GetSelectedInstance().Title)
+		})
+	}
+
+	t.Run("a selected instance that isn't in the filter is shown last", func(t *testing.T) {
+		l, instances := newTestList("a", "b", "c")
+		l.SetFilter([]*session.Instance{instances[0], instances[2]})
+		l.SetSelectedInstance(1)
+		l.Up()
+		assert.Equal(t, "c", l.GetSelectedInstance().Title)
+	})
+}
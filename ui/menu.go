@@ -48,13 +48,15 @@ type Menu struct {
 	state         MenuState
 	instance      *session.Instance
 	isInDiffTab   bool
+	// promptAfterCreate is true if the keys for new sessions with and without a prompt are swapped
+	promptAfterCreate bool
 
 	// keyDown is the key which is pressed. The default is -1.
 	keyDown keys.KeyName
 }
 
 var defaultMenuOptions = []keys.KeyName{keys.KeyNew, keys.KeyPrompt, keys.KeyHelp, keys.KeyQuit}
-var newInstanceMenuOptions = []keys.KeyName{keys.KeySubmitName}
+var newInstanceMenuOptions = []keys.KeyName{keys.KeySubmitName, keys.KeyToggleAutoYes}
 var promptMenuOptions = []keys.KeyName{keys.KeySubmitName}
 
 func NewMenu() *Menu {
@@ -80,6 +82,12 @@ func (m *Menu) SetState(state MenuState) {
 	m.updateOptions()
 }
 
+// SetPromptAfterCreate sets whether the keys for new sessions with and without a prompt are swapped, so that their
+// descriptions are swapped too
+func (m *Menu) SetPromptAfterCreate(promptAfterCreate bool) {
+	m.promptAfterCreate = promptAfterCreate
+}
+
 // SetInstance updates the current instance and refreshes menu options
 func (m *Menu) SetInstance(instance *session.Instance) {
 	m.instance = instance
@@ -181,6 +189,13 @@ func (m *Menu) String() string {
 
 	for i, k := range m.options {
 		binding := keys.GlobalkeyBindings[k]
+		if m.promptAfterCreate && (k == keys.KeyNew || k == keys.KeyPrompt) {
+			other := keys.KeyPrompt
+			if k == keys.KeyPrompt {
+				other = keys.KeyNew
+			}
+			binding.SetHelp(binding.Help().Key, keys.GlobalkeyBindings[other].Help().Desc)
+		}
 
 		var (
 			localActionStyle = actionGroupStyle
@@ -0,0 +1,98 @@
+package overlay
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Choice is a single option in a MultiChoiceOverlay
+type Choice struct {
+	// Key is the key the user presses to select this choice
+	Key string
+	// Label describes the choice
+	Label string
+}
+
+// MultiChoiceOverlay represents a dialog overlay with more than two options
+type MultiChoiceOverlay struct {
+	// Whether the overlay has been dismissed
+	Dismissed bool
+	// Message to display in the overlay
+	message string
+	// Choices the user can pick from
+	choices []Choice
+	// selected is the key of the selected choice. Empty if the overlay was canceled.
+	selected string
+	// Width of the overlay
+	width int
+	// Custom styling options
+	borderColor lipgloss.Color
+}
+
+// NewMultiChoiceOverlay creates a new multi-choice dialog overlay with the given message and choices.
+// Pressing esc cancels the dialog.
+func NewMultiChoiceOverlay(message string, choices []Choice) *MultiChoiceOverlay {
+	return &MultiChoiceOverlay{
+		Dismissed:   false,
+		message:     message,
+		choices:     choices,
+		width:       50, // Default width
+		borderColor: lipgloss.Color("#de613e"),
+	}
+}
+
+// HandleKeyPress processes a key press and updates the state
+// Returns true if the overlay should be closed
+func (c *MultiChoiceOverlay) HandleKeyPress(msg tea.KeyMsg) bool {
+	if msg.String() == "esc" {
+		c.Dismissed = true
+		return true
+	}
+	for _, choice := range c.choices {
+		if msg.String() == choice.Key {
+			c.Dismissed = true
+			c.selected = choice.Key
+			return true
+		}
+	}
+	// Ignore other keys
+	return false
+}
+
+// Selected returns the key of the selected choice, or an empty string if the overlay was canceled
+func (c *MultiChoiceOverlay) Selected() string {
+	return c.selected
+}
+
+// Render renders the multi-choice overlay
+func (c *MultiChoiceOverlay) Render(opts ...WhitespaceOption) string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(c.borderColor).
+		Padding(1, 2).
+		Width(c.width)
+
+	keyStyle := lipgloss.NewStyle().Bold(true)
+
+	var b strings.Builder
+	b.WriteString(c.message)
+	b.WriteString("\n")
+	for _, choice := range c.choices {
+		b.WriteString("\n" + keyStyle.Render(choice.Key) + " - " + choice.Label)
+	}
+	b.WriteString("\n\nPress " + keyStyle.Render("esc") + " to cancel")
+
+	return style.Render(b.String())
+}
+
+// SetWidth sets the width of the multi-choice overlay
+func (c *MultiChoiceOverlay) SetWidth(width int) {
+	c.width = width
+}
+
+// SetBorderColor sets the border color of the multi-choice overlay
+func (c *MultiChoiceOverlay) SetBorderColor(color lipgloss.Color) {
+	c.borderColor = color
+}
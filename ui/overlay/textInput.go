@@ -14,6 +14,8 @@ type TextInputOverlay struct {
 	Submitted     bool
 	Canceled      bool
 	OnSubmit      func()
+	// errorMsg is shown below the input, e.g. when the submitted value was rejected
+	errorMsg      string
 	width, height int
 }
 
@@ -98,11 +100,18 @@ func (t *TextInputOverlay) HandleKeyPress(msg tea.KeyMsg) bool {
 	default:
 		if t.FocusIndex == 0 {
 			t.textinput, _ = t.textinput.Update(msg)
+			// The input changed, so the previous error no longer applies
+			t.errorMsg = ""
 		}
 		return false
 	}
 }
 
+// SetPlaceholder sets the placeholder text shown when the input is empty.
+func (t *TextInputOverlay) SetPlaceholder(placeholder string) {
+	t.textinput.Placeholder = placeholder
+}
+
 // GetValue returns the current value of the text input.
 func (t *TextInputOverlay) GetValue() string {
 	return t.textinput.Value()
@@ -113,6 +122,15 @@ func (t *TextInputOverlay) IsSubmitted() bool {
 	return t.Submitted
 }
 
+// SetError rejects the submitted value: the error is shown below the input and the overlay goes back to accepting
+// input instead of closing.
+func (t *TextInputOverlay) SetError(msg string) {
+	t.errorMsg = msg
+	t.Submitted = false
+	t.FocusIndex = 0
+	t.textinput.Focus()
+}
+
 // IsCanceled returns whether the form was canceled.
 func (t *TextInputOverlay) IsCanceled() bool {
 	return t.Canceled
@@ -136,6 +154,9 @@ func (t *TextInputOverlay) Render() string {
 		Bold(true).
 		MarginBottom(1)
 
+	errorStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FF0000"))
+
 	buttonStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("7"))
 
@@ -149,7 +170,11 @@ func (t *TextInputOverlay) Render() string {
 
 	// Build the view
 	content := titleStyle.Render(t.Title) + "\n"
-	content += t.textinput.View() + "\n\n"
+	content += t.textinput.View() + "\n"
+	if t.errorMsg != "" {
+		content += errorStyle.Render(t.errorMsg) + "\n"
+	}
+	content += "\n"
 
 	// Render enter button with appropriate style
 	enterButton := " Enter "
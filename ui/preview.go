@@ -11,11 +11,42 @@ import (
 var previewPaneStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
 
+var (
+	searchMatchStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("#FFD700")).
+				Foreground(lipgloss.Color("#000000"))
+	currentMatchStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("#FF8C00")).
+				Foreground(lipgloss.Color("#000000")).
+				Bold(true)
+)
+
 type PreviewPane struct {
 	width  int
 	height int
 
 	previewState previewState
+	// search is the search within the preview content. The query is empty when not searching.
+	search previewSearch
+
+	// instance is the instance being previewed, nil if there's no content to capture, e.g. for a paused instance
+	instance *session.Instance
+	// shown is the instance the content was captured from. It's still the previous one until the first capture of a
+	// newly selected instance arrives.
+	shown *session.Instance
+	// capturing is true if capturing the content of instance takes longer than it should
+	capturing bool
+}
+
+type previewSearch struct {
+	// query is the text searched for, case-insensitively
+	query string
+	// matches are the indexes of the lines containing the query
+	matches []int
+	// current is the index in matches of the selected match
+	current int
+	// offset is the first line shown. It follows the selected match so that it's always visible.
+	offset int
 }
 
 type previewState struct {
@@ -36,18 +67,24 @@ func (p *PreviewPane) SetSize(width, maxHeight int) {
 
 // setFallbackState sets the preview state with fallback text and a message
 func (p *PreviewPane) setFallbackState(message string) {
+	p.shown = nil
 	p.previewState = previewState{
 		fallback: true,
 		text:     lipgloss.JoinVertical(lipgloss.Center, FallBackText, "", message),
 	}
 }
 
-// Updates the preview pane content with the tmux pane content
-func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
+// SetInstance selects the instance to preview. It returns true if the content of the instance has to be captured and
+// passed to SetContent; otherwise the fallback text for it is shown.
+func (p *PreviewPane) SetInstance(instance *session.Instance) bool {
+	if instance != p.instance {
+		p.capturing = false
+	}
+	p.instance = nil
 	switch {
 	case instance == nil:
 		p.setFallbackState("No agents running yet. Spin up a new instance with 'n' to get started!")
-		return nil
+		return false
 	case instance.Status == session.Paused:
 		p.setFallbackState(lipgloss.JoinVertical(lipgloss.Center,
 			"Session is paused. Press 'r' to resume.",
@@ -62,24 +99,162 @@ func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
 					instance.Branch,
 				)),
 		))
-		return nil
-	}
-
-	content, err := instance.Preview()
-	if err != nil {
-		return err
-	}
-
-	if len(content) == 0 && !instance.Started() {
+		return false
+	case !instance.Started():
 		p.setFallbackState("Please enter a name for the instance.")
-		return nil
+		return false
 	}
+	p.instance = instance
+	return true
+}
 
+// SetContent shows the content captured from instance, unless another instance was selected in the meantime
+func (p *PreviewPane) SetContent(instance *session.Instance, content string) {
+	if instance != p.instance {
+		return
+	}
+	p.shown = instance
+	p.capturing = false
 	p.previewState = previewState{
 		fallback: false,
 		text:     content,
 	}
-	return nil
+	p.updateMatches()
+}
+
+// SetCapturing marks capturing the content of instance as slow. The last content is kept in the meantime, unless it
+// was captured from another instance.
+func (p *PreviewPane) SetCapturing(instance *session.Instance) {
+	if instance != p.instance {
+		return
+	}
+	p.capturing = true
+	if p.shown != instance {
+		p.setFallbackState("Capturing...")
+	}
+}
+
+// IsCapturing returns true if capturing the content takes longer than it should
+func (p *PreviewPane) IsCapturing() bool {
+	return p.capturing
+}
+
+// SetSearch searches the preview content for query and selects the first match. An empty query ends the search.
+func (p *PreviewPane) SetSearch(query string) {
+	p.search = previewSearch{query: query}
+	p.updateMatches()
+}
+
+// ClearSearch ends the search and scrolls back to the top.
+func (p *PreviewPane) ClearSearch() {
+	p.search = previewSearch{}
+}
+
+// IsSearching returns true if a search is active
+func (p *PreviewPane) IsSearching() bool {
+	return p.search.query != ""
+}
+
+// NextMatch selects the next match, wrapping around to the first one.
+func (p *PreviewPane) NextMatch() {
+	if len(p.search.matches) == 0 {
+		return
+	}
+	p.search.current = (p.search.current + 1) % len(p.search.matches)
+	p.scrollToMatch()
+}
+
+// PrevMatch selects the previous match, wrapping around to the last one.
+func (p *PreviewPane) PrevMatch() {
+	if len(p.search.matches) == 0 {
+		return
+	}
+	p.search.current = (p.search.current - 1 + len(p.search.matches)) % len(p.search.matches)
+	p.scrollToMatch()
+}
+
+// SearchStatus describes the search, e.g. "/error 2/5". Empty when not searching.
+func (p *PreviewPane) SearchStatus() string {
+	if !p.IsSearching() {
+		return ""
+	}
+	if len(p.search.matches) == 0 {
+		return fmt.Sprintf("/%s no matches", p.search.query)
+	}
+	return fmt.Sprintf("/%s %d/%d", p.search.query, p.search.current+1, len(p.search.matches))
+}
+
+// updateMatches finds the lines matching the query in the current content. The content is captured again every
+// preview tick, so the selected match is kept by index and clamped if the content got shorter.
+func (p *PreviewPane) updateMatches() {
+	if !p.IsSearching() {
+		return
+	}
+	p.search.matches = p.search.matches[:0]
+	if !p.previewState.fallback {
+		query := strings.ToLower(p.search.query)
+		for i, line := range strings.Split(p.previewState.text, "\n") {
+			if strings.Contains(strings.ToLower(session.StripANSI(line)), query) {
+				p.search.matches = append(p.search.matches, i)
+			}
+		}
+	}
+	if p.search.current >= len(p.search.matches) {
+		p.search.current = max(len(p.search.matches)-1, 0)
+	}
+	p.scrollToMatch()
+}
+
+// scrollToMatch moves the offset so that the selected match is visible.
+func (p *PreviewPane) scrollToMatch() {
+	if len(p.search.matches) == 0 {
+		p.search.offset = 0
+		return
+	}
+	line := p.search.matches[p.search.current]
+	availableHeight := max(p.height-1, 1)
+	if line < p.search.offset {
+		p.search.offset = line
+	} else if line >= p.search.offset+availableHeight {
+		p.search.offset = line - availableHeight + 1
+	}
+}
+
+// highlightMatches highlights the query in the matching lines. Matching lines lose their own colors, since the
+// highlighting can't be merged with the escape codes in the captured content.
+func (p *PreviewPane) highlightMatches(lines []string) []string {
+	query := strings.ToLower(p.search.query)
+	for n, i := range p.search.matches {
+		if i >= len(lines) {
+			break
+		}
+		style := searchMatchStyle
+		if n == p.search.current {
+			style = currentMatchStyle
+		}
+
+		plain := session.StripANSI(lines[i])
+		lower := strings.ToLower(plain)
+		if len(lower) != len(plain) {
+			// Lowercasing changed the byte offsets, so highlight the whole line instead
+			lines[i] = style.Render(plain)
+			continue
+		}
+
+		var b strings.Builder
+		for {
+			idx := strings.Index(lower, query)
+			if idx < 0 {
+				b.WriteString(plain)
+				break
+			}
+			b.WriteString(plain[:idx])
+			b.WriteString(style.Render(plain[idx : idx+len(query)]))
+			plain, lower = plain[idx+len(query):], lower[idx+len(query):]
+		}
+		lines[i] = b.String()
+	}
+	return lines
 }
 
 // Returns the preview pane content as a string.
@@ -125,6 +300,12 @@ func (p *PreviewPane) String() string {
 	availableHeight := p.height - 1 //  1 for ellipsis
 
 	lines := strings.Split(p.previewState.text, "\n")
+	if p.IsSearching() {
+		lines = p.highlightMatches(lines)
+		if p.search.offset < len(lines) {
+			lines = lines[p.search.offset:]
+		}
+	}
 
 	// Truncate if we have more lines than available height
 	if availableHeight > 0 {
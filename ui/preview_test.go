@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSearchPreview returns a preview pane showing content, as if it had been captured from an instance
+func newSearchPreview(height int, content string) *PreviewPane {
+	p := NewPreviewPane()
+	p.SetSize(80, height)
+	p.previewState = previewState{text: content}
+	return p
+}
+
+func TestPreviewUpdateMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		query   string
+		want    []int
+	}{
+		{
+			name:    "matches case-insensitively",
+			content: "Error: one\nok\nanother error",
+			query:   "ERROR",
+			want:    []int{0, 2},
+		},
+		{
+			name:    "ignores escape codes",
+			content: "\x1b[31mfail\x1b[0med\nfine",
+			query:   "failed",
+			want:    []int{0},
+		},
+		{
+			name:    "escape codes don't match",
+			content: "\x1b[31mred\x1b[0m",
+			query:   "31m",
+		},
+		{
+			name:    "no matches",
+			content: "one\ntwo",
+			query:   "three",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newSearchPreview(10, tt.content)
+			p.SetSearch(tt.query)
+			assert.Equal(t, tt.want, p.search.matches)
+			assert.Equal(t, 0, p.search.current)
+		})
+	}
+
+	t.Run("fallback text is not searched", func(t *testing.T) {
+		p := newSearchPreview(10, "")
+		p.setFallbackState("Session is paused")
+		p.SetSearch("paused")
+		assert.Empty(t, p.search.matches)
+		assert.Equal(t, "/paused no matches", p.SearchStatus())
+	})
+
+	t.Run("selected match is clamped when the content gets shorter", func(t *testing.T) {
+		p := newSearchPreview(10, "a\na\na")
+		p.SetSearch("a")
+		p.PrevMatch()
+		assert.Equal(t, 2, p.search.current)
+
+		p.previewState.text = "a\nb"
+		p.updateMatches()
+		assert.Equal(t, []int{0}, p.search.matches)
+		assert.Equal(t, 0, p.search.current)
+		assert.Equal(t, "/a 1/1", p.SearchStatus())
+	})
+}
+
+func TestPreviewMatchNavigation(t *testing.T) {
+	tests := []struct {
+		name        string
+		moves       []string
+		wantCurrent int
+	}{
+		{name: "starts at the first match", wantCurrent: 0},
+		{name: "n selects the next match", moves: []string{"n"}, wantCurrent: 1},
+		{name: "n wraps around to the first match", moves: []string{"n", "n", "n"}, wantCurrent: 0},
+		{name: "N wraps around to the last match", moves: []string{"N"}, wantCurrent: 2},
+		{name: "N after n goes back", moves: []string{"n", "n", "N"}, wantCurrent: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newSearchPreview(10, "match\nx\nmatch\nx\nmatch")
+			p.SetSearch("match")
+			for _, move := range tt.moves {
+				if move == "n" {
+					p.NextMatch()
+				} else {
+					p.PrevMatch()
+				}
+			}
+			assert.Equal(t, tt.wantCurrent, p.search.current)
+		})
+	}
+
+	t.Run("n and N do nothing without matches", func(t *testing.T) {
+		p := newSearchPreview(10, "one")
+		p.SetSearch("two")
+		p.NextMatch()
+		p.PrevMatch()
+		assert.Equal(t, 0, p.search.current)
+	})
+
+	t.Run("esc ends the search", func(t *testing.T) {
+		p := newSearchPreview(3, "x\nx\nx\nx\nmatch")
+		p.SetSearch("match")
+		assert.True(t, p.IsSearching())
+		assert.Equal(t, 3, p.search.offset)
+
+		p.ClearSearch()
+		assert.False(t, p.IsSearching())
+		assert.Empty(t, p.search.matches)
+		assert.Equal(t, 0, p.search.offset)
+		assert.Equal(t, "", p.SearchStatus())
+	})
+}
+
+func TestPreviewScrollToMatch(t *testing.T) {
+	// A height of 4 leaves 3 lines for the content
+	tests := []struct {
+		name       string
+		offset     int
+		match      int
+		wantOffset int
+	}{
+		{name: "visible match keeps the offset", offset: 2, match: 3, wantOffset: 2},
+		{name: "match above scrolls up to it", offset: 5, match: 1, wantOffset: 1},
+		{name: "match below scrolls down until it's the last line", offset: 0, match: 7, wantOffset: 5},
+		{name: "match just below the last line", offset: 0, match: 3, wantOffset: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newSearchPreview(4, "")
+			p.search = previewSearch{query: "q", matches: []int{tt.match}, offset: tt.offset}
+			p.scrollToMatch()
+			assert.Equal(t, tt.wantOffset, p.search.offset)
+		})
+	}
+
+	t.Run("no matches scrolls back to the top", func(t *testing.T) {
+		p := newSearchPreview(4, "")
+		p.search = previewSearch{query: "q", offset: 3}
+		p.scrollToMatch()
+		assert.Equal(t, 0, p.search.offset)
+	})
+}
+
+func TestPreviewHighlightMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		query   string
+		current int
+		want    []string
+	}{
+		{
+			name:    "highlights every occurrence and keeps the case",
+			content: "an Error and an error",
+			query:   "error",
+			want: []string{
+				"an " + currentMatchStyle.Render("Error") + " and an " + currentMatchStyle.Render("error"),
+			},
+		},
+		{
+			name:    "other matches are highlighted differently from the current one",
+			content: "foo\nbar\nfoo bar",
+			query:   "foo",
+			current: 1,
+			want:    []string{searchMatchStyle.Render("foo"), "bar", currentMatchStyle.Render("foo") + " bar"},
+		},
+		{
+			name:    "matching lines lose their colors, other lines keep them",
+			content: "\x1b[31mhit\x1b[0m\n\x1b[32mmiss\x1b[0m",
+			query:   "hit",
+			want:    []string{currentMatchStyle.Render("hit"), "\x1b[32mmiss\x1b[0m"},
+		},
+		{
+			name:    "lines whose length changes when lowercased are highlighted as a whole",
+			content: "İstanbul",
+			query:   "stan",
+			want:    []string{currentMatchStyle.Render("İstanbul")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newSearchPreview(10, tt.content)
+			p.SetSearch(tt.query)
+			p.search.current = tt.current
+			assert.Equal(t, tt.want, p.highlightMatches(strings.Split(tt.content, "\n")))
+		})
+	}
+}
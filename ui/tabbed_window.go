@@ -1,7 +1,10 @@
 package ui
 
 import (
+	"github.com/smtg-ai/claude-squad/humanize"
 	"github.com/smtg-ai/claude-squad/session"
+	"fmt"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -48,6 +51,8 @@ type TabbedWindow struct {
 	activeTab int
 	height    int
 	width     int
+	// runtime describes how long the selected instance has been around. Shown next to the preview tab name.
+	runtime string
 
 	preview *PreviewPane
 	diff    *DiffPane
@@ -93,12 +98,49 @@ func (w *TabbedWindow) Toggle() {
 	w.activeTab = (w.activeTab + 1) % len(w.tabs)
 }
 
-// UpdatePreview updates the content of the preview pane. instance may be nil.
-func (w *TabbedWindow) UpdatePreview(instance *session.Instance) error {
+// UpdateRuntime updates the age and last activity of the selected instance shown in the preview tab, and how many
+// restarts it has left once it has been restarted. instance may be nil.
+func (w *TabbedWindow) UpdateRuntime(instance *session.Instance, maxRestartAttempts int, restartCooldown time.Duration) {
+	if instance == nil || !instance.Started() {
+		w.runtime = ""
+		return
+	}
+
+	w.runtime = fmt.Sprintf("%s old", instance.GetAgeFormatted())
+	if instance.Status != session.Paused {
+		if sinceActivity := instance.GetTimeSinceActivityFormatted(); sinceActivity != "" {
+			w.runtime += fmt.Sprintf(", active %s", sinceActivity)
+		}
+		if sinceRestart := instance.GetLastRestartFormatted(); sinceRestart != "" {
+			w.runtime += fmt.Sprintf(", restarted %s", sinceRestart)
+			if left, retryIn := instance.RestartsLeft(maxRestartAttempts, restartCooldown); left == 0 {
+				w.runtime += fmt.Sprintf(", no restarts left for %s", retryIn.Round(time.Second))
+			} else if left < maxRestartAttempts {
+				w.runtime += fmt.Sprintf(" (%d/%d restarts left)", left, maxRestartAttempts)
+			}
+		}
+	} else {
+		w.runtime += fmt.Sprintf(", paused %s", humanize.RelativeTime(instance.UpdatedAt))
+	}
+}
+
+// UpdatePreview selects the instance shown in the preview pane. It returns true if the content of the instance has to be
+// captured and passed to SetPreviewContent. instance may be nil.
+func (w *TabbedWindow) UpdatePreview(instance *session.Instance) bool {
 	if w.activeTab != PreviewTab {
-		return nil
+		return false
 	}
-	return w.preview.UpdateContent(instance)
+	return w.preview.SetInstance(instance)
+}
+
+// SetPreviewContent shows the content captured from instance in the preview pane
+func (w *TabbedWindow) SetPreviewContent(instance *session.Instance, content string) {
+	w.preview.SetContent(instance, content)
+}
+
+// SetPreviewCapturing marks capturing the content of instance for the preview pane as slow
+func (w *TabbedWindow) SetPreviewCapturing(instance *session.Instance) {
+	w.preview.SetCapturing(instance)
 }
 
 func (w *TabbedWindow) UpdateDiff(instance *session.Instance) {
@@ -121,6 +163,31 @@ func (w *TabbedWindow) ScrollDown() {
 	}
 }
 
+// SetPreviewSearch searches the preview content for query. An empty query ends the search.
+func (w *TabbedWindow) SetPreviewSearch(query string) {
+	w.preview.SetSearch(query)
+}
+
+// ClearPreviewSearch ends the search in the preview.
+func (w *TabbedWindow) ClearPreviewSearch() {
+	w.preview.ClearSearch()
+}
+
+// IsPreviewSearching returns true if the preview tab is active and a search is in progress
+func (w *TabbedWindow) IsPreviewSearching() bool {
+	return w.activeTab == PreviewTab && w.preview.IsSearching()
+}
+
+// NextPreviewMatch selects the next search match in the preview.
+func (w *TabbedWindow) NextPreviewMatch() {
+	w.preview.NextMatch()
+}
+
+// PrevPreviewMatch selects the previous search match in the preview.
+func (w *TabbedWindow) PrevPreviewMatch() {
+	w.preview.PrevMatch()
+}
+
 // IsInDiffTab returns true if the diff tab is currently active
 func (w *TabbedWindow) IsInDiffTab() bool {
 	return w.activeTab == 1
@@ -162,6 +229,21 @@ func (w *TabbedWindow) String() string {
 		}
 		style = style.Border(border)
 		style = style.Width(width - 1)
+		if i == PreviewTab && w.preview.IsCapturing() {
+			if withCapturing := t + " (capturing...)"; len(withCapturing) <= width-3 {
+				t = withCapturing
+			}
+		} else if i == PreviewTab && w.preview.IsSearching() {
+			// The search status is more useful than the runtime while searching
+			if withSearch := fmt.Sprintf("%s (%s)", t, w.preview.SearchStatus()); len(withSearch) <= width-3 {
+				t = withSearch
+			}
+		} else if i == PreviewTab && w.runtime != "" {
+			// Only show the runtime if it fits, otherwise the tab wraps onto another line.
+			if withRuntime := fmt.Sprintf("%s (%s)", t, w.runtime); len(withRuntime) <= width-3 {
+				t = withRuntime
+			}
+		}
 		renderedTabs = append(renderedTabs, style.Render(t))
 	}
 